@@ -1,18 +1,26 @@
 package main
 
 import (
-	"fmt"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
+	"mmo-server/internal/metrics"
 	"mmo-server/internal/network"
+	"mmo-server/internal/world"
 )
 
 // Configuración del servidor
 const (
-	TickRate = 30                     // Cuántas veces por segundo se actualiza el mundo (30Hz)
-	TickTime = time.Second / TickRate // La duración exacta de cada tick (aprox 33.3ms)
+	TickRate    = 30                     // Cuántas veces por segundo se actualiza el mundo (30Hz)
+	TickTime    = time.Second / TickRate // La duración exacta de cada tick (aprox 33.3ms)
+	MetricsAddr = ":9100"                // HTTP para Prometheus (GET /metrics)
+
+	// Si un jugador no manda NADA (ni movimiento ni latido) durante este tiempo, lo desconectamos.
+	// El cliente debería mandar un latido cada ~1s: 10s deja margen para paquetes perdidos.
+	PlayerTimeout = 10 * time.Second
 )
 
 // RawPacket representa un paquete tal cual llega del socket, antes de ser procesado
@@ -22,8 +30,26 @@ type RawPacket struct {
 }
 
 func main() {
+	// 0. Logger estructurado: cada línea lleva nivel y campos (clave=valor) que se pueden filtrar
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
 	// 1. Inicializamos el Connection Manager (El que sabe quién está conectado)
-	connMgr := network.NewConnectionManager()
+	connMgr := network.NewConnectionManager(logger)
+
+	// 1a. El Mundo: la posición VERDADERA de cada jugador (la decide el servidor, no el cliente)
+	gameWorld := world.New(world.DefaultConfig(), logger)
+	snapshots := network.NewSnapshotHistory(network.SnapshotHistorySize) // Las últimas fotos (baselines de los deltas)
+
+	// 1b. Métricas (tick, paquetes, jugadores) en un puerto HTTP aparte
+	reg := metrics.NewRegistry()
+	stats := newServerMetrics(reg, connMgr)
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", reg.Handler())
+		if err := http.ListenAndServe(MetricsAddr, mux); err != nil {
+			logger.Warn("métricas deshabilitadas", "error", err)
+		}
+	}()
 
 	// 2. Definimos dónde va a escuchar el servidor (Cualquier IP, puerto 8080)
 	addr := net.UDPAddr{
@@ -34,14 +60,16 @@ func main() {
 	// 3. Abrimos el socket UDP
 	conn, err := net.ListenUDP("udp", &addr)
 	if err != nil {
-		fmt.Printf("❌ Error inicializando el socket: %v\n", err)
+		logger.Error("error inicializando el socket", "error", err)
 		os.Exit(1)
 	}
 	defer conn.Close() // Se asegura de cerrar el puerto al terminar el programa
 
-	fmt.Printf("🚀 MMO Game Server iniciado - Fase 0 Completa\n")
-	fmt.Printf("📡 Escuchando en UDP %s\n", conn.LocalAddr().String())
-	fmt.Printf("💓 Tick Rate: %d Hz\n", TickRate)
+	logger.Info("MMO Game Server iniciado",
+		"udp_addr", conn.LocalAddr().String(),
+		"tick_rate_hz", TickRate,
+		"metrics", "http://localhost"+MetricsAddr+"/metrics",
+	)
 
 	// Canal de Go: Es como una tubería para pasar datos entre diferentes partes del programa
 	// Aquí lo usamos para pasar paquetes desde el socket al bucle principal
@@ -58,6 +86,7 @@ func main() {
 			// Copiamos los bytes recibidos a una nueva rebanada (slice) para no sobrescribirlos
 			data := make([]byte, n)
 			copy(data, buffer[:n])
+			stats.packetsIn.Inc()
 
 			// Metemos el paquete en la "tubería" (canal)
 			packetChan <- RawPacket{Addr: remoteAddr, Data: data}
@@ -74,34 +103,99 @@ func main() {
 	// BUCLE PRINCIPAL (El corazón del servidor)
 	for range ticker.C {
 		tickCount++
+		start := time.Now()
 
-		// En cada tick, procesamos todos los paquetes que hayan llegado desde el último tick
-		processPackets(packetChan, connMgr, &nextPlayerID, conn)
+		// 1. En cada tick, procesamos todos los paquetes que hayan llegado desde el último tick
+		processPackets(packetChan, connMgr, gameWorld, &nextPlayerID, conn, stats, logger)
+
+		// Una vez por segundo (cada 30 ticks) buscamos jugadores que dejaron de responder
+		if tickCount%TickRate == 0 {
+			evictIdlePlayers(connMgr, gameWorld, conn, stats, logger)
+		}
+
+		// 2. Validamos los movimientos pedidos (y corregimos a quien pidió algo imposible)
+		simulateWorld(gameWorld, connMgr, conn, stats, start)
+
+		// 3. Una foto del mundo por tick: a cada cliente le va solo lo que cambió para él
+		broadcastSnapshot(uint32(tickCount), gameWorld, snapshots, connMgr, conn, stats, start)
+
+		// Reenviamos lo confiable que no fue confirmado y confirmamos lo que recibimos
+		resent, acks := connMgr.FlushReliable(conn, time.Now())
+		stats.packetsResent.Add(uint64(resent))
+		stats.packetsOut.Add(uint64(resent + acks))
+
+		// Medimos cuánto tardó el tick: si supera TickTime, el mundo se atrasa (jitter)
+		elapsed := time.Since(start)
+		stats.ticks.Inc()
+		stats.tickDuration.Observe(elapsed.Seconds())
+		if elapsed > TickTime {
+			stats.tickOverruns.Inc()
+		}
 
 		// Imprimimos estadísticas cada 5 segundos (150 ticks)
 		if tickCount%150 == 0 {
-			fmt.Printf("📊 Tick #%d - Jugadores activos: %d\n", tickCount, connMgr.TotalPlayers())
+			logger.Info("estado del servidor", "tick", tickCount, "players", connMgr.TotalPlayers())
 		}
 	}
 }
 
 // processPackets vacía el canal de paquetes y los procesa según su tipo
-func processPackets(ch chan RawPacket, cm *network.ConnectionManager, nextID *uint64, conn *net.UDPConn) {
+func processPackets(ch chan RawPacket, cm *network.ConnectionManager, w *world.World, nextID *uint64, conn *net.UDPConn, stats *serverMetrics, logger *slog.Logger) {
 	for {
 		select {
 		case rp := <-ch:
-			// 1. Deserializamos la cabecera (los primeros 13 bytes comunes a todo paquete)
+			// 1. Deserializamos la cabecera (los primeros HeaderSize bytes, comunes a todo paquete)
 			header, err := network.DeserializeHeader(rp.Data)
 			if err != nil {
+				stats.packetsInvalid.Inc()
 				continue // Si el paquete es basura, lo ignoramos
 			}
 
-			// 2. Dependiendo del tipo de paquete, hacemos una acción u otra
-			switch header.Type {
-			case network.PacketTypeHandshake:
-				handleHandshake(rp.Addr, cm, nextID, conn)
-			case network.PacketTypeMove:
-				handleMove(header.PlayerID, rp.Data, cm, conn)
+			// 2. El Handshake es el único paquete que se acepta sin token (todavía no lo tiene)
+			if header.Type == network.PacketTypeHandshake {
+				handleHandshake(rp.Addr, cm, nextID, conn, stats, logger)
+				continue
+			}
+
+			// 3. Todo lo demás tiene que venir del jugador que dice ser (dirección + PlayerID + token).
+			// Un paquete válido también cuenta como "señal de vida" del jugador.
+			now := time.Now()
+			player, result := cm.Authenticate(rp.Addr, header, now)
+			switch result {
+			case network.AuthNew:
+				stats.sessionsStarted.Inc()
+			case network.AuthRebound:
+				stats.sessionsRebound.Inc()
+			case network.AuthSpoofed:
+				stats.packetsSpoofed.Inc()
+			case network.AuthUnknown, network.AuthBadToken:
+				stats.packetsUnauthenticated.Inc()
+			case network.AuthIPLimit:
+				stats.sessionsRejected.Inc()
+			}
+			if player == nil {
+				// A un desconocido (ej: lo desconectamos por timeout) NO le respondemos:
+				// al no recibir respuesta, el cliente sabe que tiene que volver a hacer Handshake.
+				logger.Debug("paquete rechazado", "reason", result.String(), "addr", rp.Addr.String(),
+					"claimed_player_id", header.PlayerID, "type", header.Type)
+				continue
+			}
+
+			// 4. La capa confiable descarta duplicados y viejos, y ordena lo que tenga que ir en orden.
+			// Un paquete puede "destrabar" varios mensajes (los que esperaban a uno perdido).
+			messages, dropped := player.Endpoint.Receive(header, rp.Data[network.HeaderSize:], now)
+			if dropped {
+				stats.packetsDropped.Inc()
+			}
+
+			// 5. Dependiendo del tipo de mensaje, hacemos una acción u otra
+			for _, msg := range messages {
+				switch msg.Header.Type {
+				case network.PacketTypeMove:
+					handleMove(player, msg, w, stats)
+				case network.PacketTypeHeartbeat:
+					handleHeartbeat(player, msg.Header, conn, stats, now)
+				}
 			}
 		default:
 			// Si no hay más paquetes en el canal, salimos del bucle de procesamiento
@@ -110,31 +204,179 @@ func processPackets(ch chan RawPacket, cm *network.ConnectionManager, nextID *ui
 	}
 }
 
-// handleHandshake se encarga de registrar a un nuevo jugador y darle su ID
-func handleHandshake(addr *net.UDPAddr, cm *network.ConnectionManager, nextID *uint64, conn *net.UDPConn) {
-	player, exists := cm.GetPlayer(addr)
-	id := *nextID
-
-	if !exists {
-		// Si es la primera vez que lo vemos, lo registramos
-		cm.RegisterPlayer(addr, id)
+// handleHandshake le da al cliente su ID y su token de sesión.
+// No registra a nadie: el jugador recién entra al mapa cuando vuelve a escribir con ese token
+// (ver network.ConnectionManager.Handshake). Un Handshake con IP falsa no ocupa memoria.
+func handleHandshake(addr *net.UDPAddr, cm *network.ConnectionManager, nextID *uint64, conn *net.UDPConn, stats *serverMetrics, logger *slog.Logger) {
+	// Si la dirección ya tiene sesión, devuelve la que tenía (mismo ID y token).
+	// Si no, ofrece el próximo ID libre: si el cliente nunca lo usa, ese ID se pierde (hay de sobra).
+	id, token := cm.Handshake(addr, *nextID, time.Now())
+	if id == *nextID {
 		*nextID++
-	} else {
-		// Si ya lo conocíamos, usamos el ID que ya tenía
-		id = player.ID
 	}
 
-	// Creamos la respuesta binaria de "Bienvenida" con el ID asignado
-	response := network.SerializeHandshakeResponse(id)
+	// Creamos la respuesta binaria de "Bienvenida" con el ID y el token asignados.
+	// Mide lo mismo que el Handshake (solo la cabecera): con una IP falsa no sirve para amplificar ataques.
+	response := network.SerializeHandshakeResponse(id, token)
 
 	// Enviamos la respuesta de vuelta por el socket UDP
-	conn.WriteToUDP(response, addr)
-	fmt.Printf("📡 Handshake: ID %d asignado a %v\n", id, addr)
+	if _, err := conn.WriteToUDP(response, addr); err == nil {
+		stats.packetsOut.Inc()
+	}
+	logger.Debug("handshake", "player_id", id, "addr", addr.String()) // El token NUNCA va al log
+}
+
+// handleHeartbeat responde al latido con la hora del servidor (el cliente mide el RTT)
+func handleHeartbeat(player *network.Player, header network.PacketHeader, conn *net.UDPConn, stats *serverMetrics, now time.Time) {
+	response := network.PacketHeader{Type: network.PacketTypeHeartbeat, PlayerID: player.ID}
+	payload := network.HeartbeatResponsePayload(header.Sequence, now)
+	if err := player.Send(conn, response, network.ChannelUnreliable, payload, now); err == nil {
+		stats.packetsOut.Inc()
+	}
+	stats.heartbeats.Inc()
+}
+
+// evictIdlePlayers desconecta a los jugadores que dejaron de mandar paquetes
+// y le avisa al resto para que borren su personaje (Despawn).
+func evictIdlePlayers(cm *network.ConnectionManager, w *world.World, conn *net.UDPConn, stats *serverMetrics, logger *slog.Logger) {
+	for _, p := range cm.EvictIdle(time.Now(), PlayerTimeout) {
+		w.Remove(p.ID)
+		stats.playersEvicted.Inc()
+		logger.Info("jugador desconectado por inactividad", "player_id", p.ID, "addr", p.Addr.String(),
+			"last_seen", p.LastSeen.Format(time.TimeOnly))
+
+		// Canal confiable y ordenado: si el aviso se pierde, el resto vería un "fantasma" para siempre.
+		// Sin payload: la cabecera (PlayerID) ya dice QUIÉN se fue.
+		despawn := network.PacketHeader{Type: network.PacketTypeDespawn, PlayerID: p.ID}
+		sent := cm.Broadcast(despawn, network.ChannelReliableOrdered, nil, p.ID, conn, time.Now())
+		stats.packetsOut.Add(uint64(sent))
+	}
 }
 
-// handleMove se encarga de recibir una posición y avisar al resto de jugadores (Replicación)
-func handleMove(playerID uint64, data []byte, cm *network.ConnectionManager, conn *net.UDPConn) {
-	// Simplemente enviamos los mismos bytes que recibimos a todos los demás jugadores
-	// Esto es el "Broadcast" o Replicación
-	cm.Broadcast(data, playerID, conn)
+// handleMove recibe un PEDIDO de movimiento y lo deja esperando al próximo paso del mundo.
+// Acá no se reenvía nada: los demás se enteran por el estado que calcula el servidor (simulateWorld).
+func handleMove(player *network.Player, msg network.Message, w *world.World, stats *serverMetrics) {
+	move, err := network.DeserializeMove(msg.Payload)
+	if err != nil {
+		stats.packetsInvalid.Inc()
+		return
+	}
+
+	// ChannelSeq identifica a este Move: la Corrección le dice al cliente hasta cuál se procesó
+	position := world.Vec3{X: move.X, Y: move.Y, Z: move.Z}
+	if !w.QueueMove(player.ID, msg.Header.ChannelSeq, position, move.Yaw) {
+		stats.packetsInvalid.Inc() // NaN o infinito en las coordenadas
+	}
+}
+
+// simulateWorld avanza el mundo un tick: valida los movimientos pedidos y le manda una Corrección
+// a quien pidió algo imposible. El resto se entera por el snapshot (broadcastSnapshot).
+func simulateWorld(w *world.World, cm *network.ConnectionManager, conn *net.UDPConn, stats *serverMetrics, now time.Time) {
+	res := w.Step(now, TickTime)
+	stats.movesAccepted.Add(uint64(res.Accepted))
+	stats.movesClamped.Add(uint64(res.Clamped))
+	stats.movesRejected.Add(uint64(res.Rejected))
+
+	// Canal unreliable: si una Corrección se pierde, el próximo Move del cliente vuelve a estar
+	// fuera de lugar y sale otra. Cada una trae LastInput, así el cliente ignora las viejas.
+	for _, c := range res.Corrections {
+		player, ok := cm.GetPlayerByID(c.PlayerID)
+		if !ok {
+			continue
+		}
+		header := network.PacketHeader{Type: network.PacketTypeCorrection, PlayerID: c.PlayerID}
+		pos := network.MoveData{X: c.Position.X, Y: c.Position.Y, Z: c.Position.Z, Yaw: c.Yaw}
+		if err := player.Send(conn, header, network.ChannelUnreliable, network.CorrectionPayload(c.LastInput, pos), now); err == nil {
+			stats.packetsOut.Inc()
+			stats.corrections.Inc()
+		}
+	}
+}
+
+// broadcastSnapshot saca la foto del mundo de este tick y le manda a cada cliente el delta
+// contra la última foto que confirmó. Así el ancho de banda depende del Tick Rate y de cuánto
+// cambió el mundo, no de cuántos Moves por segundo manden los clientes.
+func broadcastSnapshot(tick uint32, w *world.World, history *network.SnapshotHistory, cm *network.ConnectionManager, conn *net.UDPConn, stats *serverMetrics, now time.Time) {
+	states := w.States() // Ya vienen ordenados por ID
+	snap := &network.Snapshot{Tick: tick, Entities: make([]network.EntityState, len(states))}
+	for i, s := range states {
+		snap.Entities[i] = network.QuantizeState(s.ID, network.StateData{
+			X: s.Position.X, Y: s.Position.Y, Z: s.Position.Z, Yaw: s.Yaw,
+			VX: s.Velocity.X, VY: s.Velocity.Y, VZ: s.Velocity.Z,
+		})
+	}
+	history.Add(snap)
+
+	packets, bytes, full := cm.SendSnapshot(conn, snap, history, now)
+	stats.packetsOut.Add(uint64(packets))
+	stats.snapshotBytes.Add(uint64(bytes))
+	stats.snapshotsFull.Add(uint64(full))
+}
+
+// serverMetrics son los números que el servidor expone en GET /metrics.
+type serverMetrics struct {
+	ticks          *metrics.Counter
+	tickOverruns   *metrics.Counter // Ticks que tardaron más que TickTime (el mundo se atrasa)
+	tickDuration   *metrics.Histogram
+	packetsIn      *metrics.Counter
+	packetsOut     *metrics.Counter
+	packetsInvalid *metrics.Counter // Paquetes descartados (cabecera inválida)
+	heartbeats     *metrics.Counter
+	playersEvicted *metrics.Counter // Jugadores desconectados por no mandar nada en PlayerTimeout
+
+	packetsSpoofed         *metrics.Counter // PlayerID distinto al de la sesión de esa dirección
+	packetsUnauthenticated *metrics.Counter // Dirección/PlayerID desconocidos o token incorrecto
+	sessionsStarted        *metrics.Counter // Sesiones creadas (primer paquete con el token del Handshake)
+	sessionsRejected       *metrics.Counter // Sesiones rechazadas por superar MaxPlayersPerIP
+	sessionsRebound        *metrics.Counter // Sesiones que cambiaron de dirección (NAT) con token válido
+
+	packetsResent  *metrics.Counter // Mensajes confiables reenviados por falta de Ack
+	packetsDropped *metrics.Counter // Duplicados o más viejos que el último (capa confiable)
+
+	movesAccepted *metrics.Counter // Movimientos válidos
+	movesClamped  *metrics.Counter // Movimientos demasiado rápidos (se avanzó solo lo permitido)
+	movesRejected *metrics.Counter // Teletransportes (se ignoraron)
+	corrections   *metrics.Counter // Correcciones enviadas a los clientes
+
+	snapshotBytes *metrics.Counter // Bytes de snapshots enviados (sin la cabecera): el ancho de banda del mundo
+	snapshotsFull *metrics.Counter // Snapshots enviados completos (el cliente no tenía una baseline confirmada)
+}
+
+// newServerMetrics registra las métricas del servidor.
+// Los jugadores conectados se leen del ConnectionManager al momento de pedir /metrics.
+func newServerMetrics(reg *metrics.Registry, cm *network.ConnectionManager) *serverMetrics {
+	reg.GaugeFunc("mmo_players_connected", "Jugadores conectados.", func() float64 {
+		return float64(cm.TotalPlayers())
+	})
+
+	// Buckets alrededor del presupuesto de un tick (33.3ms a 30Hz)
+	tickBuckets := []float64{.0005, .001, .0025, .005, .01, .02, TickTime.Seconds(), .05, .1}
+
+	return &serverMetrics{
+		ticks:          reg.Counter("mmo_ticks_total", "Ticks ejecutados."),
+		tickOverruns:   reg.Counter("mmo_tick_overruns_total", "Ticks que superaron el tiempo de tick."),
+		tickDuration:   reg.Histogram("mmo_tick_duration_seconds", "Duración del procesamiento de cada tick.", tickBuckets),
+		packetsIn:      reg.Counter("mmo_packets_in_total", "Paquetes UDP recibidos."),
+		packetsOut:     reg.Counter("mmo_packets_out_total", "Paquetes UDP enviados."),
+		packetsInvalid: reg.Counter("mmo_packets_invalid_total", "Paquetes UDP descartados por inválidos."),
+		heartbeats:     reg.Counter("mmo_heartbeats_total", "Latidos respondidos."),
+		playersEvicted: reg.Counter("mmo_players_evicted_total", "Jugadores desconectados por inactividad."),
+
+		packetsSpoofed:         reg.Counter("mmo_packets_spoofed_total", "Paquetes rechazados por PlayerID ajeno."),
+		packetsUnauthenticated: reg.Counter("mmo_packets_unauthenticated_total", "Paquetes rechazados por sesión desconocida o token inválido."),
+		sessionsStarted:        reg.Counter("mmo_sessions_started_total", "Sesiones creadas con un token de Handshake válido."),
+		sessionsRejected:       reg.Counter("mmo_sessions_rejected_total", "Sesiones rechazadas por superar el máximo por IP."),
+		sessionsRebound:        reg.Counter("mmo_sessions_rebound_total", "Sesiones movidas a una nueva dirección (NAT)."),
+
+		packetsResent:  reg.Counter("mmo_packets_resent_total", "Mensajes confiables reenviados por falta de confirmación."),
+		packetsDropped: reg.Counter("mmo_packets_dropped_total", "Paquetes descartados por duplicados o viejos."),
+
+		movesAccepted: reg.Counter("mmo_moves_accepted_total", "Movimientos aceptados por el servidor."),
+		movesClamped:  reg.Counter("mmo_moves_clamped_total", "Movimientos recortados por superar la velocidad máxima."),
+		movesRejected: reg.Counter("mmo_moves_rejected_total", "Movimientos rechazados por teletransporte."),
+		corrections:   reg.Counter("mmo_corrections_total", "Correcciones de posición enviadas."),
+
+		snapshotBytes: reg.Counter("mmo_snapshot_bytes_total", "Bytes de payload de snapshots enviados."),
+		snapshotsFull: reg.Counter("mmo_snapshots_full_total", "Snapshots enviados completos (sin baseline)."),
+	}
 }
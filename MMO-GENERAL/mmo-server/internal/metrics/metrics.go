@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Métricas en formato Prometheus (texto plano en GET /metrics).
+// Prometheus (o un simple curl) lee esta página cada pocos segundos y guarda la historia:
+// así sabemos si el tick se atrasa o cuántos paquetes por segundo entran, sin leer logs.
+//
+// Es una versión mínima (sin librerías externas): contadores, gauges e histogramas sin labels.
+
+// Registry es la lista de métricas del servidor.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(sb *strings.Builder)
+}
+
+// NewRegistry crea un registro vacío.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metrics: %s registrada dos veces", name))
+	}
+	r.metrics[name] = m
+}
+
+// Handler responde GET /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		r.mu.Lock()
+		names := make([]string, 0, len(r.metrics))
+		for name := range r.metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var sb strings.Builder
+		for _, name := range names {
+			r.metrics[name].write(&sb)
+		}
+		r.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(sb.String()))
+	})
+}
+
+// Counter es un número que solo sube (ej: paquetes recibidos).
+// Usa una operación atómica: se puede incrementar desde varias goroutines sin candado.
+type Counter struct {
+	name, help string
+	value      atomic.Uint64
+}
+
+// Counter registra un contador nuevo.
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{name: name, help: help}
+	r.register(name, c)
+	return c
+}
+
+func (c *Counter) Inc()         { c.value.Add(1) }
+func (c *Counter) Add(n uint64) { c.value.Add(n) }
+
+func (c *Counter) write(sb *strings.Builder) {
+	writeHeader(sb, c.name, c.help, "counter")
+	fmt.Fprintf(sb, "%s %d\n", c.name, c.value.Load())
+}
+
+// GaugeFunc registra un valor que sube y baja y se calcula al leer (ej: jugadores conectados).
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, gaugeFunc{name: name, help: help, fn: fn})
+}
+
+type gaugeFunc struct {
+	name, help string
+	fn         func() float64
+}
+
+func (g gaugeFunc) write(sb *strings.Builder) {
+	writeHeader(sb, g.name, g.help, "gauge")
+	fmt.Fprintf(sb, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// Histogram cuenta observaciones por rangos ("buckets"), ej: duración del tick.
+// Con los buckets, Prometheus puede calcular percentiles (p99 del tick).
+type Histogram struct {
+	name, help string
+	bounds     []float64 // Límite superior de cada bucket (ordenados)
+
+	mu     sync.Mutex
+	counts []uint64 // Observaciones por bucket (sin acumular)
+	sum    float64
+	count  uint64
+}
+
+// Histogram registra un histograma con esos límites (ordenados de menor a mayor).
+func (r *Registry) Histogram(name, help string, bounds []float64) *Histogram {
+	h := &Histogram{name: name, help: help, bounds: bounds, counts: make([]uint64, len(bounds))}
+	r.register(name, h)
+	return h
+}
+
+// Observe registra un valor (ej: segundos que tardó el tick).
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v) // Primer bucket con límite >= v
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *Histogram) write(sb *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(sb, h.name, h.help, "histogram")
+	var cumulative uint64 // Prometheus espera buckets ACUMULADOS (le = "menor o igual que")
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		fmt.Fprintf(sb, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(bound), cumulative)
+	}
+	fmt.Fprintf(sb, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(sb, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	fmt.Fprintf(sb, "%s_count %d\n", h.name, h.count)
+}
+
+func writeHeader(sb *strings.Builder, name, help, typ string) {
+	// En el HELP, la barra y el salto de línea se escriben escapados (si no, rompen el formato)
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
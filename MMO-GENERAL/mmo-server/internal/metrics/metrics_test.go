@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// go test ./internal/metrics -update vuelve a escribir el .golden con la salida actual.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// La salida de /metrics tiene que ser EXACTAMENTE la de testdata/metrics.golden.
+func TestMetricsPage(t *testing.T) {
+	r := NewRegistry()
+
+	packets := r.Counter("mmo_packets_received_total", "Paquetes recibidos.")
+	packets.Inc()
+	packets.Add(41)
+	r.Counter("mmo_help_total", "Help con \\ y\nsalto de línea.") // Se escapa en el HELP
+
+	r.GaugeFunc("mmo_players_online", "Jugadores conectados.", func() float64 { return 3 })
+	r.GaugeFunc("mmo_bytes_per_player", "Promedio de bytes.", func() float64 { return 512.5 })
+
+	tick := r.Histogram("mmo_tick_seconds", "Duración del tick.", []float64{0.25, 0.5, 1})
+	for _, v := range []float64{0.125, 0.25, 0.375, 2} { // 0.25 cae en su propio bucket (le = <=)
+		tick.Observe(v)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got, want := rec.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	body, _ := io.ReadAll(rec.Body)
+
+	path := filepath.Join("testdata", "metrics.golden")
+	if *update {
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != string(want) {
+		t.Errorf("/metrics no coincide con %s\n--- got ---\n%s--- want ---\n%s", path, body, want)
+	}
+}
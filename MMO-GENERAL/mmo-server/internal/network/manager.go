@@ -1,42 +1,236 @@
 package network
 
 import (
-	"fmt"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"log/slog"
 	"net"
 	"sync"
+	"time"
 )
 
 // Player representa a un jugador conectado en la memoria del servidor
 type Player struct {
-	ID   uint64       // ID único (ej. 1001)
-	Addr *net.UDPAddr // IP y Puerto (para saber a dónde mandarle paquetes)
+	ID        uint64        // ID único (ej. 1001)
+	Addr      *net.UDPAddr  // IP y Puerto (para saber a dónde mandarle paquetes)
+	Token     uint64        // Token de sesión secreto (solo lo conocen el servidor y ESTE cliente)
+	LastSeen  time.Time     // Cuándo llegó su último paquete (para detectar desconexiones)
+	Endpoint  *Endpoint     // Estado de la capa confiable (secuencias, acks, reenvíos) de su conexión
+	Snapshots *SnapshotAcks // Qué fotos del mundo confirmó (la baseline de su próximo delta)
 }
 
+// Send manda un mensaje a este jugador por el canal indicado (el Endpoint completa la cabecera)
+func (p *Player) Send(conn *net.UDPConn, h PacketHeader, ch Channel, payload []byte, now time.Time) error {
+	_, err := conn.WriteToUDP(p.Endpoint.Send(h, ch, payload, now), p.Addr)
+	return err
+}
+
+const (
+	// CookieWindow es cada cuánto cambia el token que se entrega en el Handshake.
+	// Se aceptan la ventana actual y la anterior: un token sin usar vale entre 30 y 60 segundos.
+	CookieWindow = 30 * time.Second
+
+	// MaxPlayersPerIP limita las sesiones abiertas desde una misma IP.
+	// No es 1: varios jugadores detrás del mismo router (NAT) comparten IP pública.
+	MaxPlayersPerIP = 16
+)
+
 // ConnectionManager es el "Libro de Registro" del servidor
 type ConnectionManager struct {
 	players map[string]*Player // El mapa donde guardamos a los jugadores (Clave: IP:Puerto)
+	byID    map[uint64]*Player // Los mismos jugadores, buscados por PlayerID (para el cambio de IP)
+	perIP   map[string]int     // Cuántas sesiones hay abiertas desde cada IP (sin el puerto)
+	secret  [32]byte           // Clave del servidor para firmar los tokens del Handshake (no sale de la RAM)
 	mu      sync.RWMutex       // Un "Candado" (Mutex) para que dos hilos no toquen el mapa al mismo tiempo
+	log     *slog.Logger       // Logger estructurado (se lo pasa main, así decide el formato)
 }
 
 // NewConnectionManager crea una nueva instancia del gestor
-func NewConnectionManager() *ConnectionManager {
-	return &ConnectionManager{
+func NewConnectionManager(logger *slog.Logger) *ConnectionManager {
+	cm := &ConnectionManager{
 		players: make(map[string]*Player),
+		byID:    make(map[uint64]*Player),
+		perIP:   make(map[string]int),
+		log:     logger.With("component", "network"),
+	}
+	// 💡 Usamos crypto/rand (no math/rand): math/rand es predecible, un atacante podría calcular la clave.
+	// Al reiniciar el servidor cambia la clave: los tokens de Handshake viejos dejan de valer.
+	if _, err := rand.Read(cm.secret[:]); err != nil {
+		panic("no se pudo generar la clave del servidor: " + err.Error())
+	}
+	return cm
+}
+
+// Handshake calcula la respuesta a un Handshake SIN guardar nada en memoria.
+// Devuelve el PlayerID y el token que hay que mandarle al cliente.
+// Si la dirección ya tiene sesión (ej: no le llegó la respuesta y repitió el saludo),
+// devuelve la que ya tenía (mismo ID y token).
+//
+// 🎓 HANDSHAKE SIN ESTADO (SYN cookies): cualquiera puede mandar Handshakes con una IP de origen falsa.
+// Si por cada uno guardáramos un Player, un atacante llenaría la memoria sin recibir nunca la respuesta.
+// En lugar de guardar, FIRMAMOS: el token es HMAC(clave, dirección + PlayerID + ventana de tiempo).
+// El jugador recién existe cuando vuelve a escribirnos con ese token (ver Authenticate):
+// eso prueba que la dirección es suya, porque solo ahí pudo leer la respuesta.
+func (cm *ConnectionManager) Handshake(addr *net.UDPAddr, playerID uint64, now time.Time) (id, token uint64) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	if p, ok := cm.players[addr.String()]; ok {
+		return p.ID, p.Token
+	}
+	return playerID, cm.cookie(addr, playerID, cookieWindow(now))
+}
+
+// cookieWindow numera los intervalos de CookieWindow desde 1970
+func cookieWindow(now time.Time) int64 {
+	return now.UnixNano() / int64(CookieWindow)
+}
+
+// cookie firma (dirección, PlayerID, ventana) con la clave del servidor.
+// Sin la clave no se puede calcular: adivinar un token de 64 bits es 1 en 18 trillones.
+func (cm *ConnectionManager) cookie(addr *net.UDPAddr, playerID uint64, window int64) uint64 {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], playerID)
+	binary.BigEndian.PutUint64(b[8:16], uint64(window))
+
+	mac := hmac.New(sha256.New, cm.secret[:])
+	mac.Write([]byte(addr.String()))
+	mac.Write(b[:])
+	if token := binary.BigEndian.Uint64(mac.Sum(nil)); token != 0 { // 0 significa "sin token"
+		return token
 	}
+	return 1
 }
 
-// RegisterPlayer agrega un nuevo jugador al mapa de forma segura
-func (cm *ConnectionManager) RegisterPlayer(addr *net.UDPAddr, playerID uint64) {
-	cm.mu.Lock()         // Cerramos el candado (Nadie más puede escribir ahora)
-	defer cm.mu.Unlock() // Al terminar la función, se abre el candado automáticamente
+// validCookie acepta un token de la ventana actual o de la anterior
+// (si no, un Handshake que cae justo en el cambio de ventana nunca serviría)
+func (cm *ConnectionManager) validCookie(addr *net.UDPAddr, playerID, token uint64, now time.Time) bool {
+	w := cookieWindow(now)
+	for _, window := range []int64{w, w - 1} {
+		if cm.cookie(addr, playerID, window) == token {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthResult es el veredicto de Authenticate sobre un paquete
+type AuthResult int
+
+const (
+	AuthOK       AuthResult = iota // Dirección, PlayerID y token coinciden
+	AuthNew                        // Primer paquete con el token del Handshake: se creó la sesión
+	AuthRebound                    // Token válido desde una dirección NUEVA (cambió la IP/puerto por NAT)
+	AuthUnknown                    // Nadie registrado con esa dirección ni con ese PlayerID (y no trae un token del Handshake)
+	AuthSpoofed                    // La dirección es de un jugador, pero el paquete dice ser de OTRO
+	AuthBadToken                   // El PlayerID existe, pero el token no es el suyo
+	AuthIPLimit                    // Token del Handshake válido, pero esa IP ya tiene MaxPlayersPerIP sesiones
+)
+
+// String permite loguear el resultado con nombre en vez de número
+func (r AuthResult) String() string {
+	switch r {
+	case AuthOK:
+		return "ok"
+	case AuthNew:
+		return "new"
+	case AuthRebound:
+		return "rebound"
+	case AuthUnknown:
+		return "unknown"
+	case AuthSpoofed:
+		return "spoofed"
+	case AuthBadToken:
+		return "bad_token"
+	case AuthIPLimit:
+		return "ip_limit"
+	}
+	return "invalid"
+}
+
+// Authenticate decide si un paquete viene de verdad del jugador que dice ser.
+// Si es válido, marca al jugador como vivo (LastSeen = now) y lo devuelve.
+// El primer paquete que trae el token del Handshake es el que crea la sesión.
+//
+// 🎓 ANTI-SPOOFING: en UDP cualquiera puede escribir cualquier PlayerID en la cabecera.
+// No le creemos al paquete: le creemos a lo que firmamos en el Handshake (dirección + token).
+//
+// 🎓 NAT REBINDING: el router de un jugador puede cambiarle el puerto (o la IP si pasa de WiFi a 4G).
+// Si llega un paquete desde una dirección desconocida pero con el PlayerID y el token correctos,
+// es el mismo jugador: movemos su sesión a la dirección nueva.
+func (cm *ConnectionManager) Authenticate(addr *net.UDPAddr, header PacketHeader, now time.Time) (*Player, AuthResult) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
 
 	addrStr := addr.String()
-	if _, exists := cm.players[addrStr]; !exists {
-		cm.players[addrStr] = &Player{
-			ID:   playerID,
-			Addr: addr,
+	if p, ok := cm.players[addrStr]; ok {
+		if p.ID != header.PlayerID {
+			return nil, AuthSpoofed
+		}
+		if p.Token != header.Token {
+			return nil, AuthBadToken
 		}
-		fmt.Printf("✅ Jugador %d registrado desde %s\n", playerID, addrStr)
+		p.LastSeen = now
+		return p, AuthOK
+	}
+
+	// Dirección desconocida: ¿es un jugador que cambió de dirección?
+	p, ok := cm.byID[header.PlayerID]
+	if !ok {
+		// ¿O es alguien que acaba de hacer el Handshake y usa por primera vez su token?
+		return cm.register(addr, header, now)
+	}
+	if p.Token != header.Token {
+		return nil, AuthBadToken
+	}
+	oldAddr := p.Addr.String()
+	cm.forget(p)
+	p.Addr = addr
+	p.LastSeen = now
+	cm.track(p)
+	cm.log.Info("sesión movida a una nueva dirección", "player_id", p.ID, "from", oldAddr, "to", addrStr)
+	return p, AuthRebound
+}
+
+// register crea la sesión si el token es el que firmamos en el Handshake para esta dirección y este ID.
+// Se llama con el candado ya tomado.
+func (cm *ConnectionManager) register(addr *net.UDPAddr, header PacketHeader, now time.Time) (*Player, AuthResult) {
+	if header.Token == 0 || !cm.validCookie(addr, header.PlayerID, header.Token, now) {
+		return nil, AuthUnknown
+	}
+	if cm.perIP[addr.IP.String()] >= MaxPlayersPerIP {
+		return nil, AuthIPLimit
+	}
+
+	p := &Player{
+		ID:        header.PlayerID,
+		Addr:      addr,
+		Token:     header.Token, // El token del Handshake queda como token de la sesión
+		LastSeen:  now,
+		Endpoint:  NewEndpoint(),
+		Snapshots: NewSnapshotAcks(),
+	}
+	p.Endpoint.OnAck = p.Snapshots.Acked // Los Acks de la conexión dicen qué snapshots le llegaron
+	cm.track(p)
+	cm.log.Info("jugador registrado", "player_id", p.ID, "addr", addr.String())
+	return p, AuthNew
+}
+
+// track agrega al jugador a los índices (con el candado ya tomado)
+func (cm *ConnectionManager) track(p *Player) {
+	cm.players[p.Addr.String()] = p
+	cm.byID[p.ID] = p
+	cm.perIP[p.Addr.IP.String()]++
+}
+
+// forget lo saca de los índices (con el candado ya tomado)
+func (cm *ConnectionManager) forget(p *Player) {
+	delete(cm.players, p.Addr.String())
+	delete(cm.byID, p.ID)
+	ip := p.Addr.IP.String()
+	if cm.perIP[ip]--; cm.perIP[ip] <= 0 {
+		delete(cm.perIP, ip) // Si no, el mapa crecería con cada IP que pasó alguna vez
 	}
 }
 
@@ -48,11 +242,39 @@ func (cm *ConnectionManager) GetPlayer(addr *net.UDPAddr) (*Player, bool) {
 	return p, ok
 }
 
+// GetPlayerByID busca a un jugador por su PlayerID
+func (cm *ConnectionManager) GetPlayerByID(playerID uint64) (*Player, bool) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	p, ok := cm.byID[playerID]
+	return p, ok
+}
+
+// EvictIdle saca del mapa a los jugadores que no mandan nada hace más de `timeout`
+// y los devuelve (para avisarle al resto que desaparecieron).
+// 💡 UDP no tiene "conexión": si el cliente se cierra o pierde la red, NO nos enteramos.
+// La única forma de saberlo es el silencio. Por eso el cliente manda latidos aunque esté quieto.
+func (cm *ConnectionManager) EvictIdle(now time.Time, timeout time.Duration) []*Player {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	var evicted []*Player
+	for _, p := range cm.players {
+		if now.Sub(p.LastSeen) > timeout {
+			cm.forget(p) // Borrar mientras se recorre un map es seguro en Go
+			evicted = append(evicted, p)
+		}
+	}
+	return evicted
+}
+
 // RemovePlayer elimina a un jugador (ej. si se desconecta)
 func (cm *ConnectionManager) RemovePlayer(addr *net.UDPAddr) {
 	cm.mu.Lock()
 	defer cm.mu.Unlock()
-	delete(cm.players, addr.String())
+	if p, ok := cm.players[addr.String()]; ok {
+		cm.forget(p)
+	}
 }
 
 // TotalPlayers nos dice cuánta gente hay conectada ahora mismo
@@ -62,15 +284,42 @@ func (cm *ConnectionManager) TotalPlayers() int {
 	return len(cm.players)
 }
 
-// Broadcast envía un mismo mensaje a TODOS los jugadores conectados excepto a uno (normalmente el emisor)
-func (cm *ConnectionManager) Broadcast(data []byte, exceptID uint64, conn *net.UDPConn) {
+// Broadcast envía un mismo mensaje a TODOS los jugadores conectados excepto a uno (normalmente el emisor).
+// Devuelve cuántos paquetes se enviaron (para las métricas).
+// 💡 El mensaje es el mismo, pero los bytes NO: cada conexión tiene sus propios Sequence y Acks.
+func (cm *ConnectionManager) Broadcast(h PacketHeader, ch Channel, payload []byte, exceptID uint64, conn *net.UDPConn, now time.Time) int {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
+	sent := 0
 	for _, p := range cm.players {
 		if p.ID != exceptID {
-			// Enviamos los bytes directamente al socket UDP de cada jugador
-			conn.WriteToUDP(data, p.Addr)
+			if err := p.Send(conn, h, ch, payload, now); err == nil {
+				sent++
+			}
+		}
+	}
+	return sent
+}
+
+// FlushReliable se llama una vez por tick: reenvía los mensajes confiables que no fueron confirmados
+// a tiempo y manda un Ack suelto a quien nos mandó algo confiable y no recibió nada desde entonces.
+// Devuelve cuántos reenvíos y cuántos Acks sueltos se enviaron (para las métricas).
+func (cm *ConnectionManager) FlushReliable(conn *net.UDPConn, now time.Time) (resent, acks int) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	for _, p := range cm.players {
+		for _, packet := range p.Endpoint.Resend(now) {
+			if _, err := conn.WriteToUDP(packet, p.Addr); err == nil {
+				resent++
+			}
+		}
+		if p.Endpoint.AckPending() {
+			if err := p.Send(conn, PacketHeader{Type: PacketTypeAck, PlayerID: p.ID}, ChannelUnreliable, nil, now); err == nil {
+				acks++
+			}
 		}
 	}
+	return resent, acks
 }
@@ -0,0 +1,217 @@
+package network
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestManager() *ConnectionManager {
+	return NewConnectionManager(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func fakeAddr(i int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(10, 0, byte(i>>8), byte(i)), Port: 7777}
+}
+
+// listenUDP abre un socket en localhost (se cierra al terminar el test)
+func listenUDP(t *testing.T) *net.UDPConn {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// connect hace lo mismo que un cliente: Handshake y primer paquete con el token recibido
+func connect(t *testing.T, cm *ConnectionManager, addr *net.UDPAddr, id uint64, now time.Time) *Player {
+	t.Helper()
+	id, token := cm.Handshake(addr, id, now)
+	p, result := cm.Authenticate(addr, PacketHeader{Type: PacketTypeHeartbeat, PlayerID: id, Token: token}, now)
+	if p == nil {
+		t.Fatalf("connect %v: Authenticate = %v", addr, result)
+	}
+	return p
+}
+
+// heartbeat autentica un latido del jugador en el instante now
+func heartbeat(cm *ConnectionManager, p *Player, now time.Time) AuthResult {
+	_, result := cm.Authenticate(p.Addr, PacketHeader{Type: PacketTypeHeartbeat, PlayerID: p.ID, Token: p.Token}, now)
+	return result
+}
+
+func TestEvictIdle(t *testing.T) {
+	cm := newTestManager()
+	now := time.Now()
+	timeout := 10 * time.Second
+
+	// 1001 calla un poco más que timeout; 1002 exactamente timeout (todavía no es desconexión:
+	// > y no >=); 1003 está activo
+	players := make([]*Player, 3)
+	for i := range players {
+		players[i] = connect(t, cm, fakeAddr(i+1), uint64(1001+i), now.Add(-time.Minute))
+	}
+	heartbeat(cm, players[0], now.Add(-timeout-time.Millisecond))
+	heartbeat(cm, players[1], now.Add(-timeout))
+	heartbeat(cm, players[2], now)
+
+	evicted := cm.EvictIdle(now, timeout)
+	if len(evicted) != 1 || evicted[0].ID != 1001 {
+		t.Fatalf("evicted %v, want only player 1001", evicted)
+	}
+	if cm.TotalPlayers() != 2 {
+		t.Errorf("TotalPlayers = %d, want 2", cm.TotalPlayers())
+	}
+	if result := heartbeat(cm, players[0], now); result != AuthUnknown {
+		t.Errorf("heartbeat of the evicted player = %v, want %v", result, AuthUnknown)
+	}
+	if evicted := cm.EvictIdle(now, timeout); len(evicted) != 0 {
+		t.Errorf("second EvictIdle evicted %d players, want 0", len(evicted))
+	}
+}
+
+// Al desalojar a un jugador, el resto recibe su Despawn; él (ya fuera del mapa) no.
+func TestEvictIdleBroadcastsDespawn(t *testing.T) {
+	server := listenUDP(t)
+	cm := newTestManager()
+	now := time.Now()
+
+	idle, active := listenUDP(t), listenUDP(t)
+	connect(t, cm, idle.LocalAddr().(*net.UDPAddr), 1001, now.Add(-time.Minute))
+	connect(t, cm, active.LocalAddr().(*net.UDPAddr), 1002, now)
+
+	// Lo mismo que hace el servidor en cada tick (ver evictIdlePlayers en cmd/main.go)
+	evicted := cm.EvictIdle(now, 10*time.Second)
+	if len(evicted) != 1 {
+		t.Fatalf("evicted %d players, want 1", len(evicted))
+	}
+	despawn := PacketHeader{Type: PacketTypeDespawn, PlayerID: evicted[0].ID}
+	if sent := cm.Broadcast(despawn, ChannelReliableOrdered, nil, evicted[0].ID, server, now); sent != 1 {
+		t.Fatalf("Broadcast sent %d packets, want 1", sent)
+	}
+
+	buf := make([]byte, 64)
+	active.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := active.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatalf("active player got nothing: %v", err)
+	}
+	header, err := DeserializeHeader(buf[:n])
+	if err != nil || header.Type != PacketTypeDespawn || header.PlayerID != 1001 {
+		t.Errorf("active player got %+v (%v), want Despawn of 1001", header, err)
+	}
+
+	idle.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := idle.ReadFromUDP(buf); err == nil {
+		t.Errorf("evicted player received %d bytes", n)
+	}
+}
+
+// El token del Handshake solo sirve para la dirección y el ID a los que se le dio, y por tiempo limitado
+func TestHandshakeCookie(t *testing.T) {
+	cm := newTestManager()
+	now := time.Now()
+	id, token := cm.Handshake(fakeAddr(1), 1001, now)
+	if id != 1001 || token == 0 {
+		t.Fatalf("Handshake = (%d, %x), want ID 1001 and a token", id, token)
+	}
+
+	tests := []struct {
+		name  string
+		addr  *net.UDPAddr
+		id    uint64
+		token uint64
+		at    time.Time
+		want  AuthResult
+	}{
+		{"other address", fakeAddr(2), 1001, token, now, AuthUnknown},
+		{"other player id", fakeAddr(1), 1002, token, now, AuthUnknown},
+		{"no token", fakeAddr(1), 1001, 0, now, AuthUnknown},
+		{"wrong token", fakeAddr(1), 1001, token + 1, now, AuthUnknown},
+		{"expired", fakeAddr(1), 1001, token, now.Add(2 * CookieWindow), AuthUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := PacketHeader{Type: PacketTypeHeartbeat, PlayerID: tt.id, Token: tt.token}
+			if _, got := cm.Authenticate(tt.addr, h, tt.at); got != tt.want {
+				t.Errorf("Authenticate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if cm.TotalPlayers() != 0 {
+		t.Errorf("TotalPlayers = %d, want 0 after invalid tokens", cm.TotalPlayers())
+	}
+
+	// Con el token correcto (todavía vale en la ventana siguiente) se crea la sesión;
+	// repetir el Handshake devuelve la misma
+	later := now.Add(CookieWindow)
+	if _, result := cm.Authenticate(fakeAddr(1), PacketHeader{Type: PacketTypeMove, PlayerID: id, Token: token}, later); result != AuthNew {
+		t.Fatalf("first packet with the token = %v, want %v", result, AuthNew)
+	}
+	if id2, token2 := cm.Handshake(fakeAddr(1), 1005, later); id2 != id || token2 != token {
+		t.Errorf("repeated Handshake = (%d, %x), want the existing session (%d, %x)", id2, token2, id, token)
+	}
+}
+
+// Un Handshake no ocupa memoria (una IP falsa nunca recibe el token) y una misma IP no abre sesiones sin límite
+func TestRegisterPlayerCapsUnverified(t *testing.T) {
+	cm := newTestManager()
+	now := time.Now()
+
+	for i := 0; i < 10_000; i++ {
+		cm.Handshake(fakeAddr(i), uint64(1000+i), now)
+	}
+	if cm.TotalPlayers() != 0 {
+		t.Fatalf("TotalPlayers = %d after handshakes only, want 0", cm.TotalPlayers())
+	}
+
+	// Mismo IP, distintos puertos (ej: varios jugadores detrás de un router)
+	sameIP := func(port int) *net.UDPAddr { return &net.UDPAddr{IP: net.IPv4(10, 1, 1, 1), Port: port} }
+	for i := 0; i < MaxPlayersPerIP; i++ {
+		connect(t, cm, sameIP(5000+i), uint64(2000+i), now)
+	}
+	extra := sameIP(6000)
+	id, token := cm.Handshake(extra, 3000, now)
+	if _, result := cm.Authenticate(extra, PacketHeader{Type: PacketTypeHeartbeat, PlayerID: id, Token: token}, now); result != AuthIPLimit {
+		t.Fatalf("session #%d from the same IP = %v, want %v", MaxPlayersPerIP+1, result, AuthIPLimit)
+	}
+
+	// Al irse uno, queda lugar para otro
+	cm.RemovePlayer(sameIP(5000))
+	if _, result := cm.Authenticate(extra, PacketHeader{Type: PacketTypeHeartbeat, PlayerID: id, Token: token}, now); result != AuthNew {
+		t.Errorf("session after a slot freed = %v, want %v", result, AuthNew)
+	}
+	if cm.TotalPlayers() != MaxPlayersPerIP {
+		t.Errorf("TotalPlayers = %d, want %d", cm.TotalPlayers(), MaxPlayersPerIP)
+	}
+}
+
+// Quien solo hizo el Handshake (o lo falsificó) no está en el mapa: no recibe lo que se reenvía al resto
+func TestUnverifiedPlayersReceiveNothing(t *testing.T) {
+	server := listenUDP(t)
+	cm := newTestManager()
+	now := time.Now()
+
+	player, unverified := listenUDP(t), listenUDP(t)
+	connect(t, cm, player.LocalAddr().(*net.UDPAddr), 1001, now)
+	cm.Handshake(unverified.LocalAddr().(*net.UDPAddr), 1002, now)
+
+	despawn := PacketHeader{Type: PacketTypeDespawn, PlayerID: 1003}
+	if sent := cm.Broadcast(despawn, ChannelReliableOrdered, nil, 1003, server, now); sent != 1 {
+		t.Fatalf("Broadcast sent %d packets, want 1", sent)
+	}
+
+	buf := make([]byte, 64)
+	player.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := player.ReadFromUDP(buf); err != nil {
+		t.Fatalf("verified player got nothing: %v", err)
+	}
+	unverified.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if n, _, err := unverified.ReadFromUDP(buf); err == nil {
+		t.Errorf("unverified address received %d bytes", n)
+	}
+}
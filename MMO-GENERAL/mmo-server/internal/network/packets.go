@@ -4,24 +4,37 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math"
+	"time"
 )
 
 // Tipos de paquetes (Protocolo Fase 0)
 const (
-	PacketTypeHandshake uint8 = 0 // El primer saludo
-	PacketTypeMove      uint8 = 1 // Actualización de posición
-	PacketTypeHeartbeat uint8 = 2 // Latido de conexión (proximamente)
+	PacketTypeHandshake  uint8 = 0 // El primer saludo
+	PacketTypeMove       uint8 = 1 // Cliente -> servidor: pedido de movimiento (a dónde quiere ir)
+	PacketTypeHeartbeat  uint8 = 2 // Latido de conexión ("sigo acá"); el servidor responde con su hora
+	PacketTypeDespawn    uint8 = 3 // Servidor -> clientes: este jugador se fue, borralo del mundo
+	PacketTypeAck        uint8 = 4 // Solo confirma recepción (no hay nada más que mandar); sin payload
+	PacketTypeSnapshot   uint8 = 5 // Servidor -> cliente: lo que cambió en el mundo desde su última foto confirmada (ver snapshot.go)
+	PacketTypeCorrection uint8 = 6 // Servidor -> cliente: tu movimiento no era válido, estás ACÁ
 )
 
 // Tamaño del encabezado:
-// 1 byte (Tipo) + 4 bytes (Secuencia) + 8 bytes (PlayerID) = 13 bytes en total
-const HeaderSize = 13
+// 1 (Tipo) + 4 (Secuencia) + 8 (PlayerID) + 8 (Token)
+// + 4 (Ack) + 4 (AckBits) + 1 (Canal) + 4 (Secuencia del canal) = 34 bytes en total
+const HeaderSize = 34
 
 // PacketHeader es lo mínimo que tienen todos nuestros paquetes
 type PacketHeader struct {
 	Type     uint8  // Qué tipo de mensaje es
-	Sequence uint32 // El número de mensaje (para ordenarlos si llegan desordenados)
+	Sequence uint32 // Número de paquete de ESTA conexión (cada envío, incluso un reenvío, usa uno nuevo)
 	PlayerID uint64 // A qué jugador pertenece
+	Token    uint64 // Token de sesión que el servidor entregó en el Handshake (0 antes de tenerlo)
+
+	// Capa de confiabilidad (ver reliable.go). La completa el Endpoint, no el que arma el paquete.
+	Ack        uint32  // El último Sequence que recibimos del otro lado
+	AckBits    uint32  // Bit i = 1 si también recibimos el Sequence (Ack - 1 - i)
+	Channel    Channel // Qué garantías tiene este mensaje (orden, reenvío...)
+	ChannelSeq uint32  // Número de mensaje DENTRO del canal (se repite en los reenvíos)
 }
 
 // DeserializeHeader toma los bytes crudos y los convierte en una estructura entendible
@@ -35,15 +48,45 @@ func DeserializeHeader(data []byte) (PacketHeader, error) {
 		Type:     data[0],
 		Sequence: binary.BigEndian.Uint32(data[1:5]),
 		PlayerID: binary.BigEndian.Uint64(data[5:13]),
+		Token:    binary.BigEndian.Uint64(data[13:21]),
+
+		Ack:        binary.BigEndian.Uint32(data[21:25]),
+		AckBits:    binary.BigEndian.Uint32(data[25:29]),
+		Channel:    Channel(data[29]),
+		ChannelSeq: binary.BigEndian.Uint32(data[30:34]),
 	}, nil
 }
 
-// SerializeHandshakeResponse construye el paquete de respuesta al handshake
-func SerializeHandshakeResponse(playerID uint64) []byte {
+// putHeader escribe la cabecera en los primeros HeaderSize bytes de buf
+func putHeader(buf []byte, h PacketHeader) {
+	buf[0] = h.Type
+	binary.BigEndian.PutUint32(buf[1:5], h.Sequence)
+	binary.BigEndian.PutUint64(buf[5:13], h.PlayerID)
+	binary.BigEndian.PutUint64(buf[13:21], h.Token)
+	binary.BigEndian.PutUint32(buf[21:25], h.Ack)
+	binary.BigEndian.PutUint32(buf[25:29], h.AckBits)
+	buf[29] = uint8(h.Channel)
+	binary.BigEndian.PutUint32(buf[30:34], h.ChannelSeq)
+}
+
+// SerializeHandshakeResponse construye el paquete de respuesta al handshake.
+// Es el ÚNICO paquete que lleva el token de sesión del servidor hacia el cliente:
+// a partir de acá, el cliente lo copia en la cabecera de todo lo que envía.
+func SerializeHandshakeResponse(playerID, token uint64) []byte {
 	buf := make([]byte, HeaderSize)
-	buf[0] = PacketTypeHandshake
-	binary.BigEndian.PutUint32(buf[1:5], 0) // Secuencia 0 para respuestas simples
-	binary.BigEndian.PutUint64(buf[5:13], playerID)
+	// Secuencia 0 para respuestas simples
+	putHeader(buf, PacketHeader{Type: PacketTypeHandshake, PlayerID: playerID, Token: token})
+	return buf
+}
+
+// HeartbeatResponsePayload arma el payload de la respuesta a un latido (12 bytes):
+// 4 bytes con la Secuencia del latido del cliente + 8 bytes con la hora del servidor (ms Unix).
+// Con la Secuencia el cliente sabe a qué latido corresponde y calcula el RTT (ida y vuelta)
+// = ahora - cuándo lo envió. La hora del servidor le sirve para estimar la diferencia de relojes.
+func HeartbeatResponsePayload(clientSequence uint32, serverTime time.Time) []byte {
+	buf := make([]byte, 12)
+	binary.BigEndian.PutUint32(buf[0:4], clientSequence)
+	binary.BigEndian.PutUint64(buf[4:12], uint64(serverTime.UnixMilli()))
 	return buf
 }
 
@@ -52,16 +95,16 @@ type MoveData struct {
 	X, Y, Z, Yaw float32
 }
 
-// DeserializeMove extrae las coordenadas de un paquete de tipo Move
-func DeserializeMove(data []byte) (MoveData, error) {
-	// Un paquete de movimiento tiene: Header (13) + Payload (16) = 29 bytes
-	if len(data) < HeaderSize+16 {
+// MovePayloadSize: 4 floats (X, Y, Z, Yaw) de 4 bytes cada uno
+const MovePayloadSize = 16
+
+// DeserializeMove extrae las coordenadas del payload de un mensaje de tipo Move
+// (lo que viene DESPUÉS de la cabecera: el paquete completo mide HeaderSize + 16 bytes)
+func DeserializeMove(payload []byte) (MoveData, error) {
+	if len(payload) < MovePayloadSize {
 		return MoveData{}, fmt.Errorf("paquete de movimiento incompleto")
 	}
 
-	// El payload empieza después de los 13 bytes de la cabecera
-	payload := data[HeaderSize : HeaderSize+16]
-
 	return MoveData{
 		X:   Float32frombytes(payload[0:4]),
 		Y:   Float32frombytes(payload[4:8]),
@@ -70,6 +113,25 @@ func DeserializeMove(data []byte) (MoveData, error) {
 	}, nil
 }
 
+// StateData es el estado de un jugador tal como lo calculó el servidor (antes de cuantizarlo, ver QuantizeState)
+type StateData struct {
+	X, Y, Z, Yaw float32
+	VX, VY, VZ   float32 // Velocidad (cm/s): el cliente la usa para extrapolar entre actualizaciones
+}
+
+// CorrectionPayloadSize: 4 bytes (último Move procesado) + 12 bytes (X, Y, Z en mm) + 2 bytes (Yaw)
+const CorrectionPayloadSize = 18
+
+// CorrectionPayload arma el payload de una Corrección (posición cuantizada igual que en los snapshots).
+// lastInput es el ChannelSeq del último Move del cliente que el servidor procesó:
+// el cliente vuelve a aplicar, desde la posición corregida, los Moves que envió DESPUÉS de ese.
+func CorrectionPayload(lastInput uint32, pos MoveData) []byte {
+	q := QuantizeState(0, StateData{X: pos.X, Y: pos.Y, Z: pos.Z, Yaw: pos.Yaw})
+	buf := binary.BigEndian.AppendUint32(make([]byte, 0, CorrectionPayloadSize), lastInput)
+	buf = appendInt32s(buf, q.X, q.Y, q.Z)
+	return binary.BigEndian.AppendUint16(buf, q.Yaw)
+}
+
 // Float32frombytes convierte 4 bytes en un número decimal de 32 bits (Estándar IEEE 754)
 func Float32frombytes(bytes []byte) float32 {
 	bits := binary.BigEndian.Uint32(bytes)
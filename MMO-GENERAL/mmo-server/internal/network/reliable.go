@@ -0,0 +1,390 @@
+package network
+
+import (
+	"time"
+)
+
+// 🎓 UDP CONFIABLE (custom)
+// UDP no garantiza nada: un paquete puede perderse, llegar dos veces o llegar después de uno
+// más nuevo. TCP lo arregla todo, pero si se pierde UN paquete frena a todos los siguientes
+// (y una posición vieja ya no sirve de nada). Por eso armamos nuestra propia capa, y cada
+// mensaje elige qué garantías necesita (su Canal).
+//
+// Cómo funciona (el esquema de "ack + ack bits" que usan muchos juegos):
+//  1. Cada paquete que sale lleva un Sequence nuevo (1, 2, 3...).
+//  2. Cada paquete que sale también dice qué recibimos del otro lado:
+//     Ack = el último Sequence recibido, AckBits = cuáles de los 32 anteriores también llegaron.
+//     Así un solo paquete confirma hasta 33, y si se pierde una confirmación, la siguiente la repite.
+//  3. Los mensajes confiables que no se confirman a tiempo se REENVÍAN (con un Sequence nuevo,
+//     pero el mismo ChannelSeq: así el receptor detecta el duplicado).
+//  4. El tiempo de espera antes de reenviar sale del RTT medido con esas mismas confirmaciones.
+
+// Channel define las garantías de entrega de un mensaje
+type Channel uint8
+
+const (
+	// ChannelUnreliable: se manda una vez y ya. Sirve para latidos y confirmaciones sueltas.
+	ChannelUnreliable Channel = iota
+	// ChannelUnreliableSequenced: sin reenvío, pero si llega uno más viejo que el último, se descarta.
+	// Ideal para posiciones: solo importa la más nueva.
+	ChannelUnreliableSequenced
+	// ChannelReliableUnordered: se reenvía hasta que llegue; se entrega apenas llega (sin esperar a otros).
+	ChannelReliableUnordered
+	// ChannelReliableOrdered: se reenvía hasta que llegue y se entrega en el orden en que se envió.
+	// Ideal para eventos que no se pueden perder ni desordenar (ej: Despawn, chat, inventario).
+	ChannelReliableOrdered
+
+	numChannels
+)
+
+// String permite loguear el canal con nombre
+func (c Channel) String() string {
+	switch c {
+	case ChannelUnreliable:
+		return "unreliable"
+	case ChannelUnreliableSequenced:
+		return "unreliable_sequenced"
+	case ChannelReliableUnordered:
+		return "reliable_unordered"
+	case ChannelReliableOrdered:
+		return "reliable_ordered"
+	}
+	return "invalid"
+}
+
+// reliable indica si los mensajes del canal se reenvían hasta ser confirmados
+func (c Channel) reliable() bool {
+	return c == ChannelReliableUnordered || c == ChannelReliableOrdered
+}
+
+// Límites de la capa de confiabilidad
+const (
+	ackWindow       = 32                     // Cuántos paquetes anteriores al Ack caben en AckBits
+	sentHistory     = 256                    // Cuántos paquetes enviados recordamos esperando su Ack
+	maxReliableGap  = 1024                   // Cuántos mensajes "adelantados" aceptamos esperando al que falta
+	initialRTO      = 250 * time.Millisecond // Espera antes del primer reenvío (todavía no medimos el RTT)
+	minRTO          = 50 * time.Millisecond
+	maxRTO          = 2 * time.Second
+	rttSmoothing    = 8 // El RTT promedio se mueve 1/8 hacia cada medición nueva (como TCP)
+	rttVarSmoothing = 4
+)
+
+// Message es un mensaje ya entregado por la capa de confiabilidad (sin duplicados y, si el canal lo pide, en orden)
+type Message struct {
+	Header  PacketHeader
+	Payload []byte
+}
+
+// sentPacket recuerda un paquete enviado hasta que llegue su Ack
+type sentPacket struct {
+	sentAt  time.Time
+	message *outgoing // El mensaje confiable que viajaba en el paquete (nil si no era confiable)
+}
+
+// outgoing es un mensaje confiable que todavía no fue confirmado
+type outgoing struct {
+	header   PacketHeader // Tipo, PlayerID, Token, Canal y ChannelSeq (lo demás cambia en cada reenvío)
+	payload  []byte
+	lastSent time.Time
+	sends    int
+	acked    bool
+}
+
+// receiveChannel es el estado de recepción de un canal
+type receiveChannel struct {
+	started bool               // Ya llegó al menos un mensaje (para el canal secuenciado)
+	last    uint32             // Secuenciado: el ChannelSeq más nuevo entregado
+	next    uint32             // Confiables: todo lo anterior a `next` ya se entregó
+	pending map[uint32]Message // Ordenado: mensajes adelantados esperando al que falta
+	seen    map[uint32]bool    // No ordenado: entregados por encima de `next` (para detectar reenvíos)
+}
+
+// Endpoint es el estado de confiabilidad de UNA conexión (un jugador, visto desde el servidor).
+// ⚠️ No es seguro entre goroutines: el servidor lo usa solo desde el Tick Loop.
+type Endpoint struct {
+	// Envío
+	localSeq   uint32                 // Último Sequence usado (el primero es 1: Ack = 0 significa "nada todavía")
+	sendSeq    [numChannels]uint32    // Próximo ChannelSeq de cada canal
+	sent       map[uint32]*sentPacket // Paquetes enviados esperando Ack
+	unacked    []*outgoing            // Mensajes confiables sin confirmar
+	ackPending bool                   // Recibimos algo confiable y todavía no lo confirmamos
+
+	// Recepción
+	remoteSeq uint32 // El Sequence más nuevo que recibimos
+	recvBits  uint32 // Bit i = recibimos (remoteSeq - 1 - i)
+	hasRemote bool
+	channels  [numChannels]receiveChannel
+
+	// RTT (tiempo de ida y vuelta) medido con los Acks
+	srtt   time.Duration // Promedio
+	rttVar time.Duration // Variación
+
+	// OnAck (opcional) se llama con el Sequence de cada paquete nuestro que el otro lado confirma.
+	// Sirve para saber qué llegó aunque el canal no sea confiable (ej: qué snapshots recibió el cliente).
+	OnAck func(seq uint32)
+}
+
+// NewEndpoint crea el estado de confiabilidad de una conexión nueva
+func NewEndpoint() *Endpoint {
+	e := &Endpoint{sent: make(map[uint32]*sentPacket)}
+	for i := range e.channels {
+		e.channels[i].pending = make(map[uint32]Message)
+		e.channels[i].seen = make(map[uint32]bool)
+	}
+	return e
+}
+
+// seqNewer dice si el número a es más nuevo que b, aunque el contador haya dado la vuelta
+// (después de 4294967295 viene 0: la resta en uint32 "da la vuelta" también y el signo lo resuelve).
+func seqNewer(a, b uint32) bool {
+	return int32(a-b) > 0
+}
+
+// Send arma un paquete listo para escribir en el socket.
+// De `h` se usan Type, PlayerID y Token; el Endpoint completa Sequence, Ack, AckBits, Channel y ChannelSeq.
+// Si el canal es confiable, el mensaje se guarda para reenviarlo (ver Resend) hasta que llegue su Ack.
+func (e *Endpoint) Send(h PacketHeader, ch Channel, payload []byte, now time.Time) []byte {
+	h.Channel = ch
+	h.ChannelSeq = e.sendSeq[ch]
+	e.sendSeq[ch]++
+
+	var msg *outgoing
+	if ch.reliable() {
+		msg = &outgoing{header: h, payload: payload}
+		e.unacked = append(e.unacked, msg)
+	}
+	return e.write(h, payload, msg, now)
+}
+
+// write asigna un Sequence nuevo, agrega los Acks y serializa el paquete
+func (e *Endpoint) write(h PacketHeader, payload []byte, msg *outgoing, now time.Time) []byte {
+	e.localSeq++
+	if e.localSeq == 0 {
+		e.localSeq = 1 // 0 está reservado para "nada recibido todavía"
+	}
+	h.Sequence = e.localSeq
+	if e.hasRemote {
+		h.Ack = e.remoteSeq
+		h.AckBits = e.recvBits
+	}
+	e.ackPending = false // Todo paquete que sale lleva los Acks
+
+	e.sent[h.Sequence] = &sentPacket{sentAt: now, message: msg}
+	delete(e.sent, h.Sequence-sentHistory) // Demasiado viejo para que llegue su Ack: lo olvidamos
+	if msg != nil {
+		msg.lastSent = now
+		msg.sends++
+	}
+
+	buf := make([]byte, HeaderSize+len(payload))
+	putHeader(buf, h)
+	copy(buf[HeaderSize:], payload)
+	return buf
+}
+
+// LastSequence devuelve el Sequence del último paquete armado (por Send o Resend)
+func (e *Endpoint) LastSequence() uint32 {
+	return e.localSeq
+}
+
+// Resend devuelve los paquetes de los mensajes confiables que vencieron su espera sin Ack.
+// Se llama una vez por tick. Cada reenvío espera el doble que el anterior (hasta maxRTO).
+func (e *Endpoint) Resend(now time.Time) [][]byte {
+	var packets [][]byte
+	kept := e.unacked[:0]
+	for _, msg := range e.unacked {
+		if msg.acked {
+			continue // Confirmado: lo sacamos de la lista
+		}
+		kept = append(kept, msg)
+		if now.Sub(msg.lastSent) >= e.backoff(msg.sends) {
+			packets = append(packets, e.write(msg.header, msg.payload, msg, now))
+		}
+	}
+	clear(e.unacked[len(kept):]) // Que el GC pueda liberar los confirmados
+	e.unacked = kept
+	return packets
+}
+
+// Unacked cuenta los mensajes confiables que todavía esperan confirmación
+func (e *Endpoint) Unacked() int {
+	n := 0
+	for _, msg := range e.unacked {
+		if !msg.acked {
+			n++
+		}
+	}
+	return n
+}
+
+// AckPending indica que recibimos mensajes confiables y no mandamos nada desde entonces:
+// hay que mandar un paquete (aunque sea PacketTypeAck) para que el otro lado deje de reenviar.
+func (e *Endpoint) AckPending() bool {
+	return e.ackPending
+}
+
+// RTO es el tiempo que esperamos un Ack antes de reenviar (Retransmission TimeOut)
+func (e *Endpoint) RTO() time.Duration {
+	if e.srtt == 0 {
+		return initialRTO
+	}
+	return min(max(e.srtt+4*e.rttVar, minRTO), maxRTO)
+}
+
+// RTT devuelve el tiempo de ida y vuelta promedio (0 si todavía no hay mediciones)
+func (e *Endpoint) RTT() time.Duration {
+	return e.srtt
+}
+
+// backoff es la espera antes del próximo reenvío: RTO, 2×RTO, 4×RTO... con tope en maxRTO
+func (e *Endpoint) backoff(sends int) time.Duration {
+	d := e.RTO()
+	for i := 1; i < sends && d < maxRTO; i++ {
+		d *= 2
+	}
+	return min(d, maxRTO)
+}
+
+// Receive procesa un paquete que llegó del otro lado y devuelve los mensajes listos para usar.
+// Puede devolver:
+//   - nada: el paquete era un duplicado, era más viejo que el último (canal secuenciado),
+//     o es un mensaje ordenado que llegó antes de tiempo (queda guardado).
+//   - uno o más: uno ordenado que faltaba "destraba" a los que estaban esperando.
+//
+// `dropped` indica que el paquete se descartó (duplicado, reenvío de algo ya entregado o demasiado viejo).
+// Aunque se descarte, el paquete queda confirmado: así el otro lado deja de reenviarlo.
+func (e *Endpoint) Receive(h PacketHeader, payload []byte, now time.Time) (messages []Message, dropped bool) {
+	// 1. Lo que el otro lado dice haber recibido de NOSOTROS
+	e.processAcks(h.Ack, h.AckBits, now)
+
+	// 2. ¿Ya vimos este paquete? (UDP puede duplicarlo)
+	if !e.markReceived(h.Sequence) {
+		return nil, true
+	}
+	if h.Channel >= numChannels {
+		return nil, true
+	}
+	if h.Channel.reliable() {
+		e.ackPending = true
+	}
+
+	// 3. Las garantías de cada canal
+	msg := Message{Header: h, Payload: payload}
+	rc := &e.channels[h.Channel]
+	switch h.Channel {
+	case ChannelUnreliable:
+		return []Message{msg}, false
+
+	case ChannelUnreliableSequenced:
+		if rc.started && !seqNewer(h.ChannelSeq, rc.last) {
+			return nil, true // Llegó después de uno más nuevo: ya no sirve
+		}
+		rc.started, rc.last = true, h.ChannelSeq
+		return []Message{msg}, false
+
+	case ChannelReliableUnordered:
+		if seqNewer(rc.next, h.ChannelSeq) || rc.seen[h.ChannelSeq] {
+			return nil, true // Reenvío de algo ya entregado (se había perdido el Ack)
+		}
+		if h.ChannelSeq-rc.next >= maxReliableGap {
+			return nil, true // Demasiado adelantado: el emisor lo va a reenviar
+		}
+		// Lo entregamos ya; solo recordamos su número para detectar reenvíos
+		rc.seen[h.ChannelSeq] = true
+		for rc.seen[rc.next] {
+			delete(rc.seen, rc.next)
+			rc.next++
+		}
+		return []Message{msg}, false
+
+	default: // ChannelReliableOrdered
+		if seqNewer(rc.next, h.ChannelSeq) {
+			return nil, true // Reenvío de algo ya entregado
+		}
+		if h.ChannelSeq-rc.next >= maxReliableGap {
+			return nil, true // Demasiado adelantado: el emisor lo va a reenviar
+		}
+		rc.pending[h.ChannelSeq] = msg
+		// Entregamos todo lo que ya está en orden a partir de `next`
+		for {
+			m, ok := rc.pending[rc.next]
+			if !ok {
+				break
+			}
+			messages = append(messages, m)
+			delete(rc.pending, rc.next)
+			rc.next++
+		}
+		return messages, false
+	}
+}
+
+// markReceived registra el Sequence recibido y dice si es la primera vez que lo vemos
+func (e *Endpoint) markReceived(seq uint32) bool {
+	if !e.hasRemote {
+		e.hasRemote, e.remoteSeq, e.recvBits = true, seq, 0
+		return true
+	}
+	if seqNewer(seq, e.remoteSeq) {
+		// Llegó uno más nuevo: corremos la ventana. El Ack anterior pasa a ser un bit.
+		// (En Go, correr 32 o más bits un uint32 da 0: los que salen de la ventana se olvidan solos)
+		shift := seq - e.remoteSeq
+		e.recvBits = e.recvBits<<shift | 1<<(shift-1)
+		e.remoteSeq = seq
+		return true
+	}
+	diff := e.remoteSeq - seq
+	if diff == 0 || diff > ackWindow {
+		return false // Duplicado del último, o tan viejo que ya no lo podemos confirmar
+	}
+	bit := uint32(1) << (diff - 1)
+	if e.recvBits&bit != 0 {
+		return false // Duplicado
+	}
+	e.recvBits |= bit
+	return true
+}
+
+// processAcks marca como confirmados los paquetes que el otro lado dice haber recibido
+func (e *Endpoint) processAcks(ack, bits uint32, now time.Time) {
+	if ack == 0 {
+		return // Todavía no recibió nada nuestro
+	}
+	e.ackPacket(ack, now)
+	for i := uint32(0); i < ackWindow; i++ {
+		if bits&(1<<i) != 0 {
+			e.ackPacket(ack-1-i, now)
+		}
+	}
+}
+
+// ackPacket confirma un paquete: mide el RTT y, si llevaba un mensaje confiable, deja de reenviarlo
+func (e *Endpoint) ackPacket(seq uint32, now time.Time) {
+	sp, ok := e.sent[seq]
+	if !ok {
+		return // Ya confirmado antes (los Acks se repiten en varios paquetes)
+	}
+	delete(e.sent, seq)
+	e.updateRTT(now.Sub(sp.sentAt))
+	if sp.message != nil {
+		sp.message.acked = true
+	}
+	if e.OnAck != nil {
+		e.OnAck(seq)
+	}
+}
+
+// updateRTT actualiza el promedio y la variación del RTT (el mismo cálculo que TCP)
+// 💡 Cada reenvío usa un Sequence nuevo, así que cada Ack corresponde a UN envío: la medición no es ambigua.
+func (e *Endpoint) updateRTT(sample time.Duration) {
+	if e.srtt == 0 {
+		e.srtt, e.rttVar = sample, sample/2
+		return
+	}
+	diff := e.srtt - sample
+	if diff < 0 {
+		diff = -diff
+	}
+	e.rttVar += (diff - e.rttVar) / rttVarSmoothing
+	e.srtt += (sample - e.srtt) / rttSmoothing
+}
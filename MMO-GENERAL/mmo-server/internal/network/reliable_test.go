@@ -0,0 +1,327 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+// lossyLink simula una red MALA entre dos Endpoints, sin sockets y con tiempo "virtual".
+// Pierde, duplica y demora paquetes (cada uno con una demora al azar, así que también los desordena).
+// Sirve para probar la capa de confiabilidad en condiciones que en la red local casi nunca se ven.
+type lossyLink struct {
+	loss      float64       // Probabilidad de perder un paquete (0.2 = 20%)
+	duplicate float64       // Probabilidad de entregarlo dos veces
+	minDelay  time.Duration // Demora mínima de la "red"
+	maxDelay  time.Duration // Demora máxima (si es mayor que minDelay, los paquetes se desordenan)
+
+	rng      *rand.Rand
+	inFlight []delayedPacket
+
+	sent, lost int // Para los mensajes de error
+}
+
+type delayedPacket struct {
+	deliverAt time.Time
+	data      []byte
+}
+
+// newLossyLink crea un enlace simulado. Con la misma semilla, la "mala suerte" se repite igual
+// (si algo falla, se puede reproducir).
+func newLossyLink(seed uint64, loss, duplicate float64, minDelay, maxDelay time.Duration) *lossyLink {
+	return &lossyLink{
+		loss:      loss,
+		duplicate: duplicate,
+		minDelay:  minDelay,
+		maxDelay:  maxDelay,
+		rng:       rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+	}
+}
+
+// send mete un paquete en la "red" en el instante `now`
+func (l *lossyLink) send(data []byte, now time.Time) {
+	l.sent++
+	if l.rng.Float64() < l.loss {
+		l.lost++
+		return
+	}
+	copies := 1
+	if l.rng.Float64() < l.duplicate {
+		copies = 2
+	}
+	for range copies {
+		delay := l.minDelay
+		if l.maxDelay > l.minDelay {
+			delay += time.Duration(l.rng.Int64N(int64(l.maxDelay - l.minDelay)))
+		}
+		l.inFlight = append(l.inFlight, delayedPacket{deliverAt: now.Add(delay), data: data})
+	}
+}
+
+// receive devuelve los paquetes que ya "llegaron" en el instante `now`, en orden de llegada
+// (que no tiene por qué ser el orden en que se enviaron)
+func (l *lossyLink) receive(now time.Time) [][]byte {
+	slices.SortStableFunc(l.inFlight, func(a, b delayedPacket) int {
+		return a.deliverAt.Compare(b.deliverAt)
+	})
+	n := 0
+	for n < len(l.inFlight) && !l.inFlight[n].deliverAt.After(now) {
+		n++
+	}
+	arrived := make([][]byte, n)
+	for i := range n {
+		arrived[i] = l.inFlight[i].data
+	}
+	l.inFlight = slices.Delete(l.inFlight, 0, n)
+	return arrived
+}
+
+// Cada canal cumple su garantía sobre una red que pierde, duplica y desordena,
+// con distintas semillas y porcentajes de pérdida.
+func TestChannelsOverLossyNetwork(t *testing.T) {
+	const (
+		messages = 300                    // Mensajes por canal (uno por tick)
+		drain    = 60 * time.Second       // Tiempo extra para que terminen los reenvíos
+		tick     = time.Second / 30       // Ticks de 33ms, como el servidor
+		minDelay = 20 * time.Millisecond  // Demora mínima de la red
+		maxDelay = 120 * time.Millisecond // Demora máxima (más que la mínima = desorden)
+	)
+	cases := []struct {
+		loss, dup float64
+	}{
+		{loss: 0, dup: 0},
+		{loss: 0.2, dup: 0.05},
+		{loss: 0.4, dup: 0.1},
+		{loss: 0.5, dup: 0.05},
+	}
+	channels := []Channel{ChannelUnreliableSequenced, ChannelReliableUnordered, ChannelReliableOrdered}
+
+	for _, tc := range cases {
+		for _, seed := range []uint64{1, 2, 7, 42} {
+			t.Run(fmt.Sprintf("loss=%v/dup=%v/seed=%d", tc.loss, tc.dup, seed), func(t *testing.T) {
+				// Servidor -> Cliente y de vuelta (para los Acks), cada sentido con su propia "red"
+				server, client := NewEndpoint(), NewEndpoint()
+				toClient := newLossyLink(seed, tc.loss, tc.dup, minDelay, maxDelay)
+				toServer := newLossyLink(seed+1, tc.loss, tc.dup, minDelay, maxDelay)
+
+				received := map[Channel][]uint32{}
+				now := time.Unix(0, 0)
+				for i := range messages + int(drain/tick) {
+					now = now.Add(tick)
+
+					if i < messages {
+						for _, ch := range channels {
+							payload := binary.BigEndian.AppendUint32(nil, uint32(i))
+							toClient.send(server.Send(PacketHeader{Type: PacketTypeMove}, ch, payload, now), now)
+						}
+					}
+					for _, packet := range server.Resend(now) {
+						toClient.send(packet, now)
+					}
+
+					// Cliente: recibe y confirma
+					for _, data := range toClient.receive(now) {
+						h, err := DeserializeHeader(data)
+						if err != nil {
+							t.Fatalf("invalid packet: %v", err)
+						}
+						msgs, _ := client.Receive(h, data[HeaderSize:], now)
+						for _, m := range msgs {
+							received[m.Header.Channel] = append(received[m.Header.Channel], binary.BigEndian.Uint32(m.Payload))
+						}
+					}
+					if client.AckPending() {
+						toServer.send(client.Send(PacketHeader{Type: PacketTypeAck}, ChannelUnreliable, nil, now), now)
+					}
+
+					// Servidor: procesa los Acks
+					for _, data := range toServer.receive(now) {
+						h, err := DeserializeHeader(data)
+						if err != nil {
+							t.Fatalf("invalid ack: %v", err)
+						}
+						server.Receive(h, data[HeaderSize:], now)
+					}
+				}
+
+				t.Logf("server->client: %d sent, %d lost; RTT %v", toClient.sent, toClient.lost, server.RTT())
+				checkSequenced(t, received[ChannelUnreliableSequenced])
+				checkUnordered(t, received[ChannelReliableUnordered], messages)
+				checkOrdered(t, received[ChannelReliableOrdered], messages)
+				if n := server.Unacked(); n != 0 {
+					t.Errorf("%d reliable messages were never acked", n)
+				}
+			})
+		}
+	}
+}
+
+// checkSequenced: nunca un duplicado ni uno más viejo que el anterior (perder algunos está permitido)
+func checkSequenced(t *testing.T, got []uint32) {
+	t.Helper()
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Errorf("unreliable_sequenced delivered %d after %d", got[i], got[i-1])
+			return
+		}
+	}
+}
+
+// checkUnordered: todos exactamente una vez, en cualquier orden
+func checkUnordered(t *testing.T, got []uint32, total int) {
+	t.Helper()
+	seen := make(map[uint32]bool, len(got))
+	for _, v := range got {
+		if seen[v] {
+			t.Errorf("reliable_unordered delivered %d twice", v)
+			return
+		}
+		seen[v] = true
+	}
+	if len(seen) != total {
+		t.Errorf("reliable_unordered delivered %d of %d", len(seen), total)
+	}
+}
+
+// checkOrdered: todos exactamente una vez y en orden (0, 1, 2...)
+func checkOrdered(t *testing.T, got []uint32, total int) {
+	t.Helper()
+	if len(got) != total {
+		t.Errorf("reliable_ordered delivered %d of %d", len(got), total)
+		return
+	}
+	for i, v := range got {
+		if v != uint32(i) {
+			t.Errorf("reliable_ordered delivered %d at position %d", v, i)
+			return
+		}
+	}
+}
+
+func TestSeqNewer(t *testing.T) {
+	cases := []struct {
+		a, b uint32
+		want bool
+	}{
+		{a: 2, b: 1, want: true},
+		{a: 1, b: 2, want: false},
+		{a: 5, b: 5, want: false},
+		{a: 0, b: math.MaxUint32, want: true}, // Después de 4294967295 viene 0
+		{a: math.MaxUint32, b: 0, want: false},
+		{a: 10, b: math.MaxUint32 - 10, want: true},
+		{a: 1 << 31, b: 1, want: true},    // La mitad del contador menos uno: todavía es más nuevo
+		{a: 1<<31 + 1, b: 1, want: false}, // A media vuelta exacta no es más nuevo en ningún sentido
+		{a: 1, b: 1<<31 + 1, want: false}, // (int32(1<<31) es negativo)
+		{a: 1<<31 + 2, b: 1, want: false}, // Más de media vuelta: se interpreta como más viejo
+		{a: 1, b: 1<<31 + 2, want: true},
+	}
+	for _, tc := range cases {
+		if got := seqNewer(tc.a, tc.b); got != tc.want {
+			t.Errorf("seqNewer(%d, %d) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// Un mensaje confiable a maxReliableGap-1 del próximo esperado se acepta; a maxReliableGap se descarta
+// (el emisor lo va a reenviar cuando el receptor se ponga al día).
+func TestMaxReliableGap(t *testing.T) {
+	now := time.Unix(0, 0)
+	for _, ch := range []Channel{ChannelReliableUnordered, ChannelReliableOrdered} {
+		t.Run(ch.String(), func(t *testing.T) {
+			cases := []struct {
+				channelSeq  uint32
+				wantDropped bool
+			}{
+				{channelSeq: maxReliableGap - 1, wantDropped: false},
+				{channelSeq: maxReliableGap, wantDropped: true},
+				{channelSeq: maxReliableGap + 1, wantDropped: true},
+			}
+			for i, tc := range cases {
+				e := NewEndpoint()
+				h := PacketHeader{Sequence: uint32(i + 1), Channel: ch, ChannelSeq: tc.channelSeq}
+				if _, dropped := e.Receive(h, nil, now); dropped != tc.wantDropped {
+					t.Errorf("ChannelSeq %d: dropped = %v, want %v", tc.channelSeq, dropped, tc.wantDropped)
+				}
+			}
+
+			// Con el receptor adelantado, el límite se mide desde su `next` (también al dar la vuelta)
+			next := uint32(math.MaxUint32 - 10)
+			e := NewEndpoint()
+			e.channels[ch].next = next
+			h := PacketHeader{Sequence: 1, Channel: ch, ChannelSeq: next + maxReliableGap - 1}
+			if _, dropped := e.Receive(h, nil, now); dropped {
+				t.Errorf("ChannelSeq %d past the wrap was dropped", h.ChannelSeq)
+			}
+			h = PacketHeader{Sequence: 2, Channel: ch, ChannelSeq: next + maxReliableGap}
+			if _, dropped := e.Receive(h, nil, now); !dropped {
+				t.Errorf("ChannelSeq %d past the wrap was accepted", h.ChannelSeq)
+			}
+		})
+	}
+}
+
+// Un paquete que llega tarde se puede confirmar si está hasta ackWindow detrás del último recibido.
+func TestAckWindowEdge(t *testing.T) {
+	now := time.Unix(0, 0)
+	cases := []struct {
+		name     string
+		late     uint32 // Sequence del paquete que llega tarde
+		wantNew  bool   // markReceived lo toma como nuevo
+		wantAcks []uint32
+	}{
+		{name: "inside window", late: 100 - ackWindow, wantNew: true, wantAcks: []uint32{100, 100 - ackWindow}},
+		{name: "outside window", late: 100 - ackWindow - 1, wantNew: false, wantAcks: []uint32{100}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// El servidor manda 100 paquetes; al cliente le llega el 100 y después uno viejo
+			server, client := NewEndpoint(), NewEndpoint()
+			packets := make([][]byte, 101)
+			for seq := 1; seq <= 100; seq++ {
+				packets[seq] = server.Send(PacketHeader{Type: PacketTypeMove}, ChannelUnreliable, nil, now)
+			}
+			for _, seq := range []uint32{100, tc.late} {
+				h, err := DeserializeHeader(packets[seq])
+				if err != nil {
+					t.Fatal(err)
+				}
+				_, dropped := client.Receive(h, nil, now)
+				if seq == tc.late && dropped == tc.wantNew {
+					t.Errorf("late packet %d: dropped = %v, want %v", seq, dropped, !tc.wantNew)
+				}
+			}
+
+			// La respuesta del cliente confirma solo lo que entra en la ventana
+			reply, err := DeserializeHeader(client.Send(PacketHeader{Type: PacketTypeAck}, ChannelUnreliable, nil, now))
+			if err != nil {
+				t.Fatal(err)
+			}
+			server.Receive(reply, nil, now)
+			var acked []uint32 // Los confirmados dejan de esperar Ack en server.sent
+			for seq := uint32(100); seq >= 1; seq-- {
+				if _, waiting := server.sent[seq]; !waiting {
+					acked = append(acked, seq)
+				}
+			}
+			if !slices.Equal(acked, tc.wantAcks) {
+				t.Errorf("acked %v, want %v", acked, tc.wantAcks)
+			}
+		})
+	}
+
+	// Un paquete nuevo a ackWindow+1 del anterior saca al anterior de la ventana
+	e := NewEndpoint()
+	e.markReceived(1)
+	e.markReceived(1 + ackWindow)
+	if e.recvBits != 1<<(ackWindow-1) {
+		t.Errorf("after a jump of %d: recvBits = %032b, want only the last bit", ackWindow, e.recvBits)
+	}
+	e.markReceived(2 + ackWindow)
+	if e.recvBits != 1 {
+		t.Errorf("after one more: recvBits = %032b, want only bit 0 (packet 1 left the window)", e.recvBits)
+	}
+}
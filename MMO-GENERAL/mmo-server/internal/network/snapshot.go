@@ -0,0 +1,314 @@
+package network
+
+import (
+	"encoding/binary"
+	"math"
+	"net"
+	"time"
+)
+
+// 🎓 SNAPSHOTS CON COMPRESIÓN DELTA
+// En vez de reenviar cada Move apenas llega (más paquetes cuanto más rápido manden los clientes),
+// en cada tick el servidor saca una "foto" del mundo (Snapshot) y le manda a cada cliente
+// SOLO LO QUE CAMBIÓ desde la última foto que ese cliente confirmó haber recibido (su baseline).
+//
+//   - Si nada cambió, el paquete lleva 0 entidades (14 bytes de payload).
+//   - Si una foto se pierde, no pasa nada: la próxima sigue comparando contra la última CONFIRMADA.
+//   - Si el cliente nunca confirmó nada (o su baseline es muy vieja), va la foto completa.
+//
+// Además los números se "cuantizan": en vez de floats de 4 bytes, enteros con la precisión justa
+// (milímetros para la posición, 2 bytes para el yaw), y los cambios chicos van como diferencia de 2 bytes.
+
+// Límites de los snapshots
+const (
+	MaxDatagramSize     = 1200 // Tope por paquete UDP: por debajo del MTU de casi cualquier red (sin fragmentación IP)
+	SnapshotHeaderSize  = 14   // Tick (4) + Baseline (4) + Fragmento (2) + Total de fragmentos (2) + Entidades (2)
+	SnapshotHistorySize = 64   // Fotos que recordamos (~2s a 30Hz): baselines más viejas se reemplazan por una foto completa
+
+	PositionScale = 10 // La posición viaja en milímetros: 1 unidad de Unreal (cm) = 10
+)
+
+// Qué campos trae cada entidad dentro de un snapshot (bits del byte Flags)
+const (
+	FieldPosition      uint8 = 1 << iota // Trae posición (3 × int32 absolutos, o 3 × int16 si además está FieldPositionDelta)
+	FieldPositionDelta                   // La posición es la diferencia contra la baseline (3 × int16)
+	FieldYaw                             // Trae yaw (uint16)
+	FieldVelocity                        // Trae velocidad (3 × int16, cm/s)
+	FieldRemoved                         // La entidad ya no existe (no trae nada más)
+)
+
+// EntityState es el estado de una entidad ya cuantizado (tal cual viaja por la red)
+type EntityState struct {
+	ID         uint64
+	X, Y, Z    int32  // Milímetros (unidades de Unreal × PositionScale)
+	Yaw        uint16 // 0..65535 = 0..360°
+	VX, VY, VZ int16  // cm/s
+}
+
+// QuantizeState convierte el estado (floats) de un jugador a su versión para la red
+func QuantizeState(id uint64, s StateData) EntityState {
+	return EntityState{
+		ID:  id,
+		X:   quantizeInt32(s.X * PositionScale),
+		Y:   quantizeInt32(s.Y * PositionScale),
+		Z:   quantizeInt32(s.Z * PositionScale),
+		Yaw: quantizeYaw(s.Yaw),
+		VX:  quantizeInt16(s.VX),
+		VY:  quantizeInt16(s.VY),
+		VZ:  quantizeInt16(s.VZ),
+	}
+}
+
+// quantizeYaw pasa grados (cualquier valor, incluso negativos) a 0..65535
+func quantizeYaw(deg float32) uint16 {
+	d := math.Mod(float64(deg), 360)
+	if d < 0 {
+		d += 360
+	}
+	return uint16(int(math.Round(d*65536/360)) & 0xFFFF) // 360° da la vuelta a 0
+}
+
+// quantizeInt32 redondea y recorta al rango de un int32 (para que un valor enorme no "dé la vuelta")
+func quantizeInt32(f float32) int32 {
+	return int32(max(min(math.Round(float64(f)), math.MaxInt32), math.MinInt32))
+}
+
+// quantizeInt16 redondea y recorta al rango de un int16
+func quantizeInt16(f float32) int16 {
+	return int16(max(min(math.Round(float64(f)), math.MaxInt16), math.MinInt16))
+}
+
+// Snapshot es la foto del mundo en un tick, con las entidades ordenadas por ID
+type Snapshot struct {
+	Tick     uint32
+	Entities []EntityState
+}
+
+// SnapshotHistory guarda las últimas fotos, para usarlas de baseline
+type SnapshotHistory struct {
+	ring []*Snapshot
+}
+
+// NewSnapshotHistory crea un historial de `size` fotos
+func NewSnapshotHistory(size int) *SnapshotHistory {
+	return &SnapshotHistory{ring: make([]*Snapshot, size)}
+}
+
+// Add guarda una foto (pisa la de hace `size` ticks)
+func (h *SnapshotHistory) Add(s *Snapshot) {
+	h.ring[int(s.Tick%uint32(len(h.ring)))] = s
+}
+
+// Get busca la foto de un tick (false si es tan vieja que ya se pisó)
+func (h *SnapshotHistory) Get(tick uint32) (*Snapshot, bool) {
+	s := h.ring[int(tick%uint32(len(h.ring)))]
+	if s == nil || s.Tick != tick {
+		return nil, false
+	}
+	return s, true
+}
+
+// EncodeSnapshot arma los payloads del snapshot `cur` comparado contra `base` (nil = foto completa),
+// sin la entidad `exceptID` (el propio jugador: su posición le llega por las Correcciones).
+// Las entidades se reparten en tantos paquetes como haga falta para no pasar MaxDatagramSize.
+// Siempre devuelve al menos un payload (aunque no haya cambios): así el cliente confirma esta foto.
+func EncodeSnapshot(cur, base *Snapshot, exceptID uint64) [][]byte {
+	var baseTick uint32
+	var baseEntities []EntityState
+	if base != nil {
+		baseTick, baseEntities = base.Tick, base.Entities
+	}
+
+	// 1. Qué cambió: recorremos las dos listas (ordenadas por ID) al mismo tiempo
+	var entries [][]byte
+	add := func(id uint64, entry []byte) {
+		if id != exceptID && entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	i, j := 0, 0
+	for i < len(cur.Entities) || j < len(baseEntities) {
+		switch {
+		case j >= len(baseEntities) || (i < len(cur.Entities) && cur.Entities[i].ID < baseEntities[j].ID):
+			add(cur.Entities[i].ID, encodeEntity(cur.Entities[i], nil)) // Nueva: va completa
+			i++
+		case i >= len(cur.Entities) || baseEntities[j].ID < cur.Entities[i].ID:
+			add(baseEntities[j].ID, encodeRemoved(baseEntities[j].ID)) // Ya no está
+			j++
+		default:
+			add(cur.Entities[i].ID, encodeEntity(cur.Entities[i], &baseEntities[j])) // Solo lo que cambió
+			i++
+			j++
+		}
+	}
+
+	// 2. Repartimos las entidades en paquetes que entren en el MTU
+	budget := MaxDatagramSize - HeaderSize
+	fragments := [][]byte{make([]byte, SnapshotHeaderSize, budget)}
+	counts := []int{0}
+	for _, entry := range entries {
+		last := len(fragments) - 1
+		if len(fragments[last])+len(entry) > budget {
+			fragments = append(fragments, make([]byte, SnapshotHeaderSize, budget))
+			counts = append(counts, 0)
+			last++
+		}
+		fragments[last] = append(fragments[last], entry...)
+		counts[last]++
+	}
+
+	// 3. Recién ahora sabemos cuántos fragmentos son: completamos las cabeceras
+	for n, frag := range fragments {
+		binary.BigEndian.PutUint32(frag[0:4], cur.Tick)
+		binary.BigEndian.PutUint32(frag[4:8], baseTick)
+		binary.BigEndian.PutUint16(frag[8:10], uint16(n))
+		binary.BigEndian.PutUint16(frag[10:12], uint16(len(fragments)))
+		binary.BigEndian.PutUint16(frag[12:14], uint16(counts[n]))
+	}
+	return fragments
+}
+
+// encodeEntity serializa los campos de `e` que difieren de `base` (todos si base es nil).
+// Devuelve nil si no cambió nada (la entidad no viaja).
+func encodeEntity(e EntityState, base *EntityState) []byte {
+	var flags uint8
+	var body []byte
+
+	switch {
+	case base == nil:
+		flags |= FieldPosition
+		body = appendInt32s(body, e.X, e.Y, e.Z)
+	case e.X != base.X || e.Y != base.Y || e.Z != base.Z:
+		dx, dy, dz := int64(e.X)-int64(base.X), int64(e.Y)-int64(base.Y), int64(e.Z)-int64(base.Z)
+		if fitsInt16(dx) && fitsInt16(dy) && fitsInt16(dz) {
+			// Cambio chico (menos de ~32m): 6 bytes en vez de 12
+			flags |= FieldPosition | FieldPositionDelta
+			body = appendInt16s(body, int16(dx), int16(dy), int16(dz))
+		} else {
+			flags |= FieldPosition
+			body = appendInt32s(body, e.X, e.Y, e.Z)
+		}
+	}
+	if base == nil || e.Yaw != base.Yaw {
+		flags |= FieldYaw
+		body = binary.BigEndian.AppendUint16(body, e.Yaw)
+	}
+	if base == nil || e.VX != base.VX || e.VY != base.VY || e.VZ != base.VZ {
+		flags |= FieldVelocity
+		body = appendInt16s(body, e.VX, e.VY, e.VZ)
+	}
+	if flags == 0 {
+		return nil
+	}
+
+	entry := binary.BigEndian.AppendUint64(make([]byte, 0, 9+len(body)), e.ID)
+	entry = append(entry, flags)
+	return append(entry, body...)
+}
+
+// encodeRemoved avisa que una entidad de la baseline ya no existe
+func encodeRemoved(id uint64) []byte {
+	return append(binary.BigEndian.AppendUint64(nil, id), FieldRemoved)
+}
+
+func fitsInt16(v int64) bool { return v >= math.MinInt16 && v <= math.MaxInt16 }
+
+func appendInt32s(buf []byte, values ...int32) []byte {
+	for _, v := range values {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(v))
+	}
+	return buf
+}
+
+func appendInt16s(buf []byte, values ...int16) []byte {
+	for _, v := range values {
+		buf = binary.BigEndian.AppendUint16(buf, uint16(v))
+	}
+	return buf
+}
+
+// SnapshotAcks recuerda qué fotos le llegaron completas a UN cliente.
+// Se entera por los Acks de la capa confiable (Endpoint.OnAck): una foto cuenta como recibida
+// cuando se confirmaron TODOS sus fragmentos. Esa es la baseline del próximo delta.
+type SnapshotAcks struct {
+	baseline  uint32            // Última foto completa confirmada (0 = ninguna)
+	remaining map[uint32]int    // Tick -> fragmentos todavía sin confirmar
+	packets   map[uint32]uint32 // Sequence del paquete -> Tick de la foto que llevaba
+}
+
+// NewSnapshotAcks crea el registro de un cliente nuevo (sin baseline)
+func NewSnapshotAcks() *SnapshotAcks {
+	return &SnapshotAcks{
+		remaining: make(map[uint32]int),
+		packets:   make(map[uint32]uint32),
+	}
+}
+
+// Baseline devuelve el tick de la última foto que el cliente confirmó completa (0 = ninguna)
+func (s *SnapshotAcks) Baseline() uint32 {
+	return s.baseline
+}
+
+// Sent registra que el paquete `seq` llevó un fragmento de la foto `tick`
+func (s *SnapshotAcks) Sent(seq, tick uint32) {
+	s.packets[seq] = tick
+	s.remaining[tick]++
+}
+
+// Acked se llama cuando el cliente confirma el paquete `seq` (ver Endpoint.OnAck)
+func (s *SnapshotAcks) Acked(seq uint32) {
+	tick, ok := s.packets[seq]
+	if !ok {
+		return // No llevaba un snapshot (ej: un latido)
+	}
+	delete(s.packets, seq)
+	s.remaining[tick]--
+	if s.remaining[tick] > 0 {
+		return
+	}
+	delete(s.remaining, tick)
+	if s.baseline == 0 || seqNewer(tick, s.baseline) {
+		s.baseline = tick
+	}
+}
+
+// Forget olvida las fotos anteriores a `tick` que nunca se completaron (algún fragmento se perdió)
+func (s *SnapshotAcks) Forget(tick uint32) {
+	for seq, t := range s.packets {
+		if seqNewer(tick, t) {
+			delete(s.packets, seq)
+			delete(s.remaining, t)
+		}
+	}
+}
+
+// SendSnapshot le manda a cada jugador el delta de `snap` contra su baseline.
+// Devuelve los paquetes y bytes (de payload) enviados, y a cuántos jugadores les fue la foto completa.
+// 💡 Canal unreliable (no secuenciado): los fragmentos de una misma foto pueden llegar en cualquier orden.
+// El cliente descarta por su cuenta las fotos con Tick más viejo que la última que aplicó.
+func (cm *ConnectionManager) SendSnapshot(conn *net.UDPConn, snap *Snapshot, history *SnapshotHistory, now time.Time) (packets, bytes, full int) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	for _, p := range cm.players {
+		var base *Snapshot
+		if tick := p.Snapshots.Baseline(); tick != 0 {
+			base, _ = history.Get(tick)
+		}
+		if base == nil {
+			full++ // Nunca confirmó una foto, o la última ya no está en el historial
+		}
+		p.Snapshots.Forget(snap.Tick - SnapshotHistorySize)
+
+		header := PacketHeader{Type: PacketTypeSnapshot, PlayerID: p.ID}
+		for _, payload := range EncodeSnapshot(snap, base, p.ID) {
+			data := p.Endpoint.Send(header, ChannelUnreliable, payload, now)
+			p.Snapshots.Sent(p.Endpoint.LastSequence(), snap.Tick)
+			if _, err := conn.WriteToUDP(data, p.Addr); err == nil {
+				packets++
+				bytes += len(payload)
+			}
+		}
+	}
+	return packets, bytes, full
+}
@@ -0,0 +1,357 @@
+package network
+
+import (
+	"cmp"
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+// decodeSnapshot aplica los fragmentos de una foto sobre base, igual que el cliente (ver test_snapshot.py).
+// También revisa lo que todo fragmento tiene que cumplir: tamaño, baseline y numeración.
+func decodeSnapshot(t *testing.T, payloads [][]byte, base *Snapshot) *Snapshot {
+	t.Helper()
+	state := make(map[uint64]EntityState)
+	var baseTick uint32
+	if base != nil {
+		baseTick = base.Tick
+		for _, e := range base.Entities {
+			state[e.ID] = e
+		}
+	}
+
+	out := &Snapshot{}
+	seen := make(map[uint16]bool)
+	for n, p := range payloads {
+		if HeaderSize+len(p) > MaxDatagramSize {
+			t.Errorf("fragment %d: datagram of %d bytes, max %d", n, HeaderSize+len(p), MaxDatagramSize)
+		}
+		out.Tick = binary.BigEndian.Uint32(p[0:4])
+		if got := binary.BigEndian.Uint32(p[4:8]); got != baseTick {
+			t.Errorf("fragment %d: baseline %d, want %d", n, got, baseTick)
+		}
+		seen[binary.BigEndian.Uint16(p[8:10])] = true
+		if total := binary.BigEndian.Uint16(p[10:12]); int(total) != len(payloads) {
+			t.Errorf("fragment %d: total %d, want %d", n, total, len(payloads))
+		}
+
+		count := binary.BigEndian.Uint16(p[12:14])
+		r := p[SnapshotHeaderSize:]
+		for range count {
+			id, flags := binary.BigEndian.Uint64(r[0:8]), r[8]
+			r = r[9:]
+			if flags&FieldRemoved != 0 {
+				delete(state, id)
+				continue
+			}
+			e := state[id]
+			e.ID = id
+			switch {
+			case flags&FieldPositionDelta != 0:
+				e.X += int32(int16(binary.BigEndian.Uint16(r[0:2])))
+				e.Y += int32(int16(binary.BigEndian.Uint16(r[2:4])))
+				e.Z += int32(int16(binary.BigEndian.Uint16(r[4:6])))
+				r = r[6:]
+			case flags&FieldPosition != 0:
+				e.X = int32(binary.BigEndian.Uint32(r[0:4]))
+				e.Y = int32(binary.BigEndian.Uint32(r[4:8]))
+				e.Z = int32(binary.BigEndian.Uint32(r[8:12]))
+				r = r[12:]
+			}
+			if flags&FieldYaw != 0 {
+				e.Yaw = binary.BigEndian.Uint16(r[0:2])
+				r = r[2:]
+			}
+			if flags&FieldVelocity != 0 {
+				e.VX = int16(binary.BigEndian.Uint16(r[0:2]))
+				e.VY = int16(binary.BigEndian.Uint16(r[2:4]))
+				e.VZ = int16(binary.BigEndian.Uint16(r[4:6]))
+				r = r[6:]
+			}
+			state[id] = e
+		}
+		if len(r) != 0 {
+			t.Errorf("fragment %d: %d bytes left after %d entities", n, len(r), count)
+		}
+	}
+	if len(seen) != len(payloads) {
+		t.Errorf("fragment numbers %v, want 0..%d", seen, len(payloads)-1)
+	}
+
+	for _, e := range state {
+		out.Entities = append(out.Entities, e)
+	}
+	slices.SortFunc(out.Entities, func(a, b EntityState) int { return cmp.Compare(a.ID, b.ID) })
+	return out
+}
+
+// entities arma n entidades quietas con IDs 1..n
+func entities(n int) []EntityState {
+	out := make([]EntityState, n)
+	for i := range out {
+		out[i] = EntityState{ID: uint64(i + 1), X: int32(i * 1000), Y: -5000, Z: 900, Yaw: 16384, VX: 300}
+	}
+	return out
+}
+
+// with devuelve una copia de es con la entidad `id` modificada por f (o quitada si f es nil)
+func with(es []EntityState, id uint64, f func(*EntityState)) []EntityState {
+	out := make([]EntityState, 0, len(es))
+	for _, e := range es {
+		if e.ID == id {
+			if f == nil {
+				continue
+			}
+			f(&e)
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func TestEncodeSnapshotRoundTrip(t *testing.T) {
+	base := &Snapshot{Tick: 10, Entities: entities(3)}
+	const fullEntry = 8 + 1 + 12 + 2 + 6                                          // ID + Flags + posición + yaw + velocidad
+	delta := with(entities(3), 1, func(e *EntityState) { e.X += 150; e.Z -= 20 }) // Se movió poco: diferencia en int16
+	delta = with(delta, 3, func(e *EntityState) { e.Yaw = 0 })                    // Solo giró
+
+	tests := []struct {
+		name          string
+		cur           *Snapshot
+		base          *Snapshot
+		exceptID      uint64
+		wantFragments int // 0: no se revisa (solo que sean varios)
+		wantBytes     int // Total de payload; 0: no se revisa
+	}{
+		{
+			name:          "full snapshot",
+			cur:           &Snapshot{Tick: 12, Entities: entities(3)},
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + 3*fullEntry,
+		},
+		{
+			name:          "full snapshot without the receiver",
+			cur:           &Snapshot{Tick: 12, Entities: entities(3)},
+			exceptID:      2,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + 2*fullEntry,
+		},
+		{
+			name:          "nothing changed",
+			cur:           &Snapshot{Tick: 12, Entities: entities(3)},
+			base:          base,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize,
+		},
+		{
+			name:          "delta vs acked baseline",
+			cur:           &Snapshot{Tick: 12, Entities: delta},
+			base:          base,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + (9 + 6) + (9 + 2),
+		},
+		{
+			name:          "large move sends absolute position",
+			cur:           &Snapshot{Tick: 12, Entities: with(entities(3), 2, func(e *EntityState) { e.Y += 40_000 })},
+			base:          base,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + 9 + 12,
+		},
+		{
+			name:          "entity removed",
+			cur:           &Snapshot{Tick: 12, Entities: with(entities(3), 2, nil)},
+			base:          base,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + 9,
+		},
+		{
+			name:          "entity added",
+			cur:           &Snapshot{Tick: 12, Entities: entities(4)},
+			base:          base,
+			wantFragments: 1,
+			wantBytes:     SnapshotHeaderSize + fullEntry,
+		},
+		{
+			name: "split into datagrams",
+			cur:  &Snapshot{Tick: 12, Entities: entities(100)},
+		},
+		{
+			name: "split delta",
+			cur: &Snapshot{Tick: 12, Entities: func() []EntityState {
+				es := entities(200)
+				for i := range es {
+					es[i].X += 10
+					es[i].VZ = -400
+				}
+				return es
+			}()},
+			base: &Snapshot{Tick: 10, Entities: entities(200)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads := EncodeSnapshot(tt.cur, tt.base, tt.exceptID)
+
+			if tt.wantFragments != 0 && len(payloads) != tt.wantFragments {
+				t.Errorf("%d fragments, want %d", len(payloads), tt.wantFragments)
+			}
+			if tt.wantFragments == 0 && len(payloads) < 2 {
+				t.Errorf("%d fragments, want at least 2", len(payloads))
+			}
+			total := 0
+			for _, p := range payloads {
+				total += len(p)
+			}
+			if tt.wantBytes != 0 && total != tt.wantBytes {
+				t.Errorf("payload = %d bytes, want %d", total, tt.wantBytes)
+			}
+
+			got := decodeSnapshot(t, payloads, tt.base)
+			want := with(tt.cur.Entities, tt.exceptID, nil)
+			got.Entities = with(got.Entities, tt.exceptID, nil) // Del receptor solo queda lo que tenía la baseline
+			if got.Tick != tt.cur.Tick || !slices.Equal(got.Entities, want) {
+				t.Errorf("decoded tick %d with %d entities, want tick %d with %d:\n got %+v\nwant %+v",
+					got.Tick, len(got.Entities), tt.cur.Tick, len(want), got.Entities, want)
+			}
+		})
+	}
+}
+
+func TestQuantizeState(t *testing.T) {
+	tests := []struct {
+		name string
+		in   StateData
+		want EntityState
+	}{
+		{"millimeters", StateData{X: 1.23, Y: -2.46, Z: 100}, EntityState{X: 12, Y: -25, Z: 1000}},
+		{"yaw quarter turns", StateData{Yaw: 90}, EntityState{Yaw: 16384}},
+		{"yaw half turn", StateData{Yaw: 180}, EntityState{Yaw: 32768}},
+		{"negative yaw", StateData{Yaw: -90}, EntityState{Yaw: 49152}},
+		{"yaw full turn wraps", StateData{Yaw: 360}, EntityState{Yaw: 0}},
+		{"yaw just below a turn wraps", StateData{Yaw: 359.999}, EntityState{Yaw: 0}},
+		{"yaw over a turn", StateData{Yaw: 450}, EntityState{Yaw: 16384}},
+		{"velocity", StateData{VX: 300.4, VY: -599.6, VZ: 0}, EntityState{VX: 300, VY: -600}},
+		{"position clamps", StateData{X: 1e10, Y: -1e10}, EntityState{X: math.MaxInt32, Y: math.MinInt32}},
+		{"velocity clamps", StateData{VX: 40_000, VZ: -40_000}, EntityState{VX: math.MaxInt16, VZ: math.MinInt16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.ID = 7
+			if got := QuantizeState(7, tt.in); got != tt.want {
+				t.Errorf("QuantizeState(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	// Lo que reconstruye el cliente queda a menos de medio milímetro y medio paso de yaw del original
+	in := StateData{X: 12345.678, Y: -0.04, Z: 87.65, Yaw: 123.456}
+	e := QuantizeState(1, in)
+	for _, c := range []struct{ got, orig, tol float64 }{
+		{float64(e.X) / PositionScale, float64(in.X), 0.05},
+		{float64(e.Y) / PositionScale, float64(in.Y), 0.05},
+		{float64(e.Z) / PositionScale, float64(in.Z), 0.05},
+		{float64(e.Yaw) * 360 / 65536, float64(in.Yaw), 360.0 / 65536 / 2},
+	} {
+		if math.Abs(c.got-c.orig) > c.tol+1e-6 {
+			t.Errorf("dequantized %v, original %v: error above %v", c.got, c.orig, c.tol)
+		}
+	}
+}
+
+func TestSnapshotAcks(t *testing.T) {
+	type sent struct{ seq, tick uint32 }
+	tests := []struct {
+		name   string
+		sent   []sent
+		acks   []uint32
+		forget uint32   // Si no es 0: Forget(forget) después de los acks
+		late   []uint32 // Acks que llegan después del Forget
+		want   uint32
+	}{
+		{name: "single fragment", sent: []sent{{1, 10}}, acks: []uint32{1}, want: 10},
+		{name: "fragment missing", sent: []sent{{1, 10}, {2, 10}}, acks: []uint32{1}, want: 0},
+		{name: "all fragments in any order", sent: []sent{{1, 10}, {2, 10}, {3, 10}}, acks: []uint32{3, 1, 2}, want: 10},
+		{name: "older snapshot completes later", sent: []sent{{1, 10}, {2, 11}}, acks: []uint32{2, 1}, want: 11},
+		{name: "duplicate ack", sent: []sent{{1, 10}, {2, 10}}, acks: []uint32{1, 1}, want: 0},
+		{name: "packet without snapshot", sent: []sent{{1, 10}}, acks: []uint32{5}, want: 0},
+		{name: "tick wraps around", sent: []sent{{1, math.MaxUint32}, {2, 2}}, acks: []uint32{2, 1}, want: 2},
+		{
+			name: "incomplete snapshot forgotten",
+			sent: []sent{{1, 10}, {2, 10}}, acks: []uint32{1},
+			forget: 11, late: []uint32{2},
+			want: 0,
+		},
+		{
+			name: "forget keeps newer snapshots",
+			sent: []sent{{1, 10}, {2, 12}}, acks: []uint32{},
+			forget: 11, late: []uint32{2},
+			want: 12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSnapshotAcks()
+			for _, p := range tt.sent {
+				s.Sent(p.seq, p.tick)
+			}
+			for _, seq := range tt.acks {
+				s.Acked(seq)
+			}
+			if tt.forget != 0 {
+				s.Forget(tt.forget)
+			}
+			for _, seq := range tt.late {
+				s.Acked(seq)
+			}
+			if got := s.Baseline(); got != tt.want {
+				t.Errorf("Baseline = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// Los Acks de la conexión llegan a SnapshotAcks: la foto confirmada pasa a ser la baseline del próximo delta
+func TestSnapshotBaselineFromEndpointAcks(t *testing.T) {
+	now := time.Unix(0, 0)
+	server, client := NewEndpoint(), NewEndpoint()
+	acks := NewSnapshotAcks()
+	server.OnAck = acks.Acked
+
+	snap := &Snapshot{Tick: 5, Entities: entities(100)}
+	var packets [][]byte
+	for _, payload := range EncodeSnapshot(snap, nil, 0) {
+		packets = append(packets, server.Send(PacketHeader{Type: PacketTypeSnapshot}, ChannelUnreliable, payload, now))
+		acks.Sent(server.LastSequence(), snap.Tick)
+	}
+
+	// Llegan todos los fragmentos menos el último: todavía no hay baseline
+	deliver := func(data []byte) {
+		h, err := DeserializeHeader(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client.Receive(h, data[HeaderSize:], now)
+	}
+	reply := func() {
+		h, err := DeserializeHeader(client.Send(PacketHeader{Type: PacketTypeAck}, ChannelUnreliable, nil, now))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server.Receive(h, nil, now)
+	}
+	for _, data := range packets[:len(packets)-1] {
+		deliver(data)
+	}
+	reply()
+	if got := acks.Baseline(); got != 0 {
+		t.Fatalf("Baseline = %d with a fragment missing, want 0", got)
+	}
+
+	deliver(packets[len(packets)-1])
+	reply()
+	if got := acks.Baseline(); got != snap.Tick {
+		t.Errorf("Baseline = %d, want %d", got, snap.Tick)
+	}
+}
@@ -0,0 +1,309 @@
+package world
+
+import (
+	"cmp"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+)
+
+// 🎓 SERVIDOR AUTORITATIVO
+// El cliente NO decide dónde está: solo PIDE moverse ("quiero estar en X, Y, Z").
+// El servidor guarda la posición verdadera de cada jugador, revisa que el pedido sea posible
+// (¿le alcanza la velocidad? ¿se teletransportó?) y recién ahí la acepta.
+// Si el pedido es imposible, el servidor manda una Corrección: "no, estás ACÁ".
+// Lo que ven los demás jugadores sale de este estado, nunca de los bytes que mandó el cliente.
+
+// Config son los límites de movimiento (en unidades de Unreal: 1 unidad = 1 cm)
+type Config struct {
+	MaxSpeed         float32       // Velocidad horizontal máxima (cm/s). 600 = MaxWalkSpeed por defecto de Unreal
+	MaxVerticalSpeed float32       // Velocidad vertical máxima (cm/s), subiendo o bajando. Tiene su propio crédito
+	SpeedTolerance   float32       // Margen para el jitter de la red (1.1 = se permite un 10% de más)
+	MaxBurst         time.Duration // Cuánto "crédito" de movimiento se puede acumular (paquetes que llegan juntos)
+	TeleportDistance float32       // Más que esto en un solo pedido es un teletransporte: se rechaza entero
+
+	// Tiempo mínimo entre dos correcciones al mismo jugador. Después de una corrección, los Moves que
+	// el cliente ya había enviado (desde la posición equivocada) siguen llegando durante un RTT:
+	// no tiene sentido responder cada uno con otra corrección.
+	CorrectionInterval time.Duration
+}
+
+// DefaultConfig devuelve límites razonables para un personaje caminando
+func DefaultConfig() Config {
+	return Config{
+		MaxSpeed:           600,
+		MaxVerticalSpeed:   1000, // Un salto de Unreal sube a 420 cm/s; cayendo un segundo se llega a ~980
+		SpeedTolerance:     1.1,
+		MaxBurst:           500 * time.Millisecond,
+		TeleportDistance:   1000,
+		CorrectionInterval: 200 * time.Millisecond,
+	}
+}
+
+// Si un jugador no pide moverse durante este tiempo, está quieto: su velocidad vuelve a 0
+// (si no, los demás lo seguirían extrapolando para siempre). Es más que un par de ticks
+// para que un paquete demorado no haga "parpadear" la velocidad.
+const stopAfter = 250 * time.Millisecond
+
+// Vec3 es un punto (o una velocidad) en el mundo
+type Vec3 struct {
+	X, Y, Z float32
+}
+
+// Sub devuelve v - o
+func (v Vec3) Sub(o Vec3) Vec3 { return Vec3{v.X - o.X, v.Y - o.Y, v.Z - o.Z} }
+
+// Add devuelve v + o
+func (v Vec3) Add(o Vec3) Vec3 { return Vec3{v.X + o.X, v.Y + o.Y, v.Z + o.Z} }
+
+// Scale devuelve v multiplicado por s
+func (v Vec3) Scale(s float32) Vec3 { return Vec3{v.X * s, v.Y * s, v.Z * s} }
+
+// Length es la distancia en 3D (para el teletransporte)
+func (v Vec3) Length() float32 { return float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y + v.Z*v.Z))) }
+
+// HorizontalLength es la distancia en el piso (X, Y), sin contar la altura (para la velocidad)
+func (v Vec3) HorizontalLength() float32 { return float32(math.Sqrt(float64(v.X*v.X + v.Y*v.Y))) }
+
+// finite indica que no hay NaN ni infinitos (un cliente roto o tramposo podría mandarlos)
+func finite(values ...float32) bool {
+	for _, f := range values {
+		if math.IsNaN(float64(f)) || math.IsInf(float64(f), 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// MoveResult es lo que decidió el servidor sobre un pedido de movimiento
+type MoveResult int
+
+const (
+	MoveAccepted MoveResult = iota // El pedido era posible: se aplicó tal cual
+	MoveClamped                    // Demasiado rápido: se avanzó solo lo permitido en esa dirección
+	MoveRejected                   // Teletransporte: se ignoró y el jugador se quedó donde estaba
+)
+
+// String permite loguear el resultado con nombre
+func (r MoveResult) String() string {
+	switch r {
+	case MoveAccepted:
+		return "accepted"
+	case MoveClamped:
+		return "clamped"
+	case MoveRejected:
+		return "rejected"
+	}
+	return "invalid"
+}
+
+// PlayerState es la verdad del servidor sobre un jugador
+type PlayerState struct {
+	ID        uint64
+	Position  Vec3
+	Yaw       float32
+	Velocity  Vec3   // cm/s, calculada con lo que se movió (sirve a los clientes para extrapolar)
+	LastInput uint32 // ChannelSeq del último Move procesado (el cliente lo usa para reconciliar)
+
+	budget         float32 // Crédito de movimiento horizontal que le queda (cm)
+	verticalBudget float32 // Crédito de movimiento vertical que le queda (cm)
+	lastMove       time.Time
+	lastCorrection time.Time
+	pending        *moveInput // El último pedido que llegó en este tick (si hubo)
+}
+
+// moveInput es un pedido de movimiento esperando al próximo tick
+type moveInput struct {
+	seq      uint32
+	position Vec3
+	yaw      float32
+}
+
+// Correction le dice a un cliente dónde está de verdad
+type Correction struct {
+	PlayerID  uint64
+	LastInput uint32 // Hasta qué Move (ChannelSeq) está incluido en esta posición
+	Position  Vec3
+	Yaw       float32
+	Reason    MoveResult
+}
+
+// StepResult es lo que pasó en un tick de simulación
+type StepResult struct {
+	Corrections []Correction // Correcciones para mandarle a cada dueño
+	Accepted    int
+	Clamped     int
+	Rejected    int
+}
+
+// World guarda el estado autoritativo de todos los jugadores.
+// 💡 No tiene candado (Mutex): solo lo usa el Tick Loop, que corre en una sola goroutine.
+type World struct {
+	cfg     Config
+	players map[uint64]*PlayerState
+	log     *slog.Logger
+}
+
+// New crea un mundo vacío
+func New(cfg Config, logger *slog.Logger) *World {
+	return &World{
+		cfg:     cfg,
+		players: make(map[uint64]*PlayerState),
+		log:     logger.With("component", "world"),
+	}
+}
+
+// QueueMove guarda el pedido de movimiento de un jugador para el próximo Step.
+// Si llegan varios en el mismo tick, queda el más nuevo: uno que no es más nuevo que el que ya espera
+// (o que el último procesado) se ignora. Devuelve false si trae números inválidos.
+func (w *World) QueueMove(playerID uint64, seq uint32, position Vec3, yaw float32) bool {
+	if !finite(position.X, position.Y, position.Z, yaw) {
+		return false
+	}
+	p, ok := w.players[playerID]
+	if !ok {
+		// ⚠️ Fase 0: todavía no hay mapa ni puntos de aparición, así que el primer Move
+		// define dónde aparece el jugador. Desde ahí, todo lo demás se valida.
+		p = &PlayerState{ID: playerID, Position: position, Yaw: yaw, LastInput: seq}
+		w.players[playerID] = p
+		w.log.Info("jugador aparece en el mundo", "player_id", playerID,
+			"x", position.X, "y", position.Y, "z", position.Z)
+		return true
+	}
+	newest := p.LastInput
+	if p.pending != nil {
+		newest = p.pending.seq
+	}
+	if !seqNewer(seq, newest) {
+		return true // Llegó tarde o repetido: ya hay uno más nuevo
+	}
+	p.pending = &moveInput{seq: seq, position: position, yaw: yaw}
+	return true
+}
+
+// seqNewer dice si el ChannelSeq a es más nuevo que b, aunque el contador haya dado la vuelta
+// (la misma cuenta que usa la capa de red: la resta en uint32 da la vuelta y el signo lo resuelve)
+func seqNewer(a, b uint32) bool {
+	return int32(a-b) > 0
+}
+
+// Remove saca a un jugador del mundo (se desconectó)
+func (w *World) Remove(playerID uint64) {
+	delete(w.players, playerID)
+}
+
+// Player devuelve una copia del estado de un jugador
+func (w *World) Player(playerID uint64) (PlayerState, bool) {
+	p, ok := w.players[playerID]
+	if !ok {
+		return PlayerState{}, false
+	}
+	return *p, true
+}
+
+// States devuelve una copia del estado de todos los jugadores, ordenados por ID (para el snapshot)
+func (w *World) States() []PlayerState {
+	states := make([]PlayerState, 0, len(w.players))
+	for _, p := range w.players {
+		states = append(states, *p)
+	}
+	slices.SortFunc(states, func(a, b PlayerState) int { return cmp.Compare(a.ID, b.ID) })
+	return states
+}
+
+// Step avanza la simulación un tick (dt) y aplica los pedidos de movimiento que llegaron.
+//
+// 🎓 CRÉDITO DE MOVIMIENTO: en cada tick, cada jugador gana MaxSpeed × dt centímetros de crédito
+// horizontal y MaxVerticalSpeed × dt de crédito vertical (cada uno con un tope de MaxBurst).
+// Moverse gasta crédito. Si un pedido cuesta más que el crédito, el jugador avanza solo lo que le alcanza.
+// El tope permite que dos paquetes que llegaron juntos (jitter) se acepten,
+// pero no que alguien "ahorre" quedándose quieto y después corra el doble.
+func (w *World) Step(now time.Time, dt time.Duration) StepResult {
+	var res StepResult
+	speed := w.cfg.MaxSpeed * w.cfg.SpeedTolerance
+	verticalSpeed := w.cfg.MaxVerticalSpeed * w.cfg.SpeedTolerance
+	burst := float32(w.cfg.MaxBurst.Seconds())
+
+	for _, p := range w.players {
+		p.budget = min(p.budget+speed*float32(dt.Seconds()), speed*burst)
+		p.verticalBudget = min(p.verticalBudget+verticalSpeed*float32(dt.Seconds()), verticalSpeed*burst)
+
+		in := p.pending
+		if in == nil {
+			if now.Sub(p.lastMove) > stopAfter {
+				p.Velocity = Vec3{}
+			}
+			continue
+		}
+		p.pending = nil
+		p.LastInput = in.seq
+
+		result, moved := w.validate(p, in)
+		switch result {
+		case MoveAccepted:
+			res.Accepted++
+		case MoveClamped:
+			res.Clamped++
+		case MoveRejected:
+			res.Rejected++
+		}
+
+		// Velocidad = lo que se movió / el tiempo desde el movimiento anterior (mínimo un tick)
+		elapsed := max(now.Sub(p.lastMove), dt)
+		if p.lastMove.IsZero() {
+			elapsed = dt
+		}
+		p.Velocity = moved.Scale(1 / float32(elapsed.Seconds()))
+		p.Position = p.Position.Add(moved)
+		p.Yaw = in.yaw
+		p.lastMove = now
+
+		if result != MoveAccepted && now.Sub(p.lastCorrection) >= w.cfg.CorrectionInterval {
+			p.lastCorrection = now
+			res.Corrections = append(res.Corrections, Correction{
+				PlayerID:  p.ID,
+				LastInput: p.LastInput,
+				Position:  p.Position,
+				Yaw:       p.Yaw,
+				Reason:    result,
+			})
+			w.log.Debug("movimiento corregido", "player_id", p.ID, "reason", result.String(),
+				"wanted", in.position, "got", p.Position)
+		}
+	}
+	return res
+}
+
+// validate decide cuánto se puede mover el jugador hacia lo que pidió
+func (w *World) validate(p *PlayerState, in *moveInput) (MoveResult, Vec3) {
+	delta := in.position.Sub(p.Position)
+
+	// Teletransporte: ni siquiera intentamos acercarlo (podría ser un hack o un cliente desincronizado)
+	if delta.Length() > w.cfg.TeleportDistance {
+		return MoveRejected, Vec3{}
+	}
+
+	// Cada eje contra su crédito: el piso (X, Y) contra el horizontal y la altura (Z) contra el vertical.
+	// Si alguno no alcanza, se avanza la parte que permite el más escaso, en la MISMA dirección
+	// (achicar solo un eje cambiaría hacia dónde va).
+	// ⚠️ Sin mapa todavía no hay gravedad ni colisiones: el crédito vertical solo frena a quien
+	// sube o baja más rápido de lo posible.
+	dist := delta.HorizontalLength()
+	height := float32(math.Abs(float64(delta.Z)))
+	scale := float32(1)
+	if dist > p.budget {
+		scale = p.budget / dist
+	}
+	if height > p.verticalBudget {
+		scale = min(scale, p.verticalBudget/height)
+	}
+	if scale == 1 {
+		p.budget -= dist
+		p.verticalBudget -= height
+		return MoveAccepted, delta
+	}
+	p.budget = max(p.budget-dist*scale, 0)
+	p.verticalBudget = max(p.verticalBudget-height*scale, 0)
+	return MoveClamped, delta.Scale(scale)
+}
@@ -0,0 +1,111 @@
+package world
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+)
+
+const tick = time.Second / 30
+
+func newTestWorld() *World {
+	return New(DefaultConfig(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// step mueve al jugador 1 hasta `to` con un Move y devuelve el resultado y dónde quedó
+func step(t *testing.T, w *World, now time.Time, seq uint32, to Vec3) (StepResult, PlayerState) {
+	t.Helper()
+	if !w.QueueMove(1, seq, to, 0) {
+		t.Fatalf("QueueMove(%v) = false", to)
+	}
+	res := w.Step(now, tick)
+	p, _ := w.Player(1)
+	return res, p
+}
+
+func TestVerticalSpeedIsLimited(t *testing.T) {
+	cfg := DefaultConfig()
+	verticalPerTick := cfg.MaxVerticalSpeed * cfg.SpeedTolerance * float32(tick.Seconds())
+
+	cases := []struct {
+		name      string
+		to        Vec3
+		want      Vec3
+		wantClamp bool
+	}{
+		{name: "jump within budget", to: Vec3{Z: verticalPerTick / 2}, want: Vec3{Z: verticalPerTick / 2}},
+		{name: "fly straight up", to: Vec3{Z: 900}, want: Vec3{Z: verticalPerTick}, wantClamp: true},
+		{name: "fall straight down", to: Vec3{Z: -900}, want: Vec3{Z: -verticalPerTick}, wantClamp: true},
+		// Demasiado alto y en diagonal: se achican los tres ejes, así sigue en la misma dirección
+		{name: "diagonal too high", to: Vec3{X: 10, Z: 900}, want: Vec3{X: 10 * verticalPerTick / 900, Z: verticalPerTick}, wantClamp: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorld()
+			start := time.Unix(0, 0)
+			w.QueueMove(1, 0, Vec3{}, 0) // Aparece en el origen
+
+			res, p := step(t, w, start.Add(tick), 1, tc.to)
+			if clamped := res.Clamped == 1; clamped != tc.wantClamp {
+				t.Errorf("clamped = %v, want %v", clamped, tc.wantClamp)
+			}
+			if !near(p.Position, tc.want) {
+				t.Errorf("position = %v, want %v", p.Position, tc.want)
+			}
+		})
+	}
+}
+
+// Con el crédito horizontal agotado, la altura se achica en la misma proporción que el piso
+func TestClampScalesHeight(t *testing.T) {
+	cfg := DefaultConfig()
+	horizontalPerTick := cfg.MaxSpeed * cfg.SpeedTolerance * float32(tick.Seconds())
+
+	w := newTestWorld()
+	w.QueueMove(1, 0, Vec3{}, 0)
+	_, p := step(t, w, time.Unix(0, 0).Add(tick), 1, Vec3{X: 400, Z: 20})
+
+	scale := horizontalPerTick / 400
+	if want := (Vec3{X: 400 * scale, Z: 20 * scale}); !near(p.Position, want) {
+		t.Errorf("position = %v, want %v", p.Position, want)
+	}
+}
+
+func TestQueueMoveIgnoresOlderSequences(t *testing.T) {
+	cases := []struct {
+		name   string
+		spawn  uint32   // ChannelSeq del primer Move (aparece)
+		queued []uint32 // Moves que llegan en el mismo tick, en este orden
+		want   uint32   // LastInput después del tick
+	}{
+		{name: "newest wins", spawn: 1, queued: []uint32{2, 3}, want: 3},
+		{name: "late move is ignored", spawn: 1, queued: []uint32{3, 2}, want: 3},
+		{name: "repeated move is ignored", spawn: 1, queued: []uint32{3, 3}, want: 3},
+		{name: "older than last processed", spawn: 5, queued: []uint32{4}, want: 5},
+		{name: "wraparound", spawn: math.MaxUint32, queued: []uint32{0, math.MaxUint32}, want: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := newTestWorld()
+			w.QueueMove(1, tc.spawn, Vec3{}, 0)
+			for _, seq := range tc.queued {
+				w.QueueMove(1, seq, Vec3{X: float32(seq%10 + 1)}, 0)
+			}
+			w.Step(time.Unix(0, 0).Add(tick), tick)
+
+			p, _ := w.Player(1)
+			if p.LastInput != tc.want {
+				t.Errorf("LastInput = %d, want %d", p.LastInput, tc.want)
+			}
+			if wantX := float32(tc.want%10 + 1); tc.want != tc.spawn && p.Position.X != wantX {
+				t.Errorf("position.X = %v, want %v (the move with seq %d)", p.Position.X, wantX, tc.want)
+			}
+		})
+	}
+}
+
+func near(a, b Vec3) bool {
+	return a.Sub(b).Length() < 0.01
+}
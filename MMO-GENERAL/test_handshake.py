@@ -28,19 +28,22 @@ def test_handshake():
     # byte 0: Tipo de paquete (0 = Handshake)
     # bytes 1-4: Secuencia (0 en el primer saludo)
     # bytes 5-12: PlayerID (0 porque aún no tenemos uno)
+    # bytes 13-20: Token de sesión (0 porque aún no tenemos uno)
+    # bytes 21-33: Ack, AckBits, Channel, ChannelSeq (capa confiable, 0 en el Handshake)
     #
-    # '>BIQ' usa el estándar "Big Endian" para que el servidor lo entienda.
+    # '>BIQQIIBI' usa el estándar "Big Endian" para que el servidor lo entienda.
     packet_type = 0
     sequence = 0
     player_id = 0
+    token = 0
     
-    packet = struct.pack(">BIQ", packet_type, sequence, player_id)
+    packet = struct.pack(">BIQQIIBI", packet_type, sequence, player_id, token, 0, 0, 0, 0)
     
     print(f"📡 Enviando Handshake a {SERVER_IP}:{SERVER_PORT}...")
     print(f"📦 Contenido del paquete (hex): {packet.hex()}")
 
     try:
-        # 3. Enviar los 13 bytes al servidor
+        # 3. Enviar los 34 bytes al servidor
         sock.sendto(packet, (SERVER_IP, SERVER_PORT))
 
         # 4. Esperar la respuesta (bloqueante hasta recibir datos o timeout)
@@ -48,14 +51,16 @@ def test_handshake():
         print(f"✅ Respuesta recibida de {addr}")
         
         # 5. Deserializar la respuesta del servidor
-        # El servidor nos devuelve el mismo formato de 13 bytes,
-        # pero con el PlayerID que nos ha asignado.
-        res_type, res_seq, res_id = struct.unpack(">BIQ", data[:13])
+        # El servidor nos devuelve el mismo formato de 34 bytes,
+        # pero con el PlayerID y el Token de sesión que nos ha asignado.
+        res_type, res_seq, res_id, res_token = struct.unpack(">BIQQ", data[:21])  # Los primeros 21 bytes alcanzan
         
         print("\n--- RESULTADO DEL SERVIDOR ---")
         print(f"Tipo de Paquete: {res_type} (Confirmación de Handshake)")
         print(f"Secuencia: {res_seq}")
         print(f"TU PLAYER ID ASIGNADO: {res_id}")
+        print(f"TOKEN DE SESIÓN: {res_token:016x} (va en la cabecera de todos tus paquetes)")
+        print("La sesión empieza con tu primer paquete que traiga ese token (ver test_heartbeat.py)")
         print("-----------------------------\n")
 
     except socket.timeout:
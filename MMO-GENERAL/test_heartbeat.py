@@ -0,0 +1,94 @@
+import socket
+import struct
+import time
+
+# =============================================================================
+# EXPLICACIÓN DEL TEST DE HEARTBEAT (LATIDO Y DESCONEXIÓN)
+# =============================================================================
+# UDP no tiene "conexión": si un cliente se cierra, el servidor no se entera.
+# Por eso el cliente manda latidos (tipo 2) y el servidor desconecta al que se calla.
+#
+# OBJETIVOS DEL TEST:
+# 1. RTT: ¿El servidor responde el latido con la misma Secuencia y su hora?
+# 2. Timeout: ¿Un jugador que deja de mandar paquetes es desconectado (10s)?
+# 3. Despawn: ¿El resto de los jugadores recibe el aviso (tipo 3) con su PlayerID?
+# =============================================================================
+
+SERVER_IP = "192.168.0.100"
+SERVER_PORT = 8080
+SERVER = (SERVER_IP, SERVER_PORT)
+
+
+HEADER = ">BIQQIIBI"  # Tipo, Secuencia, PlayerID, Token, Ack, AckBits, Canal, SecuenciaDeCanal (34 bytes)
+
+
+def handshake(sock):
+    # Cabecera de 34 bytes: Tipo 0 (Handshake) y todo lo demás en 0
+    sock.sendto(struct.pack(HEADER, 0, 0, 0, 0, 0, 0, 0, 0), SERVER)
+    data, _ = sock.recvfrom(1024)
+    _, _, player_id, token = struct.unpack(">BIQQ", data[:21])
+    return player_id, token
+
+
+def wait_for(sock, packet_type):
+    # El servidor manda un Snapshot (tipo 5) por tick: los salteamos hasta encontrar el tipo buscado
+    while True:
+        data, _ = sock.recvfrom(2048)
+        if data[0] == packet_type:
+            return data
+
+
+def test_heartbeat():
+    # Dos jugadores: A se va a "caer", B se queda mirando
+    sock_a = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+    sock_b = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+    sock_a.settimeout(2.0)
+    sock_b.settimeout(2.0)
+
+    id_a, token_a = handshake(sock_a)
+    id_b, token_b = handshake(sock_b)
+    print(f"✅ Jugadores conectados: A={id_a} B={id_b}")
+
+    # -------------------------------------------------------------------------
+    # PASO 1: LATIDO Y RTT
+    # -------------------------------------------------------------------------
+    # La respuesta trae: cabecera (34 bytes) + Secuencia de nuestro latido ('I') + hora del servidor ('Q')
+    sent_at = time.time()
+    sock_a.sendto(struct.pack(HEADER, 2, 42, id_a, token_a, 0, 0, 0, 0), SERVER)
+    data = wait_for(sock_a, 2)
+    rtt_ms = (time.time() - sent_at) * 1000
+    res_type = data[0]
+    res_seq, server_ms = struct.unpack(">IQ", data[34:46])
+    print(f"💓 Latido respondido: Tipo={res_type} Secuencia={res_seq} (esperada 42) RTT={rtt_ms:.1f}ms")
+    print(f"   Diferencia de relojes aprox: {server_ms - sent_at * 1000:.0f}ms")
+
+    # -------------------------------------------------------------------------
+    # PASO 2: A SE CALLA, B SIGUE LATIENDO
+    # -------------------------------------------------------------------------
+    print("\n⏳ A deja de mandar paquetes. Esperando el Despawn (unos 10s)...")
+    deadline = time.time() + 15
+    seq_b = 0
+    while time.time() < deadline:
+        seq_b += 1  # Cada paquete con una Secuencia nueva: si se repite, el servidor lo toma como duplicado
+        sock_b.sendto(struct.pack(HEADER, 2, seq_b, id_b, token_b, 0, 0, 0, 0), SERVER)
+        gone_id = None
+        until = time.time() + 1  # Un latido por segundo; mientras tanto leemos todo lo que llegue
+        while gone_id is None and time.time() < until:
+            try:
+                data, _ = sock_b.recvfrom(2048)
+            except socket.timeout:
+                continue
+            if data[0] == 3:
+                _, _, gone_id, _ = struct.unpack(">BIQQ", data[:21])  # Sin payload: el PlayerID dice quién se fue
+        if gone_id is not None:
+            print(f"👋 Despawn recibido: el jugador {gone_id} se desconectó (esperado {id_a})")
+            break
+    else:
+        print("❌ ERROR: No llegó el Despawn.")
+
+    sock_a.close()
+    sock_b.close()
+
+
+if __name__ == "__main__":
+    test_heartbeat()
@@ -25,20 +25,22 @@ def test_movement():
     # -------------------------------------------------------------------------
     # PASO 1: HANDSHAKE (Saludo inicial)
     # -------------------------------------------------------------------------
-    # Enviamos 13 bytes vacíos pero con Tipo 0 para pedir un PlayerID.
-    # struct.pack ">BIQ" significa: 
+    # Enviamos 34 bytes vacíos pero con Tipo 0 para pedir un PlayerID.
+    # struct.pack ">BIQQIIBI" significa: 
     #   > : Big Endian (Estándar de red)
     #   B : unsigned char (1 byte) - Tipo de paquete (0: Handshake)
     #   I : unsigned int  (4 bytes) - Secuencia (0 por ahora)
     #   Q : unsigned long long (8 bytes) - PlayerID (0 porque somos nuevos)
-    packet = struct.pack(">BIQ", 0, 0, 0)
+    #   Q : unsigned long long (8 bytes) - Token de sesión (0 porque somos nuevos)
+    #   I, I, B, I : Ack, AckBits, Channel, ChannelSeq (capa confiable, 0 en el Handshake)
+    packet = struct.pack(">BIQQIIBI", 0, 0, 0, 0, 0, 0, 0, 0)
     
     print(f"📡 Paso 1: Enviando Handshake a {SERVER_IP}...")
     sock.sendto(packet, (SERVER_IP, SERVER_PORT))
     
-    # El servidor nos responde con nuestro ID asignado
+    # El servidor nos responde con nuestro ID y nuestro Token asignados
     data, addr = sock.recvfrom(1024)
-    res_type, res_seq, player_id = struct.unpack(">BIQ", data[:13])
+    res_type, res_seq, player_id, token = struct.unpack(">BIQQ", data[:21])
     print(f"✅ Handshake exitoso. El servidor nos asignó el ID: {player_id}")
 
     # -------------------------------------------------------------------------
@@ -49,14 +51,16 @@ def test_movement():
     # Cada número es un 'float' (4 bytes). 4 floats = 16 bytes.
     x, y, z, yaw = 100.5, 200.0, 50.25, 90.0
     
-    # Construimos la CABECERA (13 bytes) indicando que es tipo 1 (Move)
-    move_header = struct.pack(">BIQ", 1, 1, player_id)
+    # Construimos la CABECERA (34 bytes) indicando que es tipo 1 (Move).
+    # Sin el Token correcto, el servidor descarta el paquete (anti-spoofing).
+    # Canal 1 (unreliable_sequenced): si llega una posición más vieja que la última, se descarta.
+    move_header = struct.pack(">BIQQIIBI", 1, 1, player_id, token, 0, 0, 1, 1)
     
     # Construimos el PAYLOAD (16 bytes) con las coordenadas
     # 'ffff' significa formatear 4 floats
     move_payload = struct.pack(">ffff", x, y, z, yaw)
     
-    # El paquete total que viaja por el cable son 29 bytes (13 + 16)
+    # El paquete total que viaja por el cable son 50 bytes (34 + 16)
     full_packet = move_header + move_payload
     
     print(f"\n📡 Paso 2: Enviando posición simulada (X:{x}, Y:{y}, Z:{z})...")
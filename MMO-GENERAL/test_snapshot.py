@@ -0,0 +1,192 @@
+import random
+import socket
+import struct
+import time
+
+# =============================================================================
+# EXPLICACIÓN DEL TEST DE SNAPSHOTS (FOTOS DEL MUNDO CON COMPRESIÓN DELTA)
+# =============================================================================
+# En cada tick el servidor manda a cada cliente un Snapshot (tipo 5) con SOLO lo que
+# cambió desde la última foto que ese cliente confirmó (su "baseline").
+# Para que el servidor sepa qué fotos llegaron, el cliente confirma los paquetes
+# (Ack + AckBits en la cabecera), igual que en la capa de UDP confiable.
+#
+# Este script es un cliente de referencia: reconstruye el mundo aplicando los deltas
+# y además "pierde" a propósito un 20% de los paquetes para ver que se recupera solo.
+#
+# OBJETIVOS DEL TEST:
+# 1. Baseline: el primer snapshot es completo (Baseline 0); los siguientes son deltas.
+# 2. Pérdida: aunque se pierdan fotos, el mundo reconstruido coincide con el del servidor.
+# 3. Ancho de banda: un jugador quieto no ocupa bytes; uno que camina, pocos (cuantizado).
+# =============================================================================
+
+SERVER_IP = "192.168.0.100"
+SERVER_PORT = 8080
+SERVER = (SERVER_IP, SERVER_PORT)
+
+HEADER = ">BIQQIIBI"  # Tipo, Secuencia, PlayerID, Token, Ack, AckBits, Canal, SecuenciaDeCanal (34 bytes)
+HEADER_SIZE = 34
+TICK = 1 / 30
+LOSS = 0.2
+
+# Bits de Flags de cada entidad (ver internal/network/snapshot.go)
+F_POSITION, F_POSITION_DELTA, F_YAW, F_VELOCITY, F_REMOVED = 1, 2, 4, 8, 16
+
+
+class Client:
+    def __init__(self):
+        self.sock = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+        self.sock.settimeout(2.0)
+        self.seq = 0
+        self.move_seq = 0
+        self.received = set()  # Sequences del servidor que recibimos (para armar Ack + AckBits)
+        self.sock.sendto(struct.pack(HEADER, 0, 0, 0, 0, 0, 0, 0, 0), SERVER)
+        data, _ = self.sock.recvfrom(2048)
+        _, _, self.id, self.token = struct.unpack(">BIQQ", data[:21])
+        self.sock.settimeout(0.005)
+
+        self.complete = {}  # Tick -> {id: estado} de las fotos que recibimos COMPLETAS (posibles baselines)
+        self.partial = {}   # Tick -> foto en construcción (faltan fragmentos)
+        self.latest = 0     # Última foto completa aplicada
+        self.stats = {"packets": 0, "bytes": 0, "full": 0, "lost": 0}
+
+        # El primer paquete con el token crea la sesión: recién ahí empiezan a llegar Snapshots
+        self.ack()
+
+    def acks(self):
+        if not self.received:
+            return 0, 0
+        ack = max(self.received)
+        bits = 0
+        for i in range(32):
+            if ack - 1 - i in self.received:
+                bits |= 1 << i
+        return ack, bits
+
+    def send(self, packet_type, channel, channel_seq, payload=b""):
+        self.seq += 1
+        ack, bits = self.acks()
+        header = struct.pack(HEADER, packet_type, self.seq, self.id, self.token, ack, bits, channel, channel_seq)
+        self.sock.sendto(header + payload, SERVER)
+
+    def move(self, x, y, z, yaw=0.0):
+        self.move_seq += 1
+        self.send(1, 1, self.move_seq, struct.pack(">ffff", x, y, z, yaw))
+
+    def ack(self):
+        self.send(4, 0, 0)  # Tipo 4: solo confirma lo recibido
+
+    def poll(self):
+        while True:
+            try:
+                data, _ = self.sock.recvfrom(2048)
+            except (socket.timeout, BlockingIOError):
+                return
+            if random.random() < LOSS:
+                self.stats["lost"] += 1
+                continue  # "Se perdió": ni lo procesamos ni lo confirmamos
+            self.received.add(struct.unpack(">I", data[1:5])[0])
+            if data[0] == 5:
+                self.apply_snapshot(data[HEADER_SIZE:])
+
+    def apply_snapshot(self, payload):
+        tick, baseline, frag, frag_count, count = struct.unpack(">IIHHH", payload[:14])
+        self.stats["packets"] += 1
+        self.stats["bytes"] += len(payload)
+        if baseline == 0 and frag == 0:
+            self.stats["full"] += 1
+        if tick <= self.latest:
+            return  # Más vieja que la que ya tenemos
+        if baseline != 0 and baseline not in self.complete:
+            raise RuntimeError(f"baseline {baseline} desconocida")
+
+        snap = self.partial.setdefault(tick, {
+            "entities": {k: dict(v) for k, v in self.complete.get(baseline, {}).items()},
+            "got": set(),
+        })
+        entities, offset = snap["entities"], 14
+        for _ in range(count):
+            entity_id, flags = struct.unpack(">QB", payload[offset:offset + 9])
+            offset += 9
+            if flags & F_REMOVED:
+                entities.pop(entity_id, None)
+                continue
+            e = entities.setdefault(entity_id, {"x": 0, "y": 0, "z": 0, "yaw": 0, "v": (0, 0, 0)})
+            if flags & F_POSITION and flags & F_POSITION_DELTA:
+                dx, dy, dz = struct.unpack(">hhh", payload[offset:offset + 6])
+                e["x"], e["y"], e["z"] = e["x"] + dx, e["y"] + dy, e["z"] + dz
+                offset += 6
+            elif flags & F_POSITION:
+                e["x"], e["y"], e["z"] = struct.unpack(">iii", payload[offset:offset + 12])
+                offset += 12
+            if flags & F_YAW:
+                (e["yaw"],) = struct.unpack(">H", payload[offset:offset + 2])
+                offset += 2
+            if flags & F_VELOCITY:
+                e["v"] = struct.unpack(">hhh", payload[offset:offset + 6])
+                offset += 6
+
+        snap["got"].add(frag)
+        if len(snap["got"]) == frag_count:
+            # Foto completa: pasa a ser una baseline posible y el nuevo estado del mundo
+            self.complete[tick] = snap["entities"]
+            self.latest = tick
+            self.partial = {t: s for t, s in self.partial.items() if t > tick}
+            self.complete = {t: s for t, s in self.complete.items() if t > tick - 64}
+
+    def world(self):
+        return self.complete.get(self.latest, {})
+
+
+def test_snapshot():
+    random.seed(7)
+    a, b = Client(), Client()
+    print(f"✅ Jugadores conectados: A={a.id} (camina) B={b.id} (observa, pierde {LOSS:.0%} de los paquetes)")
+
+    a.move(0.0, 0.0, 0.0)
+    x = 0.0
+
+    # -------------------------------------------------------------------------
+    # PASO 1: A CAMINA 2 SEGUNDOS (300 cm/s), B CONFIRMA LO QUE LE LLEGA
+    # -------------------------------------------------------------------------
+    for _ in range(int(2 / TICK)):
+        x += 300 * TICK
+        a.move(x, 0.0, 0.0, 90.0)
+        a.poll()
+        a.ack()
+        b.poll()
+        b.ack()
+        time.sleep(TICK)
+    walking = dict(b.stats)
+
+    # -------------------------------------------------------------------------
+    # PASO 2: A SE QUEDA QUIETO 1 SEGUNDO
+    # -------------------------------------------------------------------------
+    for _ in range(int(1 / TICK)):
+        a.move(x, 0.0, 0.0, 90.0)
+        a.poll()
+        a.ack()
+        b.poll()
+        b.ack()
+        time.sleep(TICK)
+
+    seen = b.world().get(a.id)
+    print(f"\n📦 B recibió {b.stats['packets']} snapshots ({b.stats['lost']} perdidos a propósito), "
+          f"{b.stats['full']} completos")
+    print(f"   Caminando: {walking['bytes'] / max(walking['packets'], 1):.0f} bytes por snapshot")
+    idle = (b.stats["bytes"] - walking["bytes"]) / max(b.stats["packets"] - walking["packets"], 1)
+    print(f"   Quieto:    {idle:.0f} bytes por snapshot (solo la cabecera del snapshot)")
+
+    if seen is None:
+        print("❌ ERROR: B no conoce a A")
+    else:
+        seen_x, yaw = seen["x"] / 10, seen["yaw"] * 360 / 65536
+        ok = abs(seen_x - x) < 0.1 and seen["v"] == (0, 0, 0)
+        print(f"\n{'✅' if ok else '❌'} B ve a A en X={seen_x:.1f} (A está en {x:.1f}), yaw={yaw:.0f}°, velocidad={seen['v']}")
+
+    a.sock.close()
+    b.sock.close()
+
+
+if __name__ == "__main__":
+    test_snapshot()
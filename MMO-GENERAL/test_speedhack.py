@@ -0,0 +1,122 @@
+import socket
+import struct
+import time
+
+# =============================================================================
+# EXPLICACIÓN DEL TEST DE MOVIMIENTO AUTORITATIVO (ANTI SPEED-HACK)
+# =============================================================================
+# El cliente solo PIDE moverse. El servidor guarda la posición verdadera y,
+# si el pedido es imposible, responde con una Corrección (tipo 6).
+# Los demás jugadores reciben el estado calculado por el servidor en los Snapshots (tipo 5).
+#
+# OBJETIVOS DEL TEST:
+# 1. Movimiento normal: caminar despacio NO genera correcciones.
+# 2. Speed hack: pedir moverse 10 veces más rápido genera una Corrección.
+# 3. Teletransporte: saltar 50 metros se rechaza y la Corrección trae la posición anterior.
+# 4. Snapshots: el otro jugador recibe el mundo calculado por el servidor (decodificado en test_snapshot.py).
+# =============================================================================
+
+SERVER_IP = "192.168.0.100"
+SERVER_PORT = 8080
+SERVER = (SERVER_IP, SERVER_PORT)
+
+HEADER = ">BIQQIIBI"  # Tipo, Secuencia, PlayerID, Token, Ack, AckBits, Canal, SecuenciaDeCanal (34 bytes)
+TICK = 1 / 30
+
+
+class Client:
+    def __init__(self):
+        self.sock = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+        self.sock.settimeout(0.05)
+        self.seq = 0
+        self.move_seq = 0
+        self.sock.sendto(struct.pack(HEADER, 0, 0, 0, 0, 0, 0, 0, 0), SERVER)
+        self.sock.settimeout(2.0)
+        data, _ = self.sock.recvfrom(1024)
+        _, _, self.id, self.token = struct.unpack(">BIQQ", data[:21])
+        self.sock.settimeout(0.05)
+        # El primer paquete con el token crea la sesión: hasta entonces el servidor no nos conoce
+        self.seq += 1
+        self.sock.sendto(struct.pack(HEADER, 4, self.seq, self.id, self.token, 0, 0, 0, 0), SERVER)
+
+    def move(self, x, y, z, yaw=0.0):
+        # Canal 1 (unreliable_sequenced) con su propia secuencia de canal
+        self.seq += 1
+        self.move_seq += 1
+        header = struct.pack(HEADER, 1, self.seq, self.id, self.token, 0, 0, 1, self.move_seq)
+        self.sock.sendto(header + struct.pack(">ffff", x, y, z, yaw), SERVER)
+
+    def receive(self, packet_type):
+        # Devuelve los payloads del tipo pedido que ya llegaron (sin esperar: el servidor manda
+        # un Snapshot por tick, así que siempre hay algo en camino)
+        found = []
+        self.sock.setblocking(False)
+        while True:
+            try:
+                data, _ = self.sock.recvfrom(2048)
+            except BlockingIOError:
+                self.sock.settimeout(0.05)
+                return found
+            if data[0] == packet_type:
+                found.append((struct.unpack(">Q", data[5:13])[0], data[34:]))
+
+
+def walk(client, start_x, speed, seconds):
+    # Mueve al cliente en X a `speed` cm/s, un Move por tick (30Hz)
+    x = start_x
+    for _ in range(int(seconds / TICK)):
+        x += speed * TICK
+        client.move(x, 0.0, 0.0)
+        time.sleep(TICK)
+    return x
+
+
+def test_speedhack():
+    a, b = Client(), Client()
+    print(f"✅ Jugadores conectados: A={a.id} (se mueve) B={b.id} (observa)")
+
+    a.move(0.0, 0.0, 0.0)  # El primer Move define dónde aparece
+    time.sleep(0.1)
+
+    # -------------------------------------------------------------------------
+    # PASO 1: CAMINAR (300 cm/s, la mitad del máximo)
+    # -------------------------------------------------------------------------
+    x = walk(a, 0.0, 300, 1.0)
+    corrections = a.receive(6)
+    states = b.receive(5)
+    print(f"🚶 Caminando hasta X={x:.0f}: {len(corrections)} correcciones (esperadas 0), B recibió {len(states)} snapshots")
+
+    # -------------------------------------------------------------------------
+    # PASO 2: SPEED HACK (6000 cm/s, 10 veces el máximo)
+    # -------------------------------------------------------------------------
+    time.sleep(0.5)
+    a.receive(6)
+    walk(a, x, 6000, 0.5)
+    corrections = a.receive(6)
+    print(f"\n🏎️  Speed hack: {len(corrections)} correcciones (esperadas > 0)")
+    if corrections:
+        # Posición en milímetros (int32) y yaw en 0..65535 (uint16), igual que en los snapshots
+        last_input, cx, cy, cz, cyaw = struct.unpack(">IiiiH", corrections[-1][1][:18])
+        x = cx / 10
+        print(f"   El servidor dice: estás en X={x:.0f} (hasta el Move #{last_input})")
+
+    # -------------------------------------------------------------------------
+    # PASO 3: TELETRANSPORTE (50 metros de golpe)
+    # -------------------------------------------------------------------------
+    time.sleep(0.5)
+    a.receive(6)
+    a.move(x + 5000, 0.0, 0.0)
+    time.sleep(0.2)
+    corrections = a.receive(6)
+    if corrections:
+        _, cx, _, _, _ = struct.unpack(">IiiiH", corrections[-1][1][:18])
+        print(f"\n🌀 Teletransporte rechazado: el servidor te deja en X={cx / 10:.0f} (pediste {x + 5000:.0f})")
+    else:
+        print("\n❌ ERROR: el teletransporte no generó una Corrección")
+
+    a.sock.close()
+    b.sock.close()
+
+
+if __name__ == "__main__":
+    test_speedhack()
@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/platform-kafka-admin/internal/core"
 	"github.com/EELorenzoni/rpg-microservices-learning/platform-kafka-admin/internal/handlers"
@@ -34,14 +36,27 @@ func main() {
 	service := core.NewAdminService(brokerAddress)
 
 	// 2. Handlers
-	handler := handlers.NewAdminHandler(service)
+	handler := handlers.NewAdminHandler(service, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	health := handlers.NewHealthHandler(2*time.Second, map[string]handlers.Checker{
+		"kafka": handlers.CheckerFunc(service.Ping),
+	})
 
 	// 3. Router
 	r := gin.Default()
 
+	// Health (liveness / readiness)
+	r.GET("/healthz", health.Liveness)
+	r.GET("/readyz", health.Readiness)
+
 	r.POST("/topics", handler.CreateTopic)
 	r.GET("/topics", handler.ListTopics)
 	r.DELETE("/topics/:name", handler.DeleteTopic)
 
+	// DLQ inspection & replay
+	r.GET("/dlq/:topic", handler.DLQSummary)
+	r.GET("/dlq/:topic/messages", handler.BrowseDLQ)
+	r.POST("/dlq/:topic/replay", handler.ReplayDLQ)
+	r.GET("/dlq-replays", handler.ListReplays)
+
 	r.Run(port)
 }
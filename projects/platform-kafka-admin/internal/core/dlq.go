@@ -0,0 +1,552 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Headers written by the section-05 ConsumerHandler when it dead-letters a message.
+const (
+	HeaderOriginalTopic = "original-topic"
+	HeaderErrorReason   = "error-reason"
+	HeaderReplayedFrom  = "replayed-from"
+	HeaderReplayedBy    = "replayed-by"
+)
+
+// controlHeaders are stripped on replay: the message goes back as if it were new.
+var controlHeaders = map[string]bool{
+	HeaderOriginalTopic: true,
+	HeaderErrorReason:   true,
+	"retry-attempt":     true,
+	"retry-not-before":  true,
+}
+
+const (
+	maxPageSize     = 100
+	readTimeout     = 5 * time.Second
+	maxBatchSize    = 10e6            // 10MB
+	replayTimeout   = 2 * time.Minute // Overall deadline for one replay (reads + writes)
+	replayBatchSize = 100             // Messages per WriteMessages call
+)
+
+var (
+	// ErrNoOriginalTopic is returned when a DLQ message has no original-topic header.
+	ErrNoOriginalTopic = errors.New("message has no original-topic header")
+	// ErrTopicNotFound is returned when the DLQ topic does not exist.
+	ErrTopicNotFound = errors.New("topic not found")
+	// ErrPartitionNotFound is returned when the DLQ topic has no such partition.
+	ErrPartitionNotFound = errors.New("partition not found")
+)
+
+// DLQPartition summarizes one partition of a DLQ topic.
+type DLQPartition struct {
+	Partition     int   `json:"partition"`
+	FirstOffset   int64 `json:"first_offset"`
+	HighWatermark int64 `json:"high_watermark"` // Offset of the NEXT message to be written
+	Messages      int64 `json:"messages"`
+}
+
+// DLQMessage is a dead-lettered message ready to be inspected.
+type DLQMessage struct {
+	Partition     int               `json:"partition"`
+	Offset        int64             `json:"offset"`
+	Key           string            `json:"key"`
+	Time          time.Time         `json:"time"`
+	OriginalTopic string            `json:"original_topic"`
+	ErrorReason   string            `json:"error_reason"`
+	Headers       map[string]string `json:"headers"`
+	Payload       string            `json:"payload"`
+}
+
+// DLQPage is one page of messages from a single partition.
+// 🎓 PAGINATION: Kafka offsets are the natural cursor. Ask again with `offset=next_offset`.
+type DLQPage struct {
+	Topic         string       `json:"topic"`
+	Partition     int          `json:"partition"`
+	FirstOffset   int64        `json:"first_offset"`
+	HighWatermark int64        `json:"high_watermark"`
+	NextOffset    *int64       `json:"next_offset,omitempty"` // nil = no more messages
+	Messages      []DLQMessage `json:"messages"`
+}
+
+// ReplaySelection identifies a DLQ message to replay, optionally with an edited payload.
+type ReplaySelection struct {
+	Partition int     `json:"partition"`
+	Offset    int64   `json:"offset"`
+	Payload   *string `json:"payload,omitempty"` // nil = replay the original payload
+}
+
+// ReplayFailure explains why one message could not be replayed.
+type ReplayFailure struct {
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Error     string `json:"error"`
+}
+
+// ReplayedMessage records one DLQ message that was sent back to its original topic.
+type ReplayedMessage struct {
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+	To        string `json:"to"` // Original topic the message was written to
+	Edited    bool   `json:"edited"`
+}
+
+// ReplayAudit records who replayed what and when.
+//
+// ⚠️ Actor is self-asserted: it comes from the request body or the X-Actor header and
+// is NOT authenticated. It is good enough to tell teammates apart, not to prove who did it.
+type ReplayAudit struct {
+	ID        int               `json:"id"`
+	Topic     string            `json:"topic"`
+	Actor     string            `json:"actor"`
+	At        time.Time         `json:"at"`
+	All       bool              `json:"all"`
+	Requested int               `json:"requested"`
+	Replayed  int               `json:"replayed"`
+	Edited    int               `json:"edited"`
+	Messages  []ReplayedMessage `json:"messages,omitempty"` // What was replayed (partition/offset)
+	Failures  []ReplayFailure   `json:"failures,omitempty"`
+}
+
+// replayLog is the in-memory audit trail (lost on restart).
+type replayLog struct {
+	mu      sync.Mutex
+	entries []ReplayAudit
+}
+
+// DLQSummary returns the partitions of a DLQ topic and how many messages each holds.
+func (s *AdminService) DLQSummary(topic string) ([]DLQPartition, error) {
+	partitions, err := s.topicPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make([]DLQPartition, 0, len(partitions))
+	for _, p := range partitions {
+		first, last, err := s.partitionOffsets(topic, p)
+		if err != nil {
+			return nil, err
+		}
+		summary = append(summary, DLQPartition{
+			Partition:     p,
+			FirstOffset:   first,
+			HighWatermark: last,
+			Messages:      last - first,
+		})
+	}
+	sort.Slice(summary, func(i, j int) bool { return summary[i].Partition < summary[j].Partition })
+	return summary, nil
+}
+
+// BrowseDLQ reads up to `limit` messages of one partition starting at `offset`.
+// A negative offset means "from the beginning".
+func (s *AdminService) BrowseDLQ(topic string, partition int, offset int64, limit int) (*DLQPage, error) {
+	if limit <= 0 || limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	partitions, err := s.topicPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(partitions, partition) {
+		return nil, fmt.Errorf("%w: %s has no partition %d", ErrPartitionNotFound, topic, partition)
+	}
+
+	first, last, err := s.partitionOffsets(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+	if offset < first {
+		offset = first
+	}
+
+	messages, err := s.readRange(topic, partition, offset, last, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &DLQPage{
+		Topic:         topic,
+		Partition:     partition,
+		FirstOffset:   first,
+		HighWatermark: last,
+		Messages:      make([]DLQMessage, 0, len(messages)),
+	}
+	for _, m := range messages {
+		page.Messages = append(page.Messages, toDLQMessage(m))
+	}
+	if n := len(messages); n > 0 {
+		if next := messages[n-1].Offset + 1; next < last {
+			page.NextOffset = &next
+		}
+	}
+	return page, nil
+}
+
+// ReplayDLQ sends DLQ messages back to their original-topic.
+// If `all` is true every message currently in the DLQ is replayed and `selections` is ignored.
+// The result is stored in the audit trail and returned.
+//
+// 🎓 Each partition is read ONCE (one connection, from the lowest to the highest selected offset)
+// and the replays are written in batches, all under a single deadline (replayTimeout).
+// Within a partition messages are replayed in offset order.
+func (s *AdminService) ReplayDLQ(ctx context.Context, topic string, selections []ReplaySelection, all bool, actor string) (*ReplayAudit, error) {
+	ctx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+
+	existing, err := s.topicPartitions(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	if all {
+		var err error
+		if selections, err = s.selectAll(topic); err != nil {
+			return nil, err
+		}
+	}
+
+	audit := ReplayAudit{
+		Topic:     topic,
+		Actor:     actor,
+		At:        time.Now().UTC(),
+		All:       all,
+		Requested: len(selections),
+	}
+	fail := func(sel ReplaySelection, err error) {
+		audit.Failures = append(audit.Failures, ReplayFailure{Partition: sel.Partition, Offset: sel.Offset, Error: err.Error()})
+	}
+
+	// 1. Group by partition, in offset order
+	byPartition := make(map[int][]ReplaySelection)
+	for _, sel := range selections {
+		byPartition[sel.Partition] = append(byPartition[sel.Partition], sel)
+	}
+	partitions := make([]int, 0, len(byPartition))
+	for p, sels := range byPartition {
+		partitions = append(partitions, p)
+		sort.SliceStable(sels, func(i, j int) bool { return sels[i].Offset < sels[j].Offset })
+	}
+	sort.Ints(partitions)
+
+	// 2. Read each partition once and build the replay messages
+	var (
+		pending  []kafka.Message
+		selected []ReplaySelection // selected[i] produced pending[i]
+	)
+	for _, partition := range partitions {
+		sels := byPartition[partition]
+		if !slices.Contains(existing, partition) {
+			for _, sel := range sels {
+				fail(sel, fmt.Errorf("%w: %s has no partition %d", ErrPartitionNotFound, topic, partition))
+			}
+			continue
+		}
+		messages, err := s.readSelected(ctx, topic, partition, sels)
+		if err != nil {
+			for _, sel := range sels {
+				fail(sel, err)
+			}
+			continue
+		}
+		for _, sel := range sels {
+			msg, ok := messages[sel.Offset]
+			if !ok {
+				fail(sel, fmt.Errorf("offset %d not found (out of range or compacted)", sel.Offset))
+				continue
+			}
+			replay, err := replayMessage(topic, msg, sel.Payload, actor)
+			if err != nil {
+				fail(sel, err)
+				continue
+			}
+			pending = append(pending, replay)
+			selected = append(selected, sel)
+		}
+	}
+
+	// 3. Write in batches
+	// ⚠️ Replay does NOT preserve the original partition or ordering: section-05 publishes
+	// with LeastBytes, so the partition a message first landed on depends on load, not on its key.
+	// Hash only keeps replays of the same key together; consumers must not rely on replayed
+	// messages arriving in order relative to live traffic.
+	writer := &kafka.Writer{
+		Addr:     kafka.TCP(s.brokerAddress),
+		Balancer: &kafka.Hash{},
+	}
+	defer writer.Close()
+
+	for start := 0; start < len(pending); start += replayBatchSize {
+		end := min(start+replayBatchSize, len(pending))
+		err := writer.WriteMessages(ctx, pending[start:end]...)
+
+		// WriteErrors tells which messages of the batch failed; any other error fails them all
+		var writeErrs kafka.WriteErrors
+		perMessage := errors.As(err, &writeErrs) && len(writeErrs) == end-start
+		for i := start; i < end; i++ {
+			msgErr := err
+			if perMessage {
+				msgErr = writeErrs[i-start]
+			}
+			if msgErr != nil {
+				fail(selected[i], fmt.Errorf("failed to write to %s: %w", pending[i].Topic, msgErr))
+				continue
+			}
+
+			sel := selected[i]
+			audit.Replayed++
+			if sel.Payload != nil {
+				audit.Edited++
+			}
+			audit.Messages = append(audit.Messages, ReplayedMessage{
+				Partition: sel.Partition,
+				Offset:    sel.Offset,
+				To:        pending[i].Topic,
+				Edited:    sel.Payload != nil,
+			})
+		}
+	}
+
+	s.replays.mu.Lock()
+	audit.ID = len(s.replays.entries) + 1
+	s.replays.entries = append(s.replays.entries, audit)
+	s.replays.mu.Unlock()
+
+	return &audit, nil
+}
+
+// ListReplays returns the audit trail, newest first.
+func (s *AdminService) ListReplays() []ReplayAudit {
+	s.replays.mu.Lock()
+	defer s.replays.mu.Unlock()
+
+	out := make([]ReplayAudit, len(s.replays.entries))
+	for i, e := range s.replays.entries {
+		out[len(out)-1-i] = e
+	}
+	return out
+}
+
+// replayMessage builds the message to send back to the original topic.
+func replayMessage(dlqTopic string, m kafka.Message, payload *string, actor string) (kafka.Message, error) {
+	var original string
+	headers := make([]kafka.Header, 0, len(m.Headers)+2)
+	for _, h := range m.Headers {
+		if h.Key == HeaderOriginalTopic {
+			original = string(h.Value)
+		}
+		if controlHeaders[h.Key] {
+			continue
+		}
+		headers = append(headers, h)
+	}
+	if original == "" {
+		return kafka.Message{}, ErrNoOriginalTopic
+	}
+
+	headers = append(headers,
+		kafka.Header{Key: HeaderReplayedFrom, Value: []byte(dlqTopic + "/" + strconv.Itoa(m.Partition) + "/" + strconv.FormatInt(m.Offset, 10))},
+		kafka.Header{Key: HeaderReplayedBy, Value: []byte(actor)},
+	)
+
+	value := m.Value
+	if payload != nil {
+		value = []byte(*payload)
+	}
+
+	return kafka.Message{
+		Topic:   original,
+		Key:     m.Key,
+		Value:   value,
+		Headers: headers,
+	}, nil
+}
+
+// topicPartitions returns the partition IDs of a topic, sorted.
+// An unknown topic is reported as ErrTopicNotFound (the handler turns it into a 404).
+func (s *AdminService) topicPartitions(topic string) ([]int, error) {
+	conn, err := kafka.Dial("tcp", s.brokerAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial kafka: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions(topic)
+	if err != nil {
+		return nil, partitionsError(topic, err)
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+	}
+
+	ids := make([]int, 0, len(partitions))
+	for _, p := range partitions {
+		ids = append(ids, p.ID)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// partitionsError maps the broker's "unknown topic" answer to ErrTopicNotFound.
+func partitionsError(topic string, err error) error {
+	if errors.Is(err, kafka.UnknownTopicOrPartition) {
+		return fmt.Errorf("%w: %s", ErrTopicNotFound, topic)
+	}
+	return fmt.Errorf("failed to read partitions: %w", err)
+}
+
+// selectAll lists every message currently stored in the DLQ.
+func (s *AdminService) selectAll(topic string) ([]ReplaySelection, error) {
+	summary, err := s.DLQSummary(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	var selections []ReplaySelection
+	for _, p := range summary {
+		for offset := p.FirstOffset; offset < p.HighWatermark; offset++ {
+			selections = append(selections, ReplaySelection{Partition: p.Partition, Offset: offset})
+		}
+	}
+	return selections, nil
+}
+
+// partitionOffsets returns [first, high watermark) for a partition.
+func (s *AdminService) partitionOffsets(topic string, partition int) (int64, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+	defer cancel()
+
+	conn, err := kafka.DialLeader(ctx, "tcp", s.brokerAddress, topic, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to dial partition leader: %w", err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read offsets: %w", err)
+	}
+	return first, last, nil
+}
+
+// readSelected reads the selected offsets of one partition with a single connection,
+// scanning from the lowest to the highest one. Offsets that no longer exist are simply missing.
+func (s *AdminService) readSelected(ctx context.Context, topic string, partition int, sels []ReplaySelection) (map[int64]kafka.Message, error) {
+	wanted := make(map[int64]bool, len(sels))
+	from, until := sels[0].Offset, sels[len(sels)-1].Offset+1 // sels is sorted by offset
+	for _, sel := range sels {
+		wanted[sel.Offset] = true
+	}
+
+	conn, err := (&kafka.Dialer{}).DialLeader(ctx, "tcp", s.brokerAddress, topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial partition leader: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offsets: %w", err)
+	}
+	from, until = max(from, first), min(until, last)
+
+	found := make(map[int64]kafka.Message, len(sels))
+	for next := from; next < until; {
+		if _, err := conn.Seek(next, kafka.SeekAbsolute); err != nil {
+			return nil, fmt.Errorf("failed to seek to offset %d: %w", next, err)
+		}
+
+		// One fetch returns up to maxBatchSize bytes: keep fetching until `until`.
+		// Each fetch also waits at most readTimeout (the overall deadline still applies).
+		fetchDeadline := time.Now().Add(readTimeout)
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(fetchDeadline) {
+			fetchDeadline = deadline
+		}
+		conn.SetReadDeadline(fetchDeadline)
+		batch := conn.ReadBatch(1, maxBatchSize)
+		progressed := false
+		for {
+			m, err := batch.ReadMessage()
+			if err != nil || m.Offset >= until {
+				break
+			}
+			next, progressed = m.Offset+1, true
+			if wanted[m.Offset] {
+				found[m.Offset] = m
+			}
+		}
+		if err := batch.Close(); err != nil && !progressed {
+			return nil, fmt.Errorf("failed to read from offset %d: %w", next, err)
+		}
+		if !progressed {
+			break // Nothing left to read (gap at the end of a compacted log)
+		}
+	}
+	return found, nil
+}
+
+// readRange reads up to `limit` messages in [from, until).
+func (s *AdminService) readRange(topic string, partition int, from, until int64, limit int) ([]kafka.Message, error) {
+	if from >= until {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), readTimeout)
+	defer cancel()
+
+	conn, err := kafka.DialLeader(ctx, "tcp", s.brokerAddress, topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial partition leader: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Seek(from, kafka.SeekAbsolute); err != nil {
+		return nil, fmt.Errorf("failed to seek to offset %d: %w", from, err)
+	}
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+
+	batch := conn.ReadBatch(1, maxBatchSize)
+	defer batch.Close()
+
+	var messages []kafka.Message
+	for len(messages) < limit {
+		m, err := batch.ReadMessage()
+		if err != nil {
+			break // End of batch (or deadline): return what we have
+		}
+		if m.Offset >= until {
+			break
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+func toDLQMessage(m kafka.Message) DLQMessage {
+	headers := make(map[string]string, len(m.Headers))
+	for _, h := range m.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	return DLQMessage{
+		Partition:     m.Partition,
+		Offset:        m.Offset,
+		Key:           string(m.Key),
+		Time:          m.Time,
+		OriginalTopic: headers[HeaderOriginalTopic],
+		ErrorReason:   headers[HeaderErrorReason],
+		Headers:       headers,
+		Payload:       string(m.Value),
+	}
+}
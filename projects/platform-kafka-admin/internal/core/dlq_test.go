@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func dlqMessage(headers ...kafka.Header) kafka.Message {
+	return kafka.Message{
+		Topic:     "hero-events-05-dlq",
+		Partition: 2,
+		Offset:    17,
+		Key:       []byte("hero-1"),
+		Value:     []byte(`{"id":"evt-1"}`),
+		Headers:   headers,
+	}
+}
+
+func hdr(key, value string) kafka.Header {
+	return kafka.Header{Key: key, Value: []byte(value)}
+}
+
+func headerMap(headers []kafka.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+	for _, h := range headers {
+		out[h.Key] = string(h.Value)
+	}
+	return out
+}
+
+func TestReplayMessage(t *testing.T) {
+	edited := `{"id":"evt-1","fixed":true}`
+
+	tests := []struct {
+		name      string
+		msg       kafka.Message
+		payload   *string
+		wantValue string
+	}{
+		{
+			name: "original payload",
+			msg: dlqMessage(
+				hdr("ce_id", "evt-1"),
+				hdr(HeaderOriginalTopic, "hero-events-05"),
+				hdr(HeaderErrorReason, "db caída"),
+				hdr("retry-attempt", "3"),
+				hdr("retry-not-before", "2026-01-01T00:00:00Z"),
+			),
+			wantValue: `{"id":"evt-1"}`,
+		},
+		{
+			name: "edited payload",
+			msg: dlqMessage(
+				hdr("ce_id", "evt-1"),
+				hdr(HeaderOriginalTopic, "hero-events-05"),
+				hdr(HeaderErrorReason, "invalid hero"),
+			),
+			payload:   &edited,
+			wantValue: edited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := replayMessage("hero-events-05-dlq", tt.msg, tt.payload, "ana")
+			if err != nil {
+				t.Fatalf("replayMessage: %v", err)
+			}
+
+			if got.Topic != "hero-events-05" {
+				t.Errorf("Topic = %q, want hero-events-05", got.Topic)
+			}
+			if string(got.Key) != "hero-1" {
+				t.Errorf("Key = %q, want hero-1", got.Key)
+			}
+			if string(got.Value) != tt.wantValue {
+				t.Errorf("Value = %s, want %s", got.Value, tt.wantValue)
+			}
+
+			headers := headerMap(got.Headers)
+			for key := range controlHeaders {
+				if _, ok := headers[key]; ok {
+					t.Errorf("control header %q was not stripped", key)
+				}
+			}
+			want := map[string]string{
+				"ce_id":            "evt-1",
+				HeaderReplayedFrom: "hero-events-05-dlq/2/17",
+				HeaderReplayedBy:   "ana",
+			}
+			for key, value := range want {
+				if headers[key] != value {
+					t.Errorf("header %q = %q, want %q", key, headers[key], value)
+				}
+			}
+			if len(headers) != len(want) {
+				t.Errorf("headers = %v, want exactly %v", headers, want)
+			}
+		})
+	}
+}
+
+func TestReplayMessageWithoutOriginalTopic(t *testing.T) {
+	_, err := replayMessage("hero-events-05-dlq", dlqMessage(hdr(HeaderErrorReason, "boom")), nil, "ana")
+	if !errors.Is(err, ErrNoOriginalTopic) {
+		t.Errorf("err = %v, want ErrNoOriginalTopic", err)
+	}
+}
+
+func TestPartitionsErrorMapsUnknownTopic(t *testing.T) {
+	err := partitionsError("nope-dlq", fmt.Errorf("metadata: %w", kafka.UnknownTopicOrPartition))
+	if !errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("unknown topic: err = %v, want ErrTopicNotFound", err)
+	}
+
+	err = partitionsError("hero-events-05-dlq", kafka.BrokerNotAvailable)
+	if errors.Is(err, ErrTopicNotFound) {
+		t.Errorf("broker down reported as ErrTopicNotFound: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Ping checks that the broker accepts connections and answers a metadata request.
+// Used by GET /readyz.
+func (s *AdminService) Ping(ctx context.Context) error {
+	var dialer kafka.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.brokerAddress)
+	if err != nil {
+		return fmt.Errorf("failed to dial kafka: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Brokers(); err != nil {
+		return fmt.Errorf("failed to read cluster metadata: %w", err)
+	}
+	return nil
+}
@@ -6,9 +6,10 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-// AdminService manages Kafka topics.
+// AdminService manages Kafka topics and DLQ replays.
 type AdminService struct {
 	brokerAddress string
+	replays       replayLog // Audit trail of DLQ replays
 }
 
 func NewAdminService(brokerAddress string) *AdminService {
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/platform-kafka-admin/internal/core"
+	"github.com/gin-gonic/gin"
+)
+
+// ReplayRequest DTO
+// Either select messages (optionally editing their payload) or set All=true.
+type ReplayRequest struct {
+	Messages []core.ReplaySelection `json:"messages"`
+	All      bool                   `json:"all"`
+	Actor    string                 `json:"actor"` // 🎓 Audit: who is replaying (falls back to X-Actor header)
+}
+
+// ⚠️ The actor (body or X-Actor header) is self-asserted: this API has no authentication,
+// so anyone who can reach it can claim any name. Put it behind an authenticating proxy
+// that sets X-Actor before trusting the audit trail.
+
+// dlqErrorStatus maps DLQ errors to HTTP statuses.
+// The topic is part of the path (404 if missing); the partition is a query/body parameter (400).
+func dlqErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrTopicNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrPartitionNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// DLQSummary handles GET /dlq/:topic
+func (h *AdminHandler) DLQSummary(c *gin.Context) {
+	topic := c.Param("topic")
+	partitions, err := h.service.DLQSummary(topic)
+	if err != nil {
+		c.JSON(dlqErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"topic": topic, "partitions": partitions})
+}
+
+// BrowseDLQ handles GET /dlq/:topic/messages?partition=0&offset=0&limit=20
+func (h *AdminHandler) BrowseDLQ(c *gin.Context) {
+	partition, err := strconv.Atoi(c.DefaultQuery("partition", "0"))
+	if err != nil || partition < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid partition"})
+		return
+	}
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "-1"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	page, err := h.service.BrowseDLQ(c.Param("topic"), partition, offset, limit)
+	if err != nil {
+		c.JSON(dlqErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// ReplayDLQ handles POST /dlq/:topic/replay
+func (h *AdminHandler) ReplayDLQ(c *gin.Context) {
+	var req ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.All && len(req.Messages) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "select at least one message or set all=true"})
+		return
+	}
+
+	actor := req.Actor
+	if actor == "" {
+		actor = c.GetHeader("X-Actor")
+	}
+	if actor == "" {
+		actor = "anonymous"
+	}
+
+	audit, err := h.service.ReplayDLQ(c.Request.Context(), c.Param("topic"), req.Messages, req.All, actor)
+	if err != nil {
+		c.JSON(dlqErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	h.log.Info("dlq replay", "id", audit.ID, "topic", audit.Topic, "actor", audit.Actor,
+		"replayed", audit.Replayed, "requested", audit.Requested, "edited", audit.Edited, "failures", len(audit.Failures))
+
+	status := http.StatusOK
+	if len(audit.Failures) > 0 {
+		status = http.StatusMultiStatus // Some messages could not be replayed
+	}
+	c.JSON(status, audit)
+}
+
+// ListReplays handles GET /dlq-replays
+func (h *AdminHandler) ListReplays(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"replays": h.service.ListReplays()})
+}
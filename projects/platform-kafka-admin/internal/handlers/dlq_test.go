@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/platform-kafka-admin/internal/core"
+)
+
+func TestDLQErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("%w: nope-dlq", core.ErrTopicNotFound), http.StatusNotFound},
+		{fmt.Errorf("%w: hero-events-05-dlq has no partition 9", core.ErrPartitionNotFound), http.StatusBadRequest},
+		{errors.New("failed to dial kafka: connection refused"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := dlqErrorStatus(tt.err); got != tt.want {
+			t.Errorf("dlqErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 🎓 HEALTH CHECKS
+// GET /healthz (liveness): the process is up. Never checks dependencies,
+// restarting the admin API does not fix a broken broker.
+// GET /readyz (readiness): every dependency answers. 503 + per-dependency detail if not.
+
+// Checker verifies one dependency (nil = healthy).
+// It is an interface so tests can swap in a fake.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a plain function (e.g. service.Ping) to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult is the detail reported for one dependency.
+type CheckResult struct {
+	Status  string `json:"status"` // up | down
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthHandler serves the health endpoints.
+type HealthHandler struct {
+	started time.Time
+	timeout time.Duration // Per check, so a hung broker cannot hang the probe
+	checks  map[string]Checker
+}
+
+func NewHealthHandler(timeout time.Duration, checks map[string]Checker) *HealthHandler {
+	return &HealthHandler{started: time.Now(), timeout: timeout, checks: checks}
+}
+
+// Liveness handles GET /healthz
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"status": "up", "uptime": h.uptime()})
+}
+
+// Readiness handles GET /readyz
+// Checks run concurrently: the probe takes as long as the slowest dependency.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(h.checks))
+		status  = "up"
+	)
+	for name, checker := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(c.Request.Context(), checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = result
+			if result.Status == "down" {
+				status = "down"
+			}
+		}()
+	}
+	wg.Wait()
+
+	code := http.StatusOK
+	if status == "down" {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, gin.H{"status": status, "uptime": h.uptime(), "checks": results})
+}
+
+func (h *HealthHandler) run(ctx context.Context, checker Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// Stop waiting at the deadline even if the checker ignores ctx;
+	// its goroutine finishes on its own whenever the call returns.
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := CheckResult{Status: "up", Latency: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status, result.Error = "down", err.Error()
+	}
+	return result
+}
+
+func (h *HealthHandler) uptime() string {
+	return time.Since(h.started).Round(time.Second).String()
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	hung := make(chan struct{})
+	defer close(hung)
+
+	up := CheckerFunc(func(context.Context) error { return nil })
+	tests := []struct {
+		name     string
+		checker  Checker
+		want     int
+		wantDown bool
+	}{
+		{"up", up, http.StatusOK, false},
+		{"failing", CheckerFunc(func(context.Context) error { return errors.New("connection refused") }), http.StatusServiceUnavailable, true},
+		{"ignores ctx past the timeout", CheckerFunc(func(context.Context) error { <-hung; return nil }), http.StatusServiceUnavailable, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(20*time.Millisecond, map[string]Checker{"kafka": tt.checker, "other": up})
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+			h.Readiness(c)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+			var body struct {
+				Checks map[string]CheckResult `json:"checks"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+				t.Fatalf("decoding body: %v", err)
+			}
+			if down := body.Checks["kafka"].Status == "down"; down != tt.wantDown {
+				t.Errorf("kafka = %+v, want down=%v", body.Checks["kafka"], tt.wantDown)
+			}
+			if body.Checks["other"].Status != "up" {
+				t.Errorf("other = %+v, want up", body.Checks["other"])
+			}
+		})
+	}
+}
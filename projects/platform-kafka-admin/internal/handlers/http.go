@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/platform-kafka-admin/internal/core"
@@ -9,10 +10,11 @@ import (
 
 type AdminHandler struct {
 	service *core.AdminService
+	log     *slog.Logger
 }
 
-func NewAdminHandler(service *core.AdminService) *AdminHandler {
-	return &AdminHandler{service: service}
+func NewAdminHandler(service *core.AdminService, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{service: service, log: logger}
 }
 
 // CreateTopicRequest DTO
@@ -1,64 +1,122 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/config"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/outboxsrv"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/handlers/herohdl"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/health"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/lifecycle"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
 )
 
 func main() {
-	const port = ":8081"
-	fmt.Println("🚀 Hero API (HTTP) Starting on port", port)
+	// 0. CONFIG (defaults -> archivo -> entorno -> flags)
+	// Ej: go run cmd/api/main.go -db=sqlite -db-path=heroes.db
+	//     HERO_DB=sqlite go run cmd/api/main.go
+	//     go run cmd/api/main.go -config=config.example.json
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ Configuración: %v", err)
+	}
+	logger, err := cfg.NewLogger(os.Stdout) // A partir de acá, todo sale por slog (nivel y formato según la config)
+	if err != nil {
+		log.Fatalf("❌ Logger: %v", err)
+	}
+
+	logger.Info("hero API iniciando", "addr", cfg.HTTP.Addr)
+	logger.Info("configuración cargada", "config", cfg.Redacted())
+
+	// El lifecycle reemplaza a los defer: los defer NO corren si el proceso muere por una señal.
+	lc := lifecycle.New(time.Duration(cfg.ShutdownTimeout), logger)
 
 	// 1. INFRASTRUCTURE (Adapters)
-	// a. Base de Datos (Memoria)
-	dbRepo := herorepo.NewMemory()
+	// a. Base de Datos (Memoria o SQLite) + su Outbox. Validate ya rechazó cualquier otro driver.
+	// (cada dependencia se registra también en el health check de /readyz)
+	checks := health.New(2*time.Second, logger)
+	reg := metrics.NewRegistry() // Métricas de Prometheus (GET /metrics)
+	var (
+		dbRepo ports.HeroRepository
+		outbox ports.Outbox
+	)
+	switch cfg.DB.Driver {
+	case "memory":
+		memRepo := herorepo.NewMemory(logger)
+		dbRepo, outbox = memRepo, memRepo
+		checks.Add("repository", health.CheckerFunc(memRepo.Ping))
+	case "sqlite":
+		sqliteRepo, err := herorepo.NewSQLite(cfg.DB.Path, logger)
+		if err != nil {
+			fatal(logger, "error inicializando SQLite", err)
+		}
+		lc.OnStop("sqlite", lifecycle.Closer(sqliteRepo))
+		dbRepo, outbox = sqliteRepo, sqliteRepo
+		checks.Add("repository", health.CheckerFunc(sqliteRepo.Ping))
+	}
+
 	// b. Event Bus (Kafka)
-	eventBus := herorepo.NewKafka("localhost:9094", "hero-events-05")
-	defer eventBus.Close()
+	eventBus := herorepo.NewKafka(cfg.Kafka.NewWriter(cfg.Kafka.Topic), domain.ContentMode(cfg.Kafka.EventMode), reg, logger)
+	lc.OnStop("kafka writer", lifecycle.Closer(eventBus)) // Close hace flush de lo que quede en el buffer
+	checks.Add("kafka", health.NewKafka(cfg.Kafka.NewClient(), cfg.Kafka.Topic))
 
 	// 2. CORE (Service)
-	// Inyectamos AMBAS dependencias: DB y EventBus
-	service := herosrv.New(dbRepo, eventBus)
+	// Inyectamos AMBAS dependencias: DB y EventBus (+ reglas de progresión)
+	service := herosrv.New(dbRepo, eventBus, cfg.LevelCurve(), logger)
+
+	// 2b. RELAY (Outbox -> Kafka) en segundo plano
+	relay := outboxsrv.New(outbox, eventBus, cfg.RelayConfig(), logger)
+	lc.Add(lifecycle.Component{
+		Name: "outbox relay",
+		Run: func(ctx context.Context) error {
+			relay.Run(ctx)
+			return nil
+		},
+	})
+	// Cuando ya no entran peticiones: publicar lo que quedó en el outbox (antes de cerrar Kafka)
+	lc.OnStop("outbox flush", func(ctx context.Context) error {
+		sent, err := relay.Flush(ctx)
+		logger.Info("outbox vaciado al apagar", "component", "outbox-relay", "sent", sent)
+		return err
+	})
 
 	// 3. HANDLER (HTTP Adapter)
-	handler := herohdl.NewHTTPHandler(service)
+	handler := herohdl.NewHTTPHandler(service, logger)
+	handler.ContractCheck(cfg.HTTP.ContractCheck)
 
 	// 4. ROUTER & SERVER
-	http.HandleFunc("/heroes", func(w http.ResponseWriter, r *http.Request) {
-		// Si tiene query param "id", es operación sobre un héroe específico
-		id := r.URL.Query().Get("id")
+	// API v1 (/v1/heroes/{id}) + contrato en /openapi.json + rutas viejas (/heroes?id=...) con headers de deprecación
+	// + /healthz y /readyz para el orquestador + /metrics para Prometheus
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	handler.RegisterLegacyRoutes(mux)
+	checks.RegisterRoutes(mux)
+	mux.Handle("GET /metrics", reg.Handler())
 
-		if id != "" {
-			// Operaciones sobre un héroe específico
-			switch r.Method {
-			case http.MethodGet:
-				handler.GetHero(w, r)
-			case http.MethodPut:
-				handler.UpdateHero(w, r)
-			case http.MethodDelete:
-				handler.DeleteHero(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		} else {
-			// Operaciones sobre la colección
-			switch r.Method {
-			case http.MethodPost:
-				handler.CreateHero(w, r)
-			case http.MethodGet:
-				handler.ListHeroes(w, r)
-			default:
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			}
-		}
-	})
-
-	if err := http.ListenAndServe(port, nil); err != nil {
-		log.Fatalf("❌ Error iniciando servidor: %v", err)
+	// 5. RUN (hasta SIGINT/SIGTERM; Shutdown espera las peticiones en curso)
+	// Middlewares (de afuera hacia adentro): request ID -> access log -> métricas -> mux
+	lc.Add(lifecycle.HTTPServer("http server", &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           herohdl.WithRequestContext(herohdl.WithAccessLog(logger, herohdl.WithMetrics(reg, mux))),
+		ReadHeaderTimeout: 5 * time.Second,
+	}))
+	if err := lc.Run(context.Background()); err != nil {
+		fatal(logger, "error en el ciclo de vida", err)
 	}
 }
+
+// fatal registra el error y termina el proceso (el equivalente a log.Fatalf, pero por slog).
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -2,30 +2,117 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/config"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/handlers/herohdl"
-	"github.com/segmentio/kafka-go"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/health"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/lifecycle"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
 )
 
 func main() {
-	fmt.Println("🐢 Hero Consumer Starting...")
-
-	// 1. INFRASTRUCTURE (Kafka Reader)
-	// Configuración para leer de "hero-events-05"
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{"localhost:9094"},
-		Topic:    "hero-events-05", // Mismo topic que el API publica
-		GroupID:  "hero-group-1",   // Importante para Consumer Groups
-		MinBytes: 10e3,             // 10KB
-		MaxBytes: 10e6,             // 10MB
-	})
-	defer reader.Close()
-
-	// 2. HANDLER
-	consumer := herohdl.NewConsumerHandler(reader)
-
-	// 3. EXECUTION
-	// Bloquea por siempre (o hasta Ctrl+C)
-	consumer.Start(context.Background())
+	// 0. CONFIG (defaults -> archivo -> entorno -> flags)
+	// Ej: go run cmd/consumer/main.go -dedup=sqlite -dedup-path=consumer.db
+	//     KAFKA_BROKERS=kafka-1:9092,kafka-2:9092 go run cmd/consumer/main.go
+	cfg, err := config.Load(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		log.Fatalf("❌ Configuración: %v", err)
+	}
+	logger, err := cfg.NewLogger(os.Stdout) // A partir de acá, todo sale por slog (nivel y formato según la config)
+	if err != nil {
+		log.Fatalf("❌ Logger: %v", err)
+	}
+
+	logger.Info("hero consumer iniciando", "topic", cfg.Kafka.Topic, "group_id", cfg.Consumer.GroupID)
+	logger.Info("configuración cargada", "config", cfg.Redacted())
+
+	// Apagado ordenado: terminar el mensaje en curso, commit, y recién ahí cerrar conexiones.
+	lc := lifecycle.New(time.Duration(cfg.ShutdownTimeout), logger)
+
+	// 1. INFRASTRUCTURE (Kafka Reader + Producer de retry/DLQ)
+	// El group ID es importante para Consumer Groups (varias instancias se reparten particiones)
+	reader := cfg.Kafka.NewReader(cfg.Kafka.Topic, cfg.Consumer.GroupID)
+	lc.OnStop("kafka reader", lifecycle.Closer(reader))
+
+	// Sin topic fijo: cada mensaje lleva el suyo (retry-5s, retry-1m, DLQ...)
+	producer := cfg.Kafka.NewWriter("")
+	lc.OnStop("retry/DLQ producer", lifecycle.Closer(producer)) // Flush de lo enviado a retry/DLQ
+
+	// Health checks en un puerto propio (el consumer no tiene API HTTP)
+	checks := health.New(2*time.Second, logger)
+
+	// 2. IDEMPOTENCIA (Store de eventos procesados; Validate ya rechazó cualquier otro)
+	var processed ports.ProcessedEventStore
+	switch cfg.Consumer.Dedup {
+	case "memory":
+		processed = herorepo.NewProcessedMemory(time.Duration(cfg.Consumer.DedupTTL))
+	case "sqlite":
+		sqliteRepo, err := herorepo.NewSQLite(cfg.Consumer.DedupPath, logger)
+		if err != nil {
+			fatal(logger, "error inicializando SQLite", err)
+		}
+		lc.OnStop("sqlite", lifecycle.Closer(sqliteRepo))
+		processed = sqliteRepo
+		checks.Add("dedup store", health.CheckerFunc(sqliteRepo.Ping))
+	}
+
+	// 3. HANDLER (con reintentos escalonados antes del DLQ)
+	// ⚠️ Los topics de retry y el DLQ deben existir (créalos con platform-kafka-admin).
+	policy := herohdl.DefaultRetryPolicy(cfg.Kafka.Topic)
+	policy.DLQTopic = cfg.Kafka.DLQ()
+	consumer := herohdl.NewConsumerHandler(reader, producer, processed, herohdl.LogEvent(logger), policy, logger)
+
+	// 4. RETRY WORKERS (uno por escalón: retry-5s, retry-1m, retry-10m)
+	for _, tier := range policy.Tiers {
+		retryReader := cfg.Kafka.NewReader(tier.Topic, cfg.Consumer.RetryGroupID)
+		lc.OnStop("kafka reader "+tier.Topic, lifecycle.Closer(retryReader))
+
+		lc.Add(lifecycle.Component{
+			Name: "retry worker " + tier.Topic,
+			Run: func(ctx context.Context) error {
+				return consumer.StartRetryWorker(ctx, retryReader)
+			},
+		})
+	}
+
+	// 5. HEALTH + MÉTRICAS: broker + todos los topics que usamos, y el estado del consumer (lag, último commit)
+	topics := []string{cfg.Kafka.Topic, policy.DLQTopic}
+	for _, tier := range policy.Tiers {
+		topics = append(topics, tier.Topic)
+	}
+	checks.Add("kafka", health.NewKafka(cfg.Kafka.NewClient(), topics...))
+	checks.Add("consumer", consumer)
+
+	reg := metrics.NewRegistry()
+	consumer.RegisterMetrics(reg)
+
+	mux := http.NewServeMux()
+	checks.RegisterRoutes(mux)
+	mux.Handle("GET /metrics", reg.Handler())
+	lc.Add(lifecycle.HTTPServer("health server", &http.Server{
+		Addr:              cfg.Consumer.HealthAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}))
+
+	// 6. EXECUTION
+	// Bloquea hasta Ctrl+C / SIGTERM (o hasta que Kafka se caiga)
+	lc.Add(lifecycle.Component{Name: "consumer", Run: consumer.Start})
+	if err := lc.Run(context.Background()); err != nil {
+		fatal(logger, "error en el ciclo de vida", err)
+	}
+}
+
+// fatal registra el error y termina el proceso (el equivalente a log.Fatalf, pero por slog).
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
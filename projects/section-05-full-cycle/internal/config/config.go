@@ -0,0 +1,269 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/outboxsrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/logging"
+)
+
+// 🎓 CONFIGURACIÓN TIPADA (12-Factor App, factor III)
+// Nada de "localhost:9094" escrito a mano en el código: todo lo que cambia entre
+// entornos (dev, docker, prod) vive en un struct con tipos, defaults y validación.
+//
+// Orden de carga (lo último gana):
+//
+//	1. Defaults()            -> valores para desarrollo local
+//	2. Archivo JSON          -> -config=hero.json (o HERO_CONFIG)
+//	3. Variables de entorno  -> HERO_DB=sqlite, KAFKA_BROKERS=a:9092,b:9092...
+//	4. Flags                 -> -db=sqlite
+//
+// Un mismo Config sirve a los dos binarios: cada uno usa las secciones que le tocan.
+
+// Config es la configuración completa de la sección 05.
+type Config struct {
+	HTTP            HTTPConfig        `json:"http"`
+	DB              DBConfig          `json:"db"`
+	Kafka           KafkaConfig       `json:"kafka"`
+	Outbox          OutboxConfig      `json:"outbox"`
+	Progression     ProgressionConfig `json:"progression"`
+	Consumer        ConsumerConfig    `json:"consumer"`
+	Log             LogConfig         `json:"log"`
+	ShutdownTimeout Duration          `json:"shutdown_timeout"` // Máximo para apagar ordenadamente
+}
+
+// HTTPConfig configura la API (cmd/api).
+type HTTPConfig struct {
+	Addr          string `json:"addr"`
+	ContractCheck bool   `json:"contract_check"` // Validar respuestas contra openapi.json (desarrollo)
+}
+
+// DBConfig elige el repositorio de héroes (cmd/api).
+type DBConfig struct {
+	Driver string `json:"driver"` // memory | sqlite
+	Path   string `json:"path"`   // Archivo SQLite
+}
+
+// KafkaConfig es la conexión al broker y los topics.
+type KafkaConfig struct {
+	Brokers   []string `json:"brokers"`
+	Topic     string   `json:"topic"`      // Topic de eventos de héroes
+	DLQTopic  string   `json:"dlq_topic"`  // "" = <topic>-dlq
+	EventMode string   `json:"event_mode"` // CloudEvents: structured | binary
+	Username  string   `json:"username"`   // SASL/PLAIN (vacío = sin autenticación)
+	Password  Secret   `json:"password"`
+}
+
+// OutboxConfig configura el Relay (cmd/api). Solo por archivo: son ajustes finos.
+type OutboxConfig struct {
+	PollInterval   Duration `json:"poll_interval"`
+	BatchSize      int      `json:"batch_size"`
+	BaseBackoff    Duration `json:"base_backoff"`
+	MaxBackoff     Duration `json:"max_backoff"`
+	PublishTimeout Duration `json:"publish_timeout"`
+}
+
+// ProgressionConfig es la curva de niveles (cmd/api).
+type ProgressionConfig struct {
+	XPBase   int     `json:"xp_base"`
+	XPGrowth float64 `json:"xp_growth"`
+	MaxLevel int     `json:"max_level"`
+}
+
+// ConsumerConfig configura cmd/consumer.
+type ConsumerConfig struct {
+	HealthAddr   string   `json:"health_addr"` // Puerto propio para /healthz, /readyz y /metrics
+	GroupID      string   `json:"group_id"`
+	RetryGroupID string   `json:"retry_group_id"`
+	Dedup        string   `json:"dedup"`      // memory | sqlite
+	DedupPath    string   `json:"dedup_path"` // Archivo SQLite
+	DedupTTL     Duration `json:"dedup_ttl"`  // Solo con dedup=memory
+}
+
+// LogConfig configura los logs (ambos binarios).
+type LogConfig struct {
+	Level  string `json:"level"`  // debug | info | warn | error
+	Format string `json:"format"` // text | json
+}
+
+// Defaults devuelve la configuración para desarrollo local (docker-compose de la plataforma).
+func Defaults() Config {
+	curve := domain.DefaultLevelCurve()
+	outbox := outboxsrv.DefaultConfig()
+	return Config{
+		HTTP: HTTPConfig{Addr: ":8081"},
+		DB:   DBConfig{Driver: "memory", Path: "heroes.db"},
+		Kafka: KafkaConfig{
+			Brokers:   []string{"localhost:9094"},
+			Topic:     "hero-events-05",
+			EventMode: string(domain.ContentModeStructured),
+		},
+		Outbox: OutboxConfig{
+			PollInterval:   Duration(outbox.PollInterval),
+			BatchSize:      outbox.BatchSize,
+			BaseBackoff:    Duration(outbox.BaseBackoff),
+			MaxBackoff:     Duration(outbox.MaxBackoff),
+			PublishTimeout: Duration(outbox.PublishTimeout),
+		},
+		Progression: ProgressionConfig{XPBase: curve.BaseXP, XPGrowth: curve.Growth, MaxLevel: curve.MaxLevel},
+		Consumer: ConsumerConfig{
+			HealthAddr:   ":8082",
+			GroupID:      "hero-group-1",
+			RetryGroupID: "hero-group-1-retry",
+			Dedup:        "memory",
+			DedupPath:    "consumer.db",
+			DedupTTL:     Duration(24 * time.Hour),
+		},
+		Log:             LogConfig{Level: "info", Format: "text"},
+		ShutdownTimeout: Duration(30 * time.Second),
+	}
+}
+
+// Validate revisa TODA la configuración y devuelve todos los problemas juntos
+// (mejor que arreglar de a un error por arranque).
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr is required")
+	check(slices.Contains([]string{"memory", "sqlite"}, c.DB.Driver), "db.driver %q must be memory | sqlite", c.DB.Driver)
+	check(c.DB.Driver != "sqlite" || c.DB.Path != "", "db.path is required with db.driver=sqlite")
+
+	check(len(c.Kafka.Brokers) > 0, "kafka.brokers is required")
+	for _, broker := range c.Kafka.Brokers {
+		_, _, err := net.SplitHostPort(broker)
+		check(err == nil, "kafka.brokers: %q must be host:port", broker)
+	}
+	check(c.Kafka.Topic != "", "kafka.topic is required")
+	mode := domain.ContentMode(c.Kafka.EventMode)
+	check(mode == domain.ContentModeStructured || mode == domain.ContentModeBinary, "kafka.event_mode %q must be structured | binary", c.Kafka.EventMode)
+	check((c.Kafka.Username == "") == (c.Kafka.Password == ""), "kafka.username and kafka.password must be set together")
+
+	check(c.Outbox.PollInterval > 0 && c.Outbox.BaseBackoff > 0 && c.Outbox.MaxBackoff > 0 && c.Outbox.PublishTimeout > 0, "outbox durations must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size must be positive")
+
+	if err := c.LevelCurve().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("progression: %w", err))
+	}
+
+	check(c.Consumer.HealthAddr != "", "consumer.health_addr is required")
+	check(c.Consumer.GroupID != "" && c.Consumer.RetryGroupID != "", "consumer.group_id and consumer.retry_group_id are required")
+	check(slices.Contains([]string{"memory", "sqlite"}, c.Consumer.Dedup), "consumer.dedup %q must be memory | sqlite", c.Consumer.Dedup)
+	check(c.Consumer.Dedup != "sqlite" || c.Consumer.DedupPath != "", "consumer.dedup_path is required with consumer.dedup=sqlite")
+	check(c.Consumer.DedupTTL > 0, "consumer.dedup_ttl must be positive")
+	check(c.ShutdownTimeout > 0, "shutdown_timeout must be positive")
+	if _, err := logging.New(io.Discard, c.Log.Format, c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
+
+// LevelCurve arma la curva de progresión del dominio.
+func (c Config) LevelCurve() domain.LevelCurve {
+	curve := domain.DefaultLevelCurve()
+	curve.BaseXP, curve.Growth, curve.MaxLevel = c.Progression.XPBase, c.Progression.XPGrowth, c.Progression.MaxLevel
+	return curve
+}
+
+// RelayConfig arma la configuración del Relay del outbox.
+func (c Config) RelayConfig() outboxsrv.Config {
+	return outboxsrv.Config{
+		PollInterval:   time.Duration(c.Outbox.PollInterval),
+		BatchSize:      c.Outbox.BatchSize,
+		BaseBackoff:    time.Duration(c.Outbox.BaseBackoff),
+		MaxBackoff:     time.Duration(c.Outbox.MaxBackoff),
+		PublishTimeout: time.Duration(c.Outbox.PublishTimeout),
+	}
+}
+
+// Redacted devuelve la configuración como JSON (una línea), sin secretos (para loguear al arrancar).
+func (c Config) Redacted() string {
+	data, _ := json.Marshal(c)
+	return string(data)
+}
+
+// NewLogger crea el logger configurado (ver logging.New).
+func (c Config) NewLogger(w io.Writer) (*slog.Logger, error) {
+	return logging.New(w, c.Log.Format, c.Log.Level)
+}
+
+// Secret es un string que nunca se imprime (logs, JSON, %v).
+type Secret string
+
+const redacted = "********"
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Duration es un time.Duration que en JSON se escribe como "15s", "1m30s".
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"15s\": %w", err)
+	}
+	return d.Set(s)
+}
+
+// Set implementa flag.Value.
+func (d *Duration) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// stringList es un flag.Value para listas separadas por comas (ej: brokers).
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = splitList(s)
+	return nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv vacía las variables que lee Load, para que el entorno de quien corre
+// los tests no se cuele (applyEnv ignora las vacías).
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("HERO_CONFIG", "")
+	for key := range envVars(&Config{}) {
+		t.Setenv(key, "")
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hero.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	return path
+}
+
+// load usa un FlagSet nuevo en cada llamada (flag.CommandLine no se puede registrar dos veces).
+func load(args ...string) (Config, error) {
+	return Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Defaults()) {
+		t.Errorf("Load() = %+v, want Defaults()", cfg)
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `{
+		"http": {"addr": ":9000"},
+		"db": {"driver": "sqlite", "path": "file.db"},
+		"kafka": {"topic": "file-topic", "brokers": ["file:9092"]},
+		"shutdown_timeout": "20s"
+	}`)
+	t.Setenv("HERO_TOPIC", "env-topic")
+	t.Setenv("HERO_HTTP_ADDR", ":9001")
+	t.Setenv("KAFKA_BROKERS", "env-a:9092, env-b:9092")
+
+	cfg, err := load("-config", path, "-http-addr", ":9002", "-dedup-ttl", "1h")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"default when nothing sets it", cfg.Consumer.GroupID, Defaults().Consumer.GroupID},
+		{"file over default", cfg.DB.Path, "file.db"},
+		{"file duration", cfg.ShutdownTimeout, Duration(20 * time.Second)},
+		{"env over file", cfg.Kafka.Topic, "env-topic"},
+		{"env list", cfg.Kafka.Brokers, []string{"env-a:9092", "env-b:9092"}},
+		{"flag over env", cfg.HTTP.Addr, ":9002"},
+		{"flag over default", cfg.Consumer.DedupTTL, Duration(time.Hour)},
+		// El flag -db no se pasó: su default NO pisa lo que dijo el archivo
+		{"unset flag keeps file", cfg.DB.Driver, "sqlite"},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+// El primer Parse solo sirve para encontrar -config: da igual dónde esté en args
+// y los flags explícitos ganan aunque vengan antes que él.
+func TestLoadTwoPassFlagParse(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `{"kafka": {"topic": "file-topic", "event_mode": "binary"}}`)
+
+	cfg, err := load("-topic", "flag-topic", "-config", path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Kafka.Topic != "flag-topic" {
+		t.Errorf("topic = %q, want the flag to win over the file", cfg.Kafka.Topic)
+	}
+	if cfg.Kafka.EventMode != "binary" {
+		t.Errorf("event_mode = %q, want the file value", cfg.Kafka.EventMode)
+	}
+}
+
+func TestLoadConfigPathFromEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("HERO_CONFIG", writeFile(t, `{"db": {"driver": "sqlite"}}`))
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.DB.Driver != "sqlite" {
+		t.Errorf("db.driver = %q, want the file from HERO_CONFIG to be read", cfg.DB.Driver)
+	}
+}
+
+func TestLoadRejectsUnknownFileFields(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, `{"kafka": {"brokres": ["a:9092"]}}`)
+
+	_, err := load("-config", path)
+	if err == nil || !strings.Contains(err.Error(), "brokres") {
+		t.Fatalf("Load with a typo = %v, want an error naming the unknown field", err)
+	}
+}
+
+func TestLoadRejectsBadEnv(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("HERO_SHUTDOWN_TIMEOUT", "soon")
+
+	_, err := load()
+	if err == nil || !strings.Contains(err.Error(), "HERO_SHUTDOWN_TIMEOUT") {
+		t.Fatalf("Load = %v, want an error naming the variable", err)
+	}
+}
+
+// Validate junta TODOS los problemas, no solo el primero.
+func TestValidateReportsEveryProblem(t *testing.T) {
+	cfg := Defaults()
+	cfg.DB.Driver = "postgres"
+	cfg.Kafka.Brokers = []string{"localhost"}
+	cfg.Kafka.Username = "hero"
+	cfg.ShutdownTimeout = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{
+		`db.driver "postgres"`,
+		`"localhost" must be host:port`,
+		"kafka.username and kafka.password must be set together",
+		"shutdown_timeout must be positive",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %q, missing %q", err, want)
+		}
+	}
+
+	if err := Defaults().Validate(); err != nil {
+		t.Errorf("Defaults().Validate() = %v, want nil", err)
+	}
+}
+
+func TestLoadValidates(t *testing.T) {
+	clearEnv(t)
+	_, err := load("-db", "postgres", "-shutdown-timeout", "0s")
+	if err == nil || !strings.Contains(err.Error(), "db.driver") || !strings.Contains(err.Error(), "shutdown_timeout") {
+		t.Fatalf("Load = %v, want both validation errors", err)
+	}
+}
+
+func TestRedactedHidesSecrets(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("KAFKA_USERNAME", "hero")
+	t.Setenv("KAFKA_PASSWORD", "hunter2")
+
+	cfg, err := load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Kafka.Password != "hunter2" {
+		t.Fatalf("password = %q, want the value from KAFKA_PASSWORD", string(cfg.Kafka.Password))
+	}
+
+	for name, out := range map[string]string{
+		"Redacted": cfg.Redacted(),
+		"%v":       fmt.Sprintf("%v", cfg),
+		"%+v":      fmt.Sprintf("%+v", cfg.Kafka),
+	} {
+		if strings.Contains(out, "hunter2") {
+			t.Errorf("%s leaks the password: %s", name, out)
+		}
+	}
+	if !strings.Contains(cfg.Redacted(), `"password":"********"`) {
+		t.Errorf("Redacted() = %s, want the masked password", cfg.Redacted())
+	}
+	if Secret("").String() != "" {
+		t.Error("an empty secret should print empty (no mask that suggests one is set)")
+	}
+}
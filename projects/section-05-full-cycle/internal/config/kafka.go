@@ -0,0 +1,59 @@
+package config
+
+import (
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+)
+
+// DLQ devuelve el topic de mensajes muertos (por defecto <topic>-dlq).
+func (k KafkaConfig) DLQ() string {
+	if k.DLQTopic != "" {
+		return k.DLQTopic
+	}
+	return k.Topic + "-dlq"
+}
+
+// NewWriter crea un productor. Con topic == "" el topic va en cada mensaje
+// (lo usa el consumer para escribir en los topics de retry y en el DLQ).
+func (k KafkaConfig) NewWriter(topic string) *kafka.Writer {
+	w := &kafka.Writer{
+		Addr:                   kafka.TCP(k.Brokers...),
+		Topic:                  topic,
+		Balancer:               &kafka.LeastBytes{},
+		AllowAutoTopicCreation: false, // Los topics los crea la "Plataforma" (platform-kafka-admin)
+	}
+	if k.Username != "" {
+		w.Transport = &kafka.Transport{SASL: k.mechanism()}
+	}
+	return w
+}
+
+// NewReader crea un consumidor de un topic dentro de un consumer group.
+func (k KafkaConfig) NewReader(topic, groupID string) *kafka.Reader {
+	cfg := kafka.ReaderConfig{
+		Brokers:  k.Brokers,
+		Topic:    topic,
+		GroupID:  groupID,
+		MinBytes: 10e3, // 10KB
+		MaxBytes: 10e6, // 10MB
+	}
+	if k.Username != "" {
+		cfg.Dialer = &kafka.Dialer{Timeout: 10 * time.Second, DualStack: true, SASLMechanism: k.mechanism()}
+	}
+	return kafka.NewReader(cfg)
+}
+
+func (k KafkaConfig) mechanism() plain.Mechanism {
+	return plain.Mechanism{Username: k.Username, Password: string(k.Password)}
+}
+
+// NewClient crea un cliente para pedidos administrativos (metadata, health checks).
+func (k KafkaConfig) NewClient() *kafka.Client {
+	c := &kafka.Client{Addr: kafka.TCP(k.Brokers...), Timeout: 5 * time.Second}
+	if k.Username != "" {
+		c.Transport = &kafka.Transport{SASL: k.mechanism()}
+	}
+	return c
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Load arma la configuración: defaults -> archivo -> entorno -> flags.
+// Registra los flags en fs (usa flag.CommandLine en los main) y parsea args.
+//
+// 💡 TRUCO: los flags apuntan a los campos de cfg. Se parsean DOS veces:
+// la primera solo para saber qué -config leer; la segunda, después de aplicar
+// archivo y entorno, vuelve a escribir los flags explícitos encima (ganan siempre).
+// Los flags que no se pasan no tocan nada, así que no pisan al archivo con su default.
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	cfg := Defaults()
+	path := fs.String("config", os.Getenv("HERO_CONFIG"), "archivo de configuración JSON (ver config.example.json)")
+	registerFlags(fs, &cfg)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg = Defaults()
+	if *path != "" {
+		if err := loadFile(*path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// loadFile pisa cfg con los campos presentes en el archivo JSON.
+// Un campo desconocido es un error: un typo ("brokres") no debe pasar desapercibido.
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// registerFlags asocia cada flag a un campo de cfg.
+// 💡 Ambos binarios comparten los flags: cada uno ignora los que no usa.
+func registerFlags(fs *flag.FlagSet, cfg *Config) {
+	// API
+	fs.StringVar(&cfg.HTTP.Addr, "http-addr", cfg.HTTP.Addr, "dirección del servidor HTTP")
+	fs.BoolVar(&cfg.HTTP.ContractCheck, "contract-check", cfg.HTTP.ContractCheck, "loguear respuestas que no cumplen openapi.json (desarrollo)")
+	fs.StringVar(&cfg.DB.Driver, "db", cfg.DB.Driver, "repositorio de héroes: memory | sqlite")
+	fs.StringVar(&cfg.DB.Path, "db-path", cfg.DB.Path, "archivo SQLite (solo con -db=sqlite)")
+	fs.IntVar(&cfg.Progression.XPBase, "xp-base", cfg.Progression.XPBase, "XP para pasar de nivel 1 a 2")
+	fs.Float64Var(&cfg.Progression.XPGrowth, "xp-growth", cfg.Progression.XPGrowth, "multiplicador del costo de XP por nivel")
+	fs.IntVar(&cfg.Progression.MaxLevel, "max-level", cfg.Progression.MaxLevel, "nivel máximo")
+
+	// Kafka (la contraseña NO tiene flag: quedaría en el historial de la shell y en `ps`)
+	fs.Var((*stringList)(&cfg.Kafka.Brokers), "brokers", "brokers de Kafka separados por coma")
+	fs.StringVar(&cfg.Kafka.Topic, "topic", cfg.Kafka.Topic, "topic de eventos de héroes")
+	fs.StringVar(&cfg.Kafka.DLQTopic, "dlq-topic", cfg.Kafka.DLQTopic, "topic DLQ (vacío = <topic>-dlq)")
+	fs.StringVar(&cfg.Kafka.EventMode, "event-mode", cfg.Kafka.EventMode, "formato CloudEvents en Kafka: structured | binary")
+	fs.StringVar(&cfg.Kafka.Username, "kafka-username", cfg.Kafka.Username, "usuario SASL/PLAIN (la contraseña va en KAFKA_PASSWORD)")
+
+	// Consumer
+	fs.StringVar(&cfg.Consumer.HealthAddr, "health-addr", cfg.Consumer.HealthAddr, "dirección HTTP de /healthz, /readyz y /metrics del consumer")
+	fs.StringVar(&cfg.Consumer.GroupID, "group", cfg.Consumer.GroupID, "consumer group del topic principal")
+	fs.StringVar(&cfg.Consumer.RetryGroupID, "retry-group", cfg.Consumer.RetryGroupID, "consumer group de los topics de retry")
+	fs.StringVar(&cfg.Consumer.Dedup, "dedup", cfg.Consumer.Dedup, "store de eventos procesados: memory | sqlite")
+	fs.StringVar(&cfg.Consumer.DedupPath, "dedup-path", cfg.Consumer.DedupPath, "archivo SQLite (solo con -dedup=sqlite)")
+	fs.Var(&cfg.Consumer.DedupTTL, "dedup-ttl", "cuánto recordar un evento procesado (solo con -dedup=memory)")
+
+	fs.StringVar(&cfg.Log.Level, "log-level", cfg.Log.Level, "nivel de log: debug | info | warn | error")
+	fs.StringVar(&cfg.Log.Format, "log-format", cfg.Log.Format, "formato de log: text | json")
+	fs.Var(&cfg.ShutdownTimeout, "shutdown-timeout", "tiempo máximo para apagar ordenadamente (SIGINT/SIGTERM)")
+}
+
+// envVars mapea cada variable de entorno a cómo se aplica sobre cfg.
+func envVars(cfg *Config) map[string]func(string) error {
+	str := func(dst *string) func(string) error {
+		return func(v string) error { *dst = v; return nil }
+	}
+	return map[string]func(string) error{
+		"HERO_HTTP_ADDR": str(&cfg.HTTP.Addr),
+		"HERO_CONTRACT_CHECK": func(v string) error {
+			b, err := strconv.ParseBool(v)
+			cfg.HTTP.ContractCheck = b
+			return err
+		},
+		"HERO_DB":               str(&cfg.DB.Driver),
+		"HERO_DB_PATH":          str(&cfg.DB.Path),
+		"KAFKA_BROKERS":         (*stringList)(&cfg.Kafka.Brokers).Set,
+		"HERO_TOPIC":            str(&cfg.Kafka.Topic),
+		"HERO_DLQ_TOPIC":        str(&cfg.Kafka.DLQTopic),
+		"HERO_EVENT_MODE":       str(&cfg.Kafka.EventMode),
+		"KAFKA_USERNAME":        str(&cfg.Kafka.Username),
+		"KAFKA_PASSWORD":        func(v string) error { cfg.Kafka.Password = Secret(v); return nil },
+		"CONSUMER_HEALTH_ADDR":  str(&cfg.Consumer.HealthAddr),
+		"CONSUMER_GROUP":        str(&cfg.Consumer.GroupID),
+		"CONSUMER_RETRY_GROUP":  str(&cfg.Consumer.RetryGroupID),
+		"CONSUMER_DEDUP":        str(&cfg.Consumer.Dedup),
+		"CONSUMER_DEDUP_PATH":   str(&cfg.Consumer.DedupPath),
+		"CONSUMER_DEDUP_TTL":    cfg.Consumer.DedupTTL.Set,
+		"HERO_LOG_LEVEL":        str(&cfg.Log.Level),
+		"HERO_LOG_FORMAT":       str(&cfg.Log.Format),
+		"HERO_SHUTDOWN_TIMEOUT": cfg.ShutdownTimeout.Set,
+	}
+}
+
+// applyEnv pisa cfg con las variables de entorno definidas (y no vacías).
+func applyEnv(cfg *Config) error {
+	for key, set := range envVars(cfg) {
+		v := os.Getenv(key)
+		if v == "" {
+			continue
+		}
+		if err := set(v); err != nil {
+			return fmt.Errorf("env %s=%q: %w", key, v, err)
+		}
+	}
+	return nil
+}
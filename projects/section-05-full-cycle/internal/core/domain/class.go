@@ -0,0 +1,215 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Errores de clases y atributos
+var (
+	ErrInvalidClass         = newError(ErrValidation, "invalid hero class")
+	ErrInvalidAllocation    = newError(ErrValidation, "invalid stat allocation")
+	ErrNotEnoughStatPoints  = newError(ErrConflict, "not enough unspent stat points")
+	ErrAttributeCapExceeded = newError(ErrValidation, "attribute cap exceeded")
+)
+
+// AttributeError es un error TIPADO: dice qué atributo falló y por qué.
+// Se puede inspeccionar con errors.As y comparar con errors.Is (vía Unwrap).
+type AttributeError struct {
+	Attribute string // "str", "agi", "int", "vit"
+	Value     int
+	Limit     int
+	Err       error // Sentinel: ErrInvalidAllocation, ErrAttributeCapExceeded...
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("%v: %s=%d (limit %d)", e.Err, e.Attribute, e.Value, e.Limit)
+}
+
+func (e *AttributeError) Unwrap() error {
+	return e.Err
+}
+
+// HeroClass es la clase (arquetipo) del héroe.
+type HeroClass string
+
+const (
+	ClassWarrior HeroClass = "warrior"
+	ClassMage    HeroClass = "mage"
+	ClassRogue   HeroClass = "rogue"
+	ClassCleric  HeroClass = "cleric"
+)
+
+// DefaultClass es la clase de un héroe creado sin indicarla (antes de que existieran las clases
+// todos eran guerreros: así los clientes viejos siguen funcionando).
+const DefaultClass = ClassWarrior
+
+// Attributes son los atributos base (los que el jugador reparte).
+type Attributes struct {
+	STR int `json:"str"` // Fuerza: ataque físico y defensa
+	AGI int `json:"agi"` // Agilidad: velocidad y algo de ataque
+	INT int `json:"int"` // Inteligencia: magia y maná
+	VIT int `json:"vit"` // Vitalidad: vida y defensa
+}
+
+// Total suma todos los puntos.
+func (a Attributes) Total() int {
+	return a.STR + a.AGI + a.INT + a.VIT
+}
+
+// Add suma dos sets de atributos.
+func (a Attributes) Add(b Attributes) Attributes {
+	return Attributes{STR: a.STR + b.STR, AGI: a.AGI + b.AGI, INT: a.INT + b.INT, VIT: a.VIT + b.VIT}
+}
+
+// each recorre los atributos por nombre (útil para validar sin repetir código).
+func (a Attributes) each(fn func(name string, value int) error) error {
+	for _, f := range []struct {
+		name  string
+		value int
+	}{{"str", a.STR}, {"agi", a.AGI}, {"int", a.INT}, {"vit", a.VIT}} {
+		if err := fn(f.name, f.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassDefinition son las reglas de una clase: atributos iniciales y crecimiento.
+type ClassDefinition struct {
+	Class      HeroClass
+	Base       Attributes // Atributos a nivel 1
+	BasePower  int        // Poder inicial
+	BaseHP     int
+	BaseMP     int
+	HPPerLevel int
+	MPPerLevel int
+}
+
+// classes es el catálogo de clases jugables.
+var classes = map[HeroClass]ClassDefinition{
+	ClassWarrior: {Class: ClassWarrior, Base: Attributes{STR: 12, AGI: 8, INT: 4, VIT: 12}, BasePower: 12, BaseHP: 120, BaseMP: 20, HPPerLevel: 15, MPPerLevel: 2},
+	ClassMage:    {Class: ClassMage, Base: Attributes{STR: 4, AGI: 7, INT: 14, VIT: 7}, BasePower: 10, BaseHP: 70, BaseMP: 100, HPPerLevel: 7, MPPerLevel: 12},
+	ClassRogue:   {Class: ClassRogue, Base: Attributes{STR: 8, AGI: 14, INT: 6, VIT: 8}, BasePower: 11, BaseHP: 90, BaseMP: 40, HPPerLevel: 10, MPPerLevel: 4},
+	ClassCleric:  {Class: ClassCleric, Base: Attributes{STR: 7, AGI: 6, INT: 11, VIT: 10}, BasePower: 9, BaseHP: 100, BaseMP: 80, HPPerLevel: 12, MPPerLevel: 9},
+}
+
+// LookupClass devuelve la definición de una clase o ErrInvalidClass.
+func LookupClass(class HeroClass) (ClassDefinition, error) {
+	def, ok := classes[class]
+	if !ok {
+		return ClassDefinition{}, fmt.Errorf("%w: %q (valid: %s)", ErrInvalidClass, class, strings.Join(ClassNames(), ", "))
+	}
+	return def, nil
+}
+
+// ClassNames lista las clases válidas (ordenadas).
+func ClassNames() []string {
+	names := make([]string, 0, len(classes))
+	for c := range classes {
+		names = append(names, string(c))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AttributeCap es el máximo que puede tener UN atributo a cierto nivel.
+// Evita builds degenerados (todo en un atributo): obliga a repartir.
+func AttributeCap(level int) int {
+	return 20 + 4*(level-1)
+}
+
+// DerivedStats son estadísticas CALCULADAS (no se guardan): clase + nivel + atributos + equipo.
+type DerivedStats struct {
+	Power   int `json:"power"` // Poder base + poder del equipo
+	MaxHP   int `json:"max_hp"`
+	MaxMP   int `json:"max_mp"`
+	Attack  int `json:"attack"`
+	Magic   int `json:"magic"`
+	Defense int `json:"defense"`
+	Speed   int `json:"speed"`
+}
+
+// Stats calcula las estadísticas derivadas del héroe.
+func (h *Hero) Stats() DerivedStats {
+	def, err := LookupClass(h.Class)
+	if err != nil {
+		return DerivedStats{}
+	}
+	// El equipo suma atributos y poder mientras esté puesto (no modifica los base)
+	bonus, bonusPower := h.EquipmentBonus()
+	a := h.Attributes.Add(bonus)
+	power := h.Power + bonusPower
+	return DerivedStats{
+		Power:   power,
+		MaxHP:   def.BaseHP + def.HPPerLevel*(h.Level-1) + a.VIT*10,
+		MaxMP:   def.BaseMP + def.MPPerLevel*(h.Level-1) + a.INT*5,
+		Attack:  power + a.STR*2 + a.AGI/2,
+		Magic:   power + a.INT*2,
+		Defense: a.VIT + a.STR/2,
+		Speed:   a.AGI*2 + h.Level,
+	}
+}
+
+// AllocateStats reparte puntos sin gastar entre los atributos.
+func (h *Hero) AllocateStats(points Attributes) error {
+	// 1. Cada valor por separado: ni negativo (no se puede "des-asignar") ni más grande que los
+	// puntos disponibles o el tope. ⚠️ Se valida ANTES de sumar: con valores enormes, Total()
+	// desborda int y podría dar un total chico (o negativo) que pasaría el chequeo del paso 2.
+	limit := AttributeCap(h.Level)
+	err := points.each(func(name string, value int) error {
+		switch {
+		case value < 0:
+			return &AttributeError{Attribute: name, Value: value, Limit: 0, Err: ErrInvalidAllocation}
+		case value > limit:
+			return &AttributeError{Attribute: name, Value: value, Limit: limit, Err: ErrAttributeCapExceeded}
+		case value > h.StatPoints:
+			return fmt.Errorf("%w: requested %s=%d, available %d", ErrNotEnoughStatPoints, name, value, h.StatPoints)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 2. Hay que asignar algo, y no más de lo disponible
+	total := points.Total()
+	if total == 0 {
+		return fmt.Errorf("%w: nothing to allocate", ErrInvalidAllocation)
+	}
+	if total > h.StatPoints {
+		return fmt.Errorf("%w: requested %d, available %d", ErrNotEnoughStatPoints, total, h.StatPoints)
+	}
+
+	// 3. Ningún atributo puede superar el tope del nivel
+	result := h.Attributes.Add(points)
+	err = result.each(func(name string, value int) error {
+		if value > limit {
+			return &AttributeError{Attribute: name, Value: value, Limit: limit, Err: ErrAttributeCapExceeded}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	h.Attributes = result
+	h.StatPoints -= total
+	return nil
+}
+
+// MarshalJSON agrega las estadísticas derivadas al JSON del héroe.
+// 🎓 ALIAS: `type heroJSON Hero` copia los campos pero NO los métodos,
+// así evitamos que json.Marshal vuelva a llamar a este MarshalJSON (recursión infinita).
+func (h Hero) MarshalJSON() ([]byte, error) {
+	type heroJSON Hero
+	return json.Marshal(struct {
+		heroJSON
+		Stats DerivedStats `json:"stats"`
+	}{
+		heroJSON: heroJSON(h),
+		Stats:    h.Stats(),
+	})
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func newAllocationHero(t *testing.T, statPoints int) *Hero {
+	t.Helper()
+	hero, err := NewHero("h-1", "Arthas", ClassWarrior) // STR 12, AGI 8, INT 4, VIT 12; tope 20 a nivel 1
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	hero.StatPoints = statPoints
+	return hero
+}
+
+func TestAllocateStats(t *testing.T) {
+	hero := newAllocationHero(t, 10)
+
+	if err := hero.AllocateStats(Attributes{STR: 3, INT: 2}); err != nil {
+		t.Fatalf("AllocateStats: %v", err)
+	}
+	want := Attributes{STR: 15, AGI: 8, INT: 6, VIT: 12}
+	if hero.Attributes != want || hero.StatPoints != 5 {
+		t.Errorf("got %+v with %d points left, want %+v with 5", hero.Attributes, hero.StatPoints, want)
+	}
+}
+
+func TestAllocateStatsRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		statPoints int
+		points     Attributes
+		want       error
+		attribute  string // Si no es vacío, el error tiene que ser un *AttributeError de ese atributo
+	}{
+		{"negative value", 10, Attributes{STR: 3, AGI: -1}, ErrInvalidAllocation, "agi"},
+		{"nothing to allocate", 10, Attributes{}, ErrInvalidAllocation, ""},
+		{"not enough points", 4, Attributes{STR: 2, AGI: 3}, ErrNotEnoughStatPoints, ""},
+		{"single value above points", 4, Attributes{VIT: 5}, ErrNotEnoughStatPoints, ""},
+		{"above attribute cap", 20, Attributes{STR: 9}, ErrAttributeCapExceeded, "str"},
+		// Sin validar cada valor antes de sumar, MaxInt + MaxInt + 2 desborda a 0 y pasaría.
+		{"overflowing total", 10, Attributes{STR: math.MaxInt, AGI: math.MaxInt, INT: 2}, ErrAttributeCapExceeded, "str"},
+		{"overflowing to negative", 10, Attributes{INT: math.MaxInt, VIT: math.MaxInt}, ErrAttributeCapExceeded, "int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hero := newAllocationHero(t, tt.statPoints)
+			before := hero.Attributes
+
+			err := hero.AllocateStats(tt.points)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AllocateStats(%+v) = %v, want %v", tt.points, err, tt.want)
+			}
+			if tt.attribute != "" {
+				var attrErr *AttributeError
+				if !errors.As(err, &attrErr) || attrErr.Attribute != tt.attribute {
+					t.Errorf("error %v is not an AttributeError for %q", err, tt.attribute)
+				}
+			}
+			if hero.Attributes != before || hero.StatPoints != tt.statPoints {
+				t.Errorf("a rejected allocation changed the hero: %+v, %d points", hero.Attributes, hero.StatPoints)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "context"
+
+// 🎓 VALORES DE PETICIÓN (request-scoped):
+// El context.Context no solo cancela: también transporta datos de la petición
+// (quién la hizo, a qué "conversación" pertenece) a través de todas las capas,
+// sin agregar parámetros a cada firma. Así llegan hasta el sobre del evento.
+
+// ctxKey es un tipo privado: evita choques con claves de otros paquetes.
+type ctxKey int
+
+const (
+	correlationIDKey ctxKey = iota
+	actorKey
+	requestIDKey
+)
+
+// WithRequestID guarda el ID de la petición que originó el trabajo (header X-Request-ID).
+// A diferencia de la correlación (que puede venir del cliente y abarcar varias peticiones),
+// identifica UNA petición: la sigue por los logs de la API, el outbox y el consumer.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFrom devuelve el ID de la petición ("" si no hay).
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithCorrelationID guarda el ID de correlación (trace ID) de la petición.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationIDFrom devuelve el ID de correlación ("" si no hay).
+func CorrelationIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// WithActor guarda quién origina la petición (usuario, servicio...).
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// ActorFrom devuelve el actor de la petición ("" si no hay).
+func ActorFrom(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey).(string)
+	return actor
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "errors"
+
+// 🎓 TAXONOMÍA DE ERRORES
+// Cada error concreto (ErrHeroNameEmpty, ErrInventoryFull...) pertenece a UNA categoría.
+// Los adaptadores de entrada (HTTP, CLI) solo miran la categoría para decidir
+// cómo responder, sin conocer cada error del dominio:
+//
+//	errors.Is(err, domain.ErrHeroNameEmpty) // ¿Es este error puntual?
+//	errors.Is(err, domain.ErrValidation)    // ¿Es un error de validación? (HTTP 400)
+
+// Categorías de error.
+var (
+	ErrValidation  = errors.New("validation error")    // El input no es válido (400)
+	ErrNotFound    = errors.New("not found")           // El recurso no existe (404)
+	ErrConflict    = errors.New("conflict")            // El estado actual no permite la operación (409)
+	ErrUnavailable = errors.New("service unavailable") // Falló la infraestructura (DB, Kafka...) (503)
+)
+
+// ErrHeroNotFound: no existe un héroe con ese ID.
+var ErrHeroNotFound = newError(ErrNotFound, "hero not found")
+
+// Errores de consultas (listados).
+var (
+	ErrInvalidQuery  = newError(ErrValidation, "invalid query")
+	ErrInvalidCursor = newError(ErrValidation, "invalid cursor")
+)
+
+// categorizedError es un error con mensaje propio que además "es" su categoría.
+type categorizedError struct {
+	kind error
+	msg  string
+}
+
+func newError(kind error, msg string) error {
+	return &categorizedError{kind: kind, msg: msg}
+}
+
+func (e *categorizedError) Error() string {
+	return e.msg
+}
+
+// Is permite errors.Is(err, ErrValidation) además de errors.Is(err, ErrHeroNameEmpty).
+func (e *categorizedError) Is(target error) bool {
+	return target == e.kind
+}
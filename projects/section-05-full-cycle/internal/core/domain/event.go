@@ -0,0 +1,278 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType identifica QUÉ pasó. Es un tipo propio (no un string suelto)
+// para que el compilador nos avise si escribimos mal un evento.
+type EventType string
+
+// Tipos de eventos del agregado Hero.
+const (
+	EventHeroCreated             EventType = "HeroCreated"
+	EventHeroCreateFailed        EventType = "HeroCreateFailed"
+	EventHeroUpdated             EventType = "HeroUpdated"
+	EventHeroUpdateFailed        EventType = "HeroUpdateFailed"
+	EventHeroDeleted             EventType = "HeroDeleted"
+	EventHeroDeleteFailed        EventType = "HeroDeleteFailed"
+	EventHeroXPGained            EventType = "HeroXPGained"
+	EventHeroXPGrantFailed       EventType = "HeroXPGrantFailed"
+	EventHeroLeveledUp           EventType = "HeroLeveledUp" // Uno por cada nivel ganado
+	EventHeroStatsAllocated      EventType = "HeroStatsAllocated"
+	EventHeroStatsAllocateFailed EventType = "HeroStatsAllocateFailed"
+	EventItemAdded               EventType = "ItemAdded"
+	EventItemAddFailed           EventType = "ItemAddFailed"
+	EventItemRemoved             EventType = "ItemRemoved"
+	EventItemRemoveFailed        EventType = "ItemRemoveFailed"
+	EventItemEquipped            EventType = "ItemEquipped"
+	EventItemEquipFailed         EventType = "ItemEquipFailed"
+	EventItemUnequipped          EventType = "ItemUnequipped"
+	EventItemUnequipFailed       EventType = "ItemUnequipFailed"
+)
+
+// Metadatos fijos del sobre (envelope).
+const (
+	CloudEventsSpecVersion = "1.0"
+	EventSource            = "/section-05/hero-service" // Quién emite (CloudEvents "source")
+	EventSchemaVersion     = 2                          // Versión del esquema de "data" (se sube si cambia Hero). 2: "change"
+	EventDataContentType   = "application/json"
+)
+
+// ContentMode define cómo viaja el evento por Kafka.
+//
+// 🎓 CLOUDEVENTS (Kafka Protocol Binding):
+//   - structured: el mensaje ES el sobre completo (JSON con id, type, data...).
+//   - binary: el mensaje es solo "data"; los atributos van en headers "ce_*".
+type ContentMode string
+
+const (
+	ContentModeStructured ContentMode = "structured"
+	ContentModeBinary     ContentMode = "binary"
+)
+
+// RequestIDHeader es un header de mensaje fuera de CloudEvents con el ID de la petición:
+// está en ambos modos (en structured el sobre va en el body), así el consumer, kafka-ui
+// o un log shipper lo leen sin decodificar el evento.
+const RequestIDHeader = "request-id"
+
+const (
+	structuredContentType = "application/cloudevents+json; charset=UTF-8"
+	contentTypeHeader     = "content-type"
+	binaryHeaderPrefix    = "ce_"
+)
+
+// ErrInvalidEvent se devuelve cuando un mensaje no es un evento válido.
+var ErrInvalidEvent = errors.New("invalid event")
+
+// Event es el sobre (envelope) versionado de un evento de dominio.
+// Sus tags JSON siguen los nombres de atributos de CloudEvents 1.0;
+// correlationid, causationid, actor, requestid y schemaversion son extensiones.
+type Event struct {
+	ID              string     `json:"id"`
+	Type            EventType  `json:"type"`
+	Source          string     `json:"source"`
+	SpecVersion     string     `json:"specversion"`
+	Subject         string     `json:"subject,omitempty"` // ID del héroe (también Key en Kafka)
+	Time            time.Time  `json:"time"`
+	DataContentType string     `json:"datacontenttype,omitempty"`
+	SchemaVersion   int        `json:"schemaversion"`
+	CorrelationID   string     `json:"correlationid,omitempty"` // Agrupa toda una "conversación" (ej: una petición HTTP)
+	CausationID     string     `json:"causationid,omitempty"`   // ID del mensaje que provocó este evento
+	Actor           string     `json:"actor,omitempty"`         // Quién originó la petición (ej: header X-Actor)
+	RequestID       string     `json:"requestid,omitempty"`     // Petición HTTP que lo originó (header X-Request-ID)
+	Data            *EventData `json:"data,omitempty"`
+}
+
+// EventData es el "data" del evento: la foto del héroe y, en algunos eventos, QUÉ cambió.
+// 💡 Embebe *Hero: event.Data.ID, event.Data.Name... se leen igual que antes.
+// En JSON va todo al mismo nivel ({"id": ..., "name": ..., "change": {...}}): un consumer
+// de la versión 1 sigue leyendo el héroe e ignora "change".
+type EventData struct {
+	*Hero
+	Change *EventChange `json:"change,omitempty"`
+}
+
+// EventChange es el detalle del cambio (schemaversion >= 2). La foto dice cómo QUEDÓ el héroe;
+// esto dice qué pasó, sin tener que comparar con la foto anterior.
+//   - ItemAdded / ItemRemoved: item_id, quantity y slot (vacío si el item no se equipa).
+//   - ItemEquipped / ItemUnequipped: item_id, quantity (1) y slot.
+//   - HeroXPGained: amount (XP otorgada).
+//
+// 💡 Agregar campos opcionales a "change" NO sube EventSchemaVersion: un consumer viejo los ignora.
+type EventChange struct {
+	ItemID   string   `json:"item_id,omitempty"`
+	Quantity int      `json:"quantity,omitempty"`
+	Slot     ItemSlot `json:"slot,omitempty"`
+	Amount   int      `json:"amount,omitempty"`
+}
+
+// MarshalJSON escribe el héroe y le agrega "change" al mismo nivel.
+func (d EventData) MarshalJSON() ([]byte, error) {
+	hero, err := json.Marshal(d.Hero)
+	if err != nil || d.Change == nil {
+		return hero, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(hero, &fields); err != nil {
+		return nil, err
+	}
+	if fields["change"], err = json.Marshal(d.Change); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON separa el héroe del "change" (si viene).
+func (d *EventData) UnmarshalJSON(data []byte) error {
+	var hero Hero
+	if err := json.Unmarshal(data, &hero); err != nil {
+		return err
+	}
+	var extra struct {
+		Change *EventChange `json:"change"`
+	}
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return err
+	}
+	d.Hero, d.Change = &hero, extra.Change
+	return nil
+}
+
+// NewHeroEvent crea un evento para un héroe.
+// Guarda una COPIA del héroe: el evento refleja el estado en este instante,
+// aunque el héroe original se modifique después.
+// Por defecto la correlación es el propio evento (inicio de la cadena).
+func NewHeroEvent(id string, eventType EventType, hero *Hero) Event {
+	event := Event{
+		ID:              id,
+		Type:            eventType,
+		Source:          EventSource,
+		SpecVersion:     CloudEventsSpecVersion,
+		Time:            time.Now().UTC(),
+		DataContentType: EventDataContentType,
+		SchemaVersion:   EventSchemaVersion,
+		CorrelationID:   id,
+	}
+	if hero != nil {
+		event.Subject = hero.ID
+		event.Data = &EventData{Hero: hero.Clone()} // Foto: cambios posteriores no alteran el evento
+	}
+	return event
+}
+
+// Validate comprueba los atributos obligatorios de CloudEvents.
+func (e Event) Validate() error {
+	switch {
+	case e.ID == "":
+		return fmt.Errorf("%w: missing id", ErrInvalidEvent)
+	case e.Type == "":
+		return fmt.Errorf("%w: missing type", ErrInvalidEvent)
+	case e.Source == "":
+		return fmt.Errorf("%w: missing source", ErrInvalidEvent)
+	case e.SpecVersion != CloudEventsSpecVersion:
+		return fmt.Errorf("%w: unsupported specversion %q", ErrInvalidEvent, e.SpecVersion)
+	case e.SchemaVersion > EventSchemaVersion:
+		return fmt.Errorf("%w: unknown schemaversion %d", ErrInvalidEvent, e.SchemaVersion)
+	}
+	return nil
+}
+
+// EncodeEvent serializa el evento en el modo indicado.
+// Devuelve los headers y el cuerpo del mensaje (independiente de Kafka).
+func EncodeEvent(e Event, mode ContentMode) (map[string]string, []byte, error) {
+	switch mode {
+	case ContentModeBinary:
+		value, err := json.Marshal(e.Data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error serializando data: %w", err)
+		}
+		headers := map[string]string{
+			contentTypeHeader:                    e.DataContentType,
+			binaryHeaderPrefix + "id":            e.ID,
+			binaryHeaderPrefix + "type":          string(e.Type),
+			binaryHeaderPrefix + "source":        e.Source,
+			binaryHeaderPrefix + "specversion":   e.SpecVersion,
+			binaryHeaderPrefix + "time":          e.Time.Format(time.RFC3339Nano),
+			binaryHeaderPrefix + "schemaversion": strconv.Itoa(e.SchemaVersion),
+			binaryHeaderPrefix + "subject":       e.Subject,
+			binaryHeaderPrefix + "correlationid": e.CorrelationID,
+			binaryHeaderPrefix + "causationid":   e.CausationID,
+			binaryHeaderPrefix + "actor":         e.Actor,
+			binaryHeaderPrefix + "requestid":     e.RequestID,
+		}
+		// Los atributos opcionales vacíos no se envían.
+		for k, v := range headers {
+			if v == "" {
+				delete(headers, k)
+			}
+		}
+		return headers, value, nil
+
+	case ContentModeStructured, "":
+		value, err := json.Marshal(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error serializando evento: %w", err)
+		}
+		return map[string]string{contentTypeHeader: structuredContentType}, value, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown content mode %q", mode)
+}
+
+// DecodeEvent reconstruye un evento a partir de headers + cuerpo.
+// Detecta el modo solo: si hay header "ce_id" es binary; si no, structured.
+func DecodeEvent(headers map[string]string, value []byte) (Event, error) {
+	var e Event
+
+	if id, ok := headers[binaryHeaderPrefix+"id"]; ok {
+		e = Event{
+			ID:              id,
+			Type:            EventType(headers[binaryHeaderPrefix+"type"]),
+			Source:          headers[binaryHeaderPrefix+"source"],
+			SpecVersion:     headers[binaryHeaderPrefix+"specversion"],
+			Subject:         headers[binaryHeaderPrefix+"subject"],
+			DataContentType: headers[contentTypeHeader],
+			CorrelationID:   headers[binaryHeaderPrefix+"correlationid"],
+			CausationID:     headers[binaryHeaderPrefix+"causationid"],
+			Actor:           headers[binaryHeaderPrefix+"actor"],
+			RequestID:       headers[binaryHeaderPrefix+"requestid"],
+		}
+		if t, ok := headers[binaryHeaderPrefix+"time"]; ok {
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return Event{}, fmt.Errorf("%w: bad time %q", ErrInvalidEvent, t)
+			}
+			e.Time = parsed
+		}
+		if v, ok := headers[binaryHeaderPrefix+"schemaversion"]; ok {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return Event{}, fmt.Errorf("%w: bad schemaversion %q", ErrInvalidEvent, v)
+			}
+			e.SchemaVersion = n
+		}
+		if len(value) > 0 && string(value) != "null" {
+			if err := json.Unmarshal(value, &e.Data); err != nil {
+				return Event{}, fmt.Errorf("%w: bad data: %v", ErrInvalidEvent, err)
+			}
+		}
+	} else {
+		if ct := headers[contentTypeHeader]; ct != "" && !strings.HasPrefix(ct, "application/cloudevents+json") {
+			return Event{}, fmt.Errorf("%w: unexpected content-type %q", ErrInvalidEvent, ct)
+		}
+		if err := json.Unmarshal(value, &e); err != nil {
+			return Event{}, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+	}
+
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+	return e, nil
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventChangeRoundTrip(t *testing.T) {
+	hero, err := NewHero("h-1", "Arthas", ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	tests := []struct {
+		eventType EventType
+		change    EventChange
+	}{
+		{EventHeroXPGained, EventChange{Amount: 300}},
+		{EventItemAdded, EventChange{ItemID: "health-potion", Quantity: 5}},
+		{EventItemEquipped, EventChange{ItemID: "rusty-sword", Quantity: 1, Slot: SlotWeapon}},
+	}
+	for _, tt := range tests {
+		event := NewHeroEvent("evt-1", tt.eventType, hero)
+		event.Data.Change = &tt.change
+
+		for _, mode := range []ContentMode{ContentModeStructured, ContentModeBinary} {
+			t.Run(string(tt.eventType)+"/"+string(mode), func(t *testing.T) {
+				headers, value, err := EncodeEvent(event, mode)
+				if err != nil {
+					t.Fatalf("EncodeEvent: %v", err)
+				}
+				got, err := DecodeEvent(headers, value)
+				if err != nil {
+					t.Fatalf("DecodeEvent: %v", err)
+				}
+				if got.SchemaVersion != EventSchemaVersion {
+					t.Errorf("schemaversion = %d, want %d", got.SchemaVersion, EventSchemaVersion)
+				}
+				if got.Data == nil || got.Data.Hero == nil || got.Data.ID != hero.ID || got.Data.Name != hero.Name {
+					t.Fatalf("data hero = %+v, want %s/%s", got.Data, hero.ID, hero.Name)
+				}
+				if got.Data.Change == nil || *got.Data.Change != tt.change {
+					t.Errorf("change = %+v, want %+v", got.Data.Change, tt.change)
+				}
+			})
+		}
+	}
+}
+
+func TestEventDataKeepsHeroFieldsFlat(t *testing.T) {
+	hero, err := NewHero("h-1", "Arthas", ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	data := EventData{Hero: hero, Change: &EventChange{Amount: 150}}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	// Un consumer de la versión 1 decodifica "data" directamente como Hero
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	for _, key := range []string{"id", "name", "level", "xp", "change"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("data is missing %q: %s", key, raw)
+		}
+	}
+	if change := fields["change"].(map[string]any); change["amount"] != float64(150) {
+		t.Errorf("change.amount = %v, want 150", change["amount"])
+	}
+
+	// Sin cambio, "data" es exactamente el héroe (como en la versión 1)
+	plain, err := json.Marshal(EventData{Hero: hero})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want, _ := json.Marshal(hero)
+	if string(plain) != string(want) {
+		t.Errorf("data without change = %s, want %s", plain, want)
+	}
+}
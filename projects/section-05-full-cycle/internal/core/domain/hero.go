@@ -1,17 +1,18 @@
 package domain
 
 import (
-	"errors"
-	"fmt"
 	"time"
 )
 
 // Errores de dominio
 // En Node.js harías: class InvalidHeroError extends Error {}
-// En Go, los errores son variables simples.
+// En Go, los errores son variables simples (cada una con su categoría, ver errors.go).
 var (
-	ErrHeroNameEmpty = errors.New("hero name cannot be empty")
-	ErrHeroPowerLow  = errors.New("hero power must be at least 1")
+	ErrHeroNameEmpty = newError(ErrValidation, "hero name cannot be empty")
+	ErrHeroPowerLow  = newError(ErrValidation, "hero power must be at least 1")
+
+	// ErrVersionConflict: alguien modificó el héroe desde que lo leímos (concurrencia optimista).
+	ErrVersionConflict = newError(ErrConflict, "hero version conflict")
 )
 
 // Hero representa a nuestro protagonista.
@@ -22,10 +23,22 @@ var (
 // TAGS (`json:"..."`): Son metadatos. Le dicen a Go: "Cuando conviertas esto a JSON,
 // usa 'id' minúscula en lugar de 'ID' mayúscula".
 type Hero struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Level     int       `json:"level"`
-	Power     int       `json:"power"`
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Class      HeroClass  `json:"class"`
+	Level      int        `json:"level"`
+	Power      int        `json:"power"`
+	XP         int        `json:"xp"`          // Experiencia TOTAL acumulada (ver LevelCurve)
+	Attributes Attributes `json:"attributes"`  // STR/AGI/INT/VIT (base de clase + puntos asignados)
+	StatPoints int        `json:"stat_points"` // Puntos ganados al subir de nivel, sin asignar
+
+	Inventory []InventoryStack    `json:"inventory"` // Casillas del inventario (máx. InventoryCapacity)
+	Equipment map[ItemSlot]string `json:"equipment"` // Slot -> ID del item equipado
+
+	// Version sube en cada escritura. Sirve para detectar escrituras concurrentes
+	// (ver ports.HeroRepository.Update) y como ETag en HTTP.
+	Version int `json:"version"`
+
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -41,22 +54,34 @@ type Hero struct {
 // 💡 WHY POINTERS?
 // 1. EFICIENCIA: Evitamos copiar structs grandes.
 // 2. IDENTIDAD: Referenciamos al MISMO objeto único.
-func NewHero(id string, name string) (*Hero, error) {
+func NewHero(id string, name string, class HeroClass) (*Hero, error) {
 	if name == "" {
-		return nil, fmt.Errorf("hero name cannot be empty")
+		return nil, ErrHeroNameEmpty
+	}
+
+	// La clase define atributos y poder iniciales
+	def, err := LookupClass(class)
+	if err != nil {
+		return nil, err
 	}
 
 	// &Hero{...} <- "Genera el struct y dame su dirección (&)"
 	return &Hero{
-		ID:        id,
-		Name:      name,
-		Level:     1, // Default value
-		Power:     10,
-		CreatedAt: time.Now(),
+		ID:         id,
+		Name:       name,
+		Class:      def.Class,
+		Level:      1, // Default value
+		Power:      def.BasePower,
+		Attributes: def.Base,
+		Inventory:  []InventoryStack{},
+		Equipment:  map[ItemSlot]string{},
+		Version:    1,
+		CreatedAt:  time.Now(),
 	}, nil
 }
 
-// LevelUp aumenta el nivel y poder del héroe.
+// LevelUp aumenta el nivel y poder del héroe según la curva.
+// Se invoca desde GainXP (no directamente): el nivel se gana con experiencia.
 // Usa un "Pointer Receiver" (h *Hero).
 //
 // 💡 POINTERS (Sintaxis):
@@ -65,7 +90,20 @@ func NewHero(id string, name string) (*Hero, error) {
 //
 // 💡 WHY POINTERS?
 // MUTABILIDAD: Al tener la dirección, podemos modificar el valor real en memoria.
-func (h *Hero) LevelUp() {
+func (h *Hero) LevelUp(curve LevelCurve) {
 	h.Level++
-	h.Power += 10
+	h.Power += curve.PowerGain(h.Power)
+	h.StatPoints += curve.StatPointsPerLevel
+}
+
+// Clone devuelve una copia PROFUNDA del héroe.
+// 💡 Copiar el struct (`*h`) no alcanza: slices y maps se comparten entre copias.
+func (h *Hero) Clone() *Hero {
+	c := *h
+	c.Inventory = append([]InventoryStack{}, h.Inventory...)
+	c.Equipment = make(map[ItemSlot]string, len(h.Equipment))
+	for slot, itemID := range h.Equipment {
+		c.Equipment[slot] = itemID
+	}
+	return &c
 }
@@ -0,0 +1,300 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Errores de inventario y equipo
+var (
+	ErrItemNotFound       = newError(ErrValidation, "item not found in catalog")
+	ErrInvalidQuantity    = newError(ErrValidation, "item quantity must be greater than 0")
+	ErrInventoryFull      = newError(ErrConflict, "inventory is full")
+	ErrItemNotInInventory = newError(ErrConflict, "item not in inventory")
+	ErrItemNotEquippable  = newError(ErrValidation, "item cannot be equipped")
+	ErrItemLevelTooLow    = newError(ErrConflict, "hero level too low for item")
+	ErrInvalidSlot        = newError(ErrValidation, "invalid equipment slot")
+	ErrSlotEmpty          = newError(ErrConflict, "equipment slot is empty")
+)
+
+// InventoryCapacity es la cantidad de casillas (stacks) del inventario.
+const InventoryCapacity = 20
+
+// ItemSlot es la parte del cuerpo donde se equipa un item.
+type ItemSlot string
+
+const (
+	SlotNone      ItemSlot = "" // Consumibles y materiales: no se equipan
+	SlotWeapon    ItemSlot = "weapon"
+	SlotHead      ItemSlot = "head"
+	SlotChest     ItemSlot = "chest"
+	SlotLegs      ItemSlot = "legs"
+	SlotAccessory ItemSlot = "accessory"
+)
+
+// EquipmentSlots lista los slots equipables (en orden de "paper doll").
+func EquipmentSlots() []ItemSlot {
+	return []ItemSlot{SlotWeapon, SlotHead, SlotChest, SlotLegs, SlotAccessory}
+}
+
+// ParseSlot valida un slot recibido desde afuera (HTTP, CLI...).
+func ParseSlot(s string) (ItemSlot, error) {
+	for _, slot := range EquipmentSlots() {
+		if string(slot) == s {
+			return slot, nil
+		}
+	}
+	return SlotNone, fmt.Errorf("%w: %q", ErrInvalidSlot, s)
+}
+
+// Rarity es la rareza del item (solo informativa por ahora).
+type Rarity string
+
+const (
+	RarityCommon    Rarity = "common"
+	RarityUncommon  Rarity = "uncommon"
+	RarityRare      Rarity = "rare"
+	RarityEpic      Rarity = "epic"
+	RarityLegendary Rarity = "legendary"
+)
+
+// ItemDefinition son las reglas de un item del catálogo.
+// El inventario solo guarda el ID: las estadísticas siempre salen de aquí.
+type ItemDefinition struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Slot      ItemSlot   `json:"slot,omitempty"`
+	Rarity    Rarity     `json:"rarity"`
+	MaxStack  int        `json:"max_stack"` // 1 = no apilable (equipo)
+	MinLevel  int        `json:"min_level"`
+	Power     int        `json:"power"`     // Poder extra mientras está equipado
+	Modifiers Attributes `json:"modifiers"` // Atributos extra mientras está equipado
+}
+
+// Equippable indica si el item ocupa un slot de equipo.
+func (d ItemDefinition) Equippable() bool {
+	return d.Slot != SlotNone
+}
+
+// itemCatalog es el catálogo de items del juego.
+var itemCatalog = map[string]ItemDefinition{
+	"rusty-sword":      {ID: "rusty-sword", Name: "Rusty Sword", Slot: SlotWeapon, Rarity: RarityCommon, MaxStack: 1, MinLevel: 1, Power: 3, Modifiers: Attributes{STR: 1}},
+	"oak-staff":        {ID: "oak-staff", Name: "Oak Staff", Slot: SlotWeapon, Rarity: RarityCommon, MaxStack: 1, MinLevel: 1, Power: 2, Modifiers: Attributes{INT: 2}},
+	"twin-daggers":     {ID: "twin-daggers", Name: "Twin Daggers", Slot: SlotWeapon, Rarity: RarityUncommon, MaxStack: 1, MinLevel: 3, Power: 5, Modifiers: Attributes{AGI: 3}},
+	"runeblade":        {ID: "runeblade", Name: "Runeblade", Slot: SlotWeapon, Rarity: RarityEpic, MaxStack: 1, MinLevel: 10, Power: 20, Modifiers: Attributes{STR: 4, INT: 4}},
+	"leather-cap":      {ID: "leather-cap", Name: "Leather Cap", Slot: SlotHead, Rarity: RarityCommon, MaxStack: 1, MinLevel: 1, Modifiers: Attributes{VIT: 1}},
+	"chainmail":        {ID: "chainmail", Name: "Chainmail", Slot: SlotChest, Rarity: RarityUncommon, MaxStack: 1, MinLevel: 5, Power: 2, Modifiers: Attributes{VIT: 4, AGI: -1}},
+	"traveler-boots":   {ID: "traveler-boots", Name: "Traveler Boots", Slot: SlotLegs, Rarity: RarityCommon, MaxStack: 1, MinLevel: 1, Modifiers: Attributes{AGI: 1}},
+	"ring-of-insight":  {ID: "ring-of-insight", Name: "Ring of Insight", Slot: SlotAccessory, Rarity: RarityRare, MaxStack: 1, MinLevel: 5, Power: 3, Modifiers: Attributes{INT: 3}},
+	"health-potion":    {ID: "health-potion", Name: "Health Potion", Rarity: RarityCommon, MaxStack: 20},
+	"dragon-scale":     {ID: "dragon-scale", Name: "Dragon Scale", Rarity: RarityLegendary, MaxStack: 5},
+	"iron-ore":         {ID: "iron-ore", Name: "Iron Ore", Rarity: RarityCommon, MaxStack: 50},
+	"phoenix-feather":  {ID: "phoenix-feather", Name: "Phoenix Feather", Rarity: RarityEpic, MaxStack: 3},
+	"cloak-of-shadows": {ID: "cloak-of-shadows", Name: "Cloak of Shadows", Slot: SlotChest, Rarity: RarityRare, MaxStack: 1, MinLevel: 8, Power: 4, Modifiers: Attributes{AGI: 4}},
+}
+
+// LookupItem devuelve la definición de un item o ErrItemNotFound.
+func LookupItem(id string) (ItemDefinition, error) {
+	def, ok := itemCatalog[id]
+	if !ok {
+		return ItemDefinition{}, fmt.Errorf("%w: %q", ErrItemNotFound, id)
+	}
+	return def, nil
+}
+
+// ItemCatalog lista todas las definiciones (ordenadas por ID).
+func ItemCatalog() []ItemDefinition {
+	defs := make([]ItemDefinition, 0, len(itemCatalog))
+	for _, def := range itemCatalog {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].ID < defs[j].ID })
+	return defs
+}
+
+// InventoryStack es una casilla del inventario: un item y cuántas unidades hay.
+type InventoryStack struct {
+	ItemID   string `json:"item_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// AddItem agrega `quantity` unidades al inventario.
+// Primero completa los stacks existentes del mismo item y luego abre casillas nuevas.
+// Es TODO o NADA: si no entra todo, no se agrega nada (ErrInventoryFull).
+func (h *Hero) AddItem(itemID string, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	def, err := LookupItem(itemID)
+	if err != nil {
+		return err
+	}
+
+	inv, err := addStacks(h.Inventory, def, quantity)
+	if err != nil {
+		return err
+	}
+	h.Inventory = inv
+	return nil
+}
+
+// RemoveItem quita `quantity` unidades del inventario (vacía primero los últimos stacks).
+func (h *Hero) RemoveItem(itemID string, quantity int) error {
+	if quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	inv, err := removeStacks(h.Inventory, itemID, quantity)
+	if err != nil {
+		return err
+	}
+	h.Inventory = inv
+	return nil
+}
+
+// CountItem devuelve cuántas unidades de un item hay en el inventario.
+func (h *Hero) CountItem(itemID string) int {
+	total := 0
+	for _, s := range h.Inventory {
+		if s.ItemID == itemID {
+			total += s.Quantity
+		}
+	}
+	return total
+}
+
+// Equip mueve un item del inventario a su slot.
+// Si el slot estaba ocupado, el item anterior vuelve al inventario (swap).
+func (h *Hero) Equip(itemID string) error {
+	def, err := LookupItem(itemID)
+	if err != nil {
+		return err
+	}
+	if !def.Equippable() {
+		return fmt.Errorf("%w: %q", ErrItemNotEquippable, itemID)
+	}
+	if h.Level < def.MinLevel {
+		return fmt.Errorf("%w: %q requires level %d (hero is %d)", ErrItemLevelTooLow, itemID, def.MinLevel, h.Level)
+	}
+
+	// Trabajamos sobre una copia: si el swap no entra, el héroe queda intacto.
+	inv, err := removeStacks(h.Inventory, itemID, 1)
+	if err != nil {
+		return err
+	}
+	if prevID, ok := h.Equipment[def.Slot]; ok {
+		prev, err := LookupItem(prevID)
+		if err != nil {
+			return err
+		}
+		if inv, err = addStacks(inv, prev, 1); err != nil {
+			return err
+		}
+	}
+
+	if h.Equipment == nil {
+		h.Equipment = make(map[ItemSlot]string)
+	}
+	h.Inventory = inv
+	h.Equipment[def.Slot] = itemID
+	return nil
+}
+
+// Unequip devuelve al inventario el item equipado en `slot`.
+func (h *Hero) Unequip(slot ItemSlot) error {
+	itemID, ok := h.Equipment[slot]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrSlotEmpty, slot)
+	}
+	def, err := LookupItem(itemID)
+	if err != nil {
+		return err
+	}
+
+	inv, err := addStacks(h.Inventory, def, 1)
+	if err != nil {
+		return err
+	}
+	h.Inventory = inv
+	delete(h.Equipment, slot)
+	return nil
+}
+
+// EquipmentBonus suma atributos y poder de todo lo equipado.
+func (h *Hero) EquipmentBonus() (Attributes, int) {
+	var (
+		attrs Attributes
+		power int
+	)
+	for _, itemID := range h.Equipment {
+		def, err := LookupItem(itemID)
+		if err != nil {
+			continue // Item retirado del catálogo: no aporta nada
+		}
+		attrs = attrs.Add(def.Modifiers)
+		power += def.Power
+	}
+	return attrs, power
+}
+
+// addStacks devuelve un inventario NUEVO con `quantity` unidades de `def` agregadas.
+func addStacks(inv []InventoryStack, def ItemDefinition, quantity int) ([]InventoryStack, error) {
+	out := append([]InventoryStack{}, inv...)
+	maxStack := max(def.MaxStack, 1)
+
+	// 1. Completar stacks existentes
+	for i := range out {
+		if quantity == 0 {
+			break
+		}
+		if out[i].ItemID != def.ID || out[i].Quantity >= maxStack {
+			continue
+		}
+		n := min(maxStack-out[i].Quantity, quantity)
+		out[i].Quantity += n
+		quantity -= n
+	}
+
+	// 2. Abrir casillas nuevas para lo que sobre
+	for quantity > 0 {
+		if len(out) >= InventoryCapacity {
+			return nil, fmt.Errorf("%w: %d/%d slots used", ErrInventoryFull, len(inv), InventoryCapacity)
+		}
+		n := min(maxStack, quantity)
+		out = append(out, InventoryStack{ItemID: def.ID, Quantity: n})
+		quantity -= n
+	}
+	return out, nil
+}
+
+// removeStacks devuelve un inventario NUEVO sin `quantity` unidades de `itemID`.
+func removeStacks(inv []InventoryStack, itemID string, quantity int) ([]InventoryStack, error) {
+	have := 0
+	for _, s := range inv {
+		if s.ItemID == itemID {
+			have += s.Quantity
+		}
+	}
+	if have < quantity {
+		return nil, fmt.Errorf("%w: %q (have %d, need %d)", ErrItemNotInInventory, itemID, have, quantity)
+	}
+
+	out := append([]InventoryStack{}, inv...)
+	for i := len(out) - 1; i >= 0 && quantity > 0; i-- {
+		if out[i].ItemID != itemID {
+			continue
+		}
+		n := min(out[i].Quantity, quantity)
+		out[i].Quantity -= n
+		quantity -= n
+	}
+
+	// Compactar: quitar casillas vacías
+	kept := out[:0]
+	for _, s := range out {
+		if s.Quantity > 0 {
+			kept = append(kept, s)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,220 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func newItemHero(t *testing.T, level int) *Hero {
+	t.Helper()
+	hero, err := NewHero("h-1", "Arthas", ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	hero.Level = level
+	return hero
+}
+
+func mustItem(t *testing.T, id string) ItemDefinition {
+	t.Helper()
+	def, err := LookupItem(id)
+	if err != nil {
+		t.Fatalf("LookupItem(%q): %v", id, err)
+	}
+	return def
+}
+
+func TestAddStacks(t *testing.T) {
+	potion := mustItem(t, "health-potion") // MaxStack 20
+	tests := []struct {
+		name     string
+		inv      []InventoryStack
+		quantity int
+		want     []InventoryStack
+	}{
+		{"opens a stack", nil, 5, []InventoryStack{{"health-potion", 5}}},
+		{"fills the existing stack first", []InventoryStack{{"health-potion", 18}}, 5,
+			[]InventoryStack{{"health-potion", 20}, {"health-potion", 3}}},
+		{"skips full stacks and other items", []InventoryStack{{"health-potion", 20}, {"iron-ore", 7}, {"health-potion", 10}}, 12,
+			[]InventoryStack{{"health-potion", 20}, {"iron-ore", 7}, {"health-potion", 20}, {"health-potion", 2}}},
+		{"splits into several stacks", nil, 45,
+			[]InventoryStack{{"health-potion", 20}, {"health-potion", 20}, {"health-potion", 5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.inv)
+			got, err := addStacks(tt.inv, potion, tt.quantity)
+			if err != nil {
+				t.Fatalf("addStacks: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("addStacks = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.inv, before) {
+				t.Errorf("addStacks modified its input: %v, want %v", tt.inv, before)
+			}
+		})
+	}
+}
+
+func TestRemoveStacks(t *testing.T) {
+	tests := []struct {
+		name     string
+		inv      []InventoryStack
+		quantity int
+		want     []InventoryStack
+	}{
+		{"takes from the last stack", []InventoryStack{{"health-potion", 20}, {"health-potion", 5}}, 3,
+			[]InventoryStack{{"health-potion", 20}, {"health-potion", 2}}},
+		{"drops emptied stacks", []InventoryStack{{"health-potion", 20}, {"iron-ore", 7}, {"health-potion", 5}}, 10,
+			[]InventoryStack{{"health-potion", 15}, {"iron-ore", 7}}},
+		{"removes everything", []InventoryStack{{"health-potion", 20}, {"health-potion", 5}}, 25, []InventoryStack{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := slices.Clone(tt.inv)
+			got, err := removeStacks(tt.inv, "health-potion", tt.quantity)
+			if err != nil {
+				t.Fatalf("removeStacks: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("removeStacks = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(tt.inv, before) {
+				t.Errorf("removeStacks modified its input: %v, want %v", tt.inv, before)
+			}
+		})
+	}
+
+	if _, err := removeStacks([]InventoryStack{{"health-potion", 2}}, "health-potion", 3); !errors.Is(err, ErrItemNotInInventory) {
+		t.Errorf("removing more than owned: err = %v, want %v", err, ErrItemNotInInventory)
+	}
+}
+
+// El inventario tiene 20 casillas: lo que no entra entero no se agrega (todo o nada).
+func TestAddItemRespectsCapacity(t *testing.T) {
+	hero := newItemHero(t, 1)
+	if err := hero.AddItem("health-potion", 19*20); err != nil { // 19 casillas llenas
+		t.Fatalf("AddItem: %v", err)
+	}
+	if err := hero.AddItem("iron-ore", 1); err != nil { // La casilla 20
+		t.Fatalf("AddItem 20th stack: %v", err)
+	}
+	if len(hero.Inventory) != InventoryCapacity {
+		t.Fatalf("%d stacks, want %d", len(hero.Inventory), InventoryCapacity)
+	}
+
+	before := slices.Clone(hero.Inventory)
+	if err := hero.AddItem("rusty-sword", 1); !errors.Is(err, ErrInventoryFull) {
+		t.Errorf("21st stack: err = %v, want %v", err, ErrInventoryFull)
+	}
+	if err := hero.AddItem("iron-ore", 50); !errors.Is(err, ErrInventoryFull) { // 49 entran en el stack, 1 no
+		t.Errorf("overflowing stack: err = %v, want %v", err, ErrInventoryFull)
+	}
+	if !slices.Equal(hero.Inventory, before) {
+		t.Errorf("a rejected AddItem changed the inventory")
+	}
+
+	// Completar un stack existente no necesita casillas nuevas
+	if err := hero.AddItem("iron-ore", 49); err != nil {
+		t.Errorf("filling the existing stack: %v", err)
+	}
+}
+
+func TestEquipSwapsPreviousItem(t *testing.T) {
+	hero := newItemHero(t, 1)
+	for _, id := range []string{"rusty-sword", "oak-staff"} {
+		if err := hero.AddItem(id, 1); err != nil {
+			t.Fatalf("AddItem(%q): %v", id, err)
+		}
+	}
+
+	if err := hero.Equip("rusty-sword"); err != nil {
+		t.Fatalf("Equip rusty-sword: %v", err)
+	}
+	if err := hero.Equip("oak-staff"); err != nil {
+		t.Fatalf("Equip oak-staff: %v", err)
+	}
+
+	if got := hero.Equipment[SlotWeapon]; got != "oak-staff" {
+		t.Errorf("weapon = %q, want oak-staff", got)
+	}
+	if hero.CountItem("rusty-sword") != 1 || hero.CountItem("oak-staff") != 0 {
+		t.Errorf("inventory = %v, want the sword back and no staff", hero.Inventory)
+	}
+	attrs, power := hero.EquipmentBonus()
+	if power != mustItem(t, "oak-staff").Power || attrs != mustItem(t, "oak-staff").Modifiers {
+		t.Errorf("bonus = %+v power %d, want only the staff", attrs, power)
+	}
+}
+
+func TestEquipRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  int
+		itemID string
+		owned  bool
+		want   error
+	}{
+		{"below min level", 4, "chainmail", true, ErrItemLevelTooLow},
+		{"at min level", 5, "chainmail", true, nil},
+		{"not equippable", 10, "health-potion", true, ErrItemNotEquippable},
+		{"not in inventory", 10, "rusty-sword", false, ErrItemNotInInventory},
+		{"unknown item", 10, "excalibur", false, ErrItemNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hero := newItemHero(t, tt.level)
+			if tt.owned {
+				if err := hero.AddItem(tt.itemID, 1); err != nil {
+					t.Fatalf("AddItem: %v", err)
+				}
+			}
+			err := hero.Equip(tt.itemID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Equip(%q) at level %d = %v, want %v", tt.itemID, tt.level, err, tt.want)
+			}
+			if tt.want != nil && len(hero.Equipment) != 0 {
+				t.Errorf("a rejected Equip changed the equipment: %v", hero.Equipment)
+			}
+		})
+	}
+}
+
+func TestUnequip(t *testing.T) {
+	hero := newItemHero(t, 1)
+	if err := hero.AddItem("leather-cap", 1); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+	if err := hero.Equip("leather-cap"); err != nil {
+		t.Fatalf("Equip: %v", err)
+	}
+
+	if err := hero.Unequip(SlotHead); err != nil {
+		t.Fatalf("Unequip: %v", err)
+	}
+	if _, ok := hero.Equipment[SlotHead]; ok || hero.CountItem("leather-cap") != 1 {
+		t.Errorf("after Unequip: equipment %v, inventory %v", hero.Equipment, hero.Inventory)
+	}
+
+	if err := hero.Unequip(SlotHead); !errors.Is(err, ErrSlotEmpty) {
+		t.Errorf("Unequip empty slot = %v, want %v", err, ErrSlotEmpty)
+	}
+}
+
+// Sin lugar en el inventario, desequipar falla y el item sigue equipado.
+func TestUnequipFailsWhenInventoryIsFull(t *testing.T) {
+	hero := newItemHero(t, 1)
+	hero.Equipment = map[ItemSlot]string{SlotHead: "leather-cap"}
+	if err := hero.AddItem("health-potion", InventoryCapacity*20); err != nil {
+		t.Fatalf("AddItem: %v", err)
+	}
+
+	if err := hero.Unequip(SlotHead); !errors.Is(err, ErrInventoryFull) {
+		t.Fatalf("Unequip = %v, want %v", err, ErrInventoryFull)
+	}
+	if hero.Equipment[SlotHead] != "leather-cap" || hero.CountItem("leather-cap") != 0 {
+		t.Errorf("a failed Unequip changed the hero: %v / %v", hero.Equipment, hero.Inventory)
+	}
+}
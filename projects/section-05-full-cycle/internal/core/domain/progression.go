@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"math"
+)
+
+// Errores de progresión
+var (
+	ErrXPAmountInvalid = newError(ErrValidation, "xp amount must be greater than 0")
+	ErrXPOverflow      = newError(ErrValidation, "xp amount is too large")
+	ErrLevelCurve      = newError(ErrValidation, "invalid level curve")
+)
+
+// maxCurveXP es la mayor XP total que admite una curva: hasta 2^53 un float64
+// representa enteros exactos y la conversión a int no desborda.
+const maxCurveXP = 1 << 53
+
+// LevelCurve define cuánta XP cuesta cada nivel y cuánto poder da subirlo.
+//
+// 🎓 CURVA EXPONENCIAL (típica de RPGs):
+// XP para pasar del nivel L al L+1 = BaseXP * Growth^(L-1)
+// Con BaseXP=100 y Growth=1.5: 1->2 cuesta 100, 2->3 cuesta 150, 3->4 cuesta 225...
+type LevelCurve struct {
+	BaseXP             int     // XP para pasar de nivel 1 a 2
+	Growth             float64 // Multiplicador del costo por nivel (>= 1)
+	MaxLevel           int     // Nivel máximo alcanzable
+	PowerPerLevel      int     // Poder fijo ganado por nivel
+	PowerGrowth        float64 // Poder extra por nivel, como % del poder actual (0.05 = +5%)
+	StatPointsPerLevel int     // Puntos de atributo para repartir por nivel ganado
+}
+
+// DefaultLevelCurve devuelve la curva por defecto del juego.
+func DefaultLevelCurve() LevelCurve {
+	return LevelCurve{
+		BaseXP:             100,
+		Growth:             1.5,
+		MaxLevel:           50,
+		PowerPerLevel:      10,
+		PowerGrowth:        0.05,
+		StatPointsPerLevel: 5,
+	}
+}
+
+// Validate comprueba que la curva tenga sentido.
+// También rechaza curvas cuyo nivel máximo cueste más XP de la que entra en un int.
+func (c LevelCurve) Validate() error {
+	if c.BaseXP <= 0 || c.Growth < 1 || c.MaxLevel < 1 || c.PowerPerLevel < 0 || c.PowerGrowth < 0 || c.StatPointsPerLevel < 0 {
+		return ErrLevelCurve
+	}
+	if c.totalXP(c.MaxLevel) > maxCurveXP {
+		return ErrLevelCurve
+	}
+	return nil
+}
+
+// XPForLevel devuelve la XP TOTAL acumulada necesaria para alcanzar `level`.
+func (c LevelCurve) XPForLevel(level int) int {
+	return int(math.Round(c.totalXP(level)))
+}
+
+func (c LevelCurve) totalXP(level int) float64 {
+	total := 0.0
+	for l := 1; l < level; l++ {
+		total += float64(c.BaseXP) * math.Pow(c.Growth, float64(l-1))
+	}
+	return total
+}
+
+// PowerGain devuelve cuánto poder se gana al subir un nivel con `currentPower`.
+func (c LevelCurve) PowerGain(currentPower int) int {
+	return c.PowerPerLevel + int(math.Round(float64(currentPower)*c.PowerGrowth))
+}
+
+// GainXP suma experiencia y aplica TODAS las subidas de nivel que correspondan.
+// Devuelve una foto (copia) del héroe tras cada nivel ganado, en orden.
+func (h *Hero) GainXP(amount int, curve LevelCurve) ([]Hero, error) {
+	if amount <= 0 {
+		return nil, ErrXPAmountInvalid
+	}
+	if amount > math.MaxInt-h.XP {
+		return nil, ErrXPOverflow
+	}
+
+	h.XP += amount
+
+	var levels []Hero
+	for h.Level < curve.MaxLevel && h.XP >= curve.XPForLevel(h.Level+1) {
+		h.LevelUp(curve)
+		levels = append(levels, *h)
+	}
+	return levels, nil
+}
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestXPForLevel(t *testing.T) {
+	curve := DefaultLevelCurve() // 100, x1.5
+	tests := []struct {
+		level int
+		want  int
+	}{
+		{1, 0},
+		{2, 100},
+		{3, 250},
+		{4, 475},
+	}
+	for _, tt := range tests {
+		if got := curve.XPForLevel(tt.level); got != tt.want {
+			t.Errorf("XPForLevel(%d) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+// Una sola ganancia grande sube varios niveles y devuelve una foto por nivel.
+func TestGainXPMultipleLevels(t *testing.T) {
+	curve := DefaultLevelCurve()
+	hero := &Hero{ID: "h-1", Name: "Arthas", Level: 1, Power: 10}
+
+	levels, err := hero.GainXP(curve.XPForLevel(4), curve)
+	if err != nil {
+		t.Fatalf("GainXP: %v", err)
+	}
+
+	if hero.Level != 4 {
+		t.Errorf("Level = %d, want 4", hero.Level)
+	}
+	if len(levels) != 3 {
+		t.Fatalf("got %d level snapshots, want 3", len(levels))
+	}
+	power := 10
+	for i, snapshot := range levels {
+		power += curve.PowerGain(power)
+		if snapshot.Level != i+2 || snapshot.Power != power {
+			t.Errorf("snapshot %d = level %d power %d, want level %d power %d",
+				i, snapshot.Level, snapshot.Power, i+2, power)
+		}
+	}
+	if hero.Power != power {
+		t.Errorf("Power = %d, want %d", hero.Power, power)
+	}
+}
+
+func TestGainXPStopsAtMaxLevel(t *testing.T) {
+	curve := DefaultLevelCurve()
+	curve.MaxLevel = 3
+	hero := &Hero{ID: "h-1", Name: "Arthas", Level: 1, Power: 10}
+
+	levels, err := hero.GainXP(1_000_000, curve)
+	if err != nil {
+		t.Fatalf("GainXP: %v", err)
+	}
+	if hero.Level != 3 || len(levels) != 2 {
+		t.Errorf("Level = %d with %d snapshots, want 3 with 2", hero.Level, len(levels))
+	}
+
+	// Ya en el máximo: la XP se acumula pero no hay más niveles.
+	levels, err = hero.GainXP(500, curve)
+	if err != nil {
+		t.Fatalf("GainXP at max level: %v", err)
+	}
+	if hero.Level != 3 || len(levels) != 0 || hero.XP != 1_000_500 {
+		t.Errorf("at max: level %d, %d snapshots, xp %d; want 3, 0, 1000500", hero.Level, len(levels), hero.XP)
+	}
+}
+
+func TestGainXPRejectsInvalidAmounts(t *testing.T) {
+	curve := DefaultLevelCurve()
+
+	for _, amount := range []int{0, -1, math.MinInt} {
+		hero := &Hero{Level: 1, Power: 10}
+		if _, err := hero.GainXP(amount, curve); !errors.Is(err, ErrXPAmountInvalid) {
+			t.Errorf("GainXP(%d) error = %v, want ErrXPAmountInvalid", amount, err)
+		}
+	}
+
+	hero := &Hero{Level: curve.MaxLevel, Power: 10, XP: math.MaxInt - 10}
+	if _, err := hero.GainXP(11, curve); !errors.Is(err, ErrXPOverflow) {
+		t.Errorf("GainXP overflowing int: error = %v, want ErrXPOverflow", err)
+	}
+	if hero.XP != math.MaxInt-10 {
+		t.Errorf("XP changed on a rejected gain: %d", hero.XP)
+	}
+	if _, err := hero.GainXP(10, curve); err != nil {
+		t.Errorf("GainXP up to MaxInt: %v", err)
+	}
+}
+
+func TestLevelCurveValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*LevelCurve)
+		valid  bool
+	}{
+		{"default", func(*LevelCurve) {}, true},
+		{"flat growth", func(c *LevelCurve) { c.Growth = 1 }, true},
+		{"zero base xp", func(c *LevelCurve) { c.BaseXP = 0 }, false},
+		{"shrinking growth", func(c *LevelCurve) { c.Growth = 0.9 }, false},
+		{"zero max level", func(c *LevelCurve) { c.MaxLevel = 0 }, false},
+		{"negative power per level", func(c *LevelCurve) { c.PowerPerLevel = -1 }, false},
+		{"negative power growth", func(c *LevelCurve) { c.PowerGrowth = -0.1 }, false},
+		{"max level xp overflows int", func(c *LevelCurve) { c.Growth = 10; c.MaxLevel = 100 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curve := DefaultLevelCurve()
+			tt.modify(&curve)
+			err := curve.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrLevelCurve) {
+				t.Errorf("Validate() = %v, want ErrLevelCurve", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package ports
+
+import "context"
+
+// 🎓 PATRÓN: Idempotent Consumer
+// Kafka garantiza "at-least-once": tras un rebalance o un crash entre procesar
+// y hacer commit, el MISMO evento puede llegar dos veces.
+// Guardamos los IDs ya procesados para ignorar los duplicados.
+
+// ProcessedEventStore recuerda qué eventos (por ID) ya fueron procesados.
+type ProcessedEventStore interface {
+	// IsProcessed indica si el evento ya se procesó.
+	IsProcessed(ctx context.Context, eventID string) (bool, error)
+
+	// MarkProcessed registra el evento como procesado.
+	MarkProcessed(ctx context.Context, eventID string) error
+}
@@ -0,0 +1,46 @@
+package ports
+
+import "github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+
+// 🎓 PAGINACIÓN POR CURSOR (keyset pagination)
+// Con LIMIT/OFFSET, si alguien crea o borra un héroe entre página y página,
+// los resultados "se corren" (duplicados o saltos). El cursor, en cambio, recuerda
+// la CLAVE del último héroe entregado (valor de orden + ID) y la página siguiente
+// arranca justo después de esa clave, pase lo que pase con las filas anteriores.
+//
+// El cursor es OPACO para el cliente: solo lo devuelve tal cual pide la siguiente página.
+
+// HeroSortField es el campo por el que se ordena el listado.
+// El ID se usa siempre como desempate, así el orden es estable.
+type HeroSortField string
+
+const (
+	SortByCreatedAt HeroSortField = "created_at"
+	SortByName      HeroSortField = "name"
+	SortByLevel     HeroSortField = "level"
+	SortByPower     HeroSortField = "power"
+)
+
+// HeroSortFields son los campos de orden soportados.
+var HeroSortFields = []HeroSortField{SortByCreatedAt, SortByName, SortByLevel, SortByPower}
+
+// HeroQuery describe qué página de héroes se pide.
+type HeroQuery struct {
+	Limit  int    // Máximo de héroes por página (<= 0: sin límite)
+	Cursor string // Cursor devuelto por la página anterior ("" = primera página)
+
+	SortBy HeroSortField // "" = created_at
+	Desc   bool
+
+	// Filtros (valor cero = no filtrar)
+	MinLevel   int
+	NamePrefix string // Sin distinguir mayúsculas/minúsculas
+	Class      domain.HeroClass
+}
+
+// HeroPage es una página del listado.
+type HeroPage struct {
+	Heroes     []*domain.Hero
+	Total      int    // Héroes que cumplen los filtros (en TODAS las páginas)
+	NextCursor string // "" si es la última página
+}
@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// 🎓 PATRÓN: Transactional Outbox
+// El problema: "Guardar en DB" y "Publicar en Kafka" son DOS sistemas distintos.
+// Si el segundo falla, el evento se pierde para siempre.
+// La solución: el evento se guarda en la MISMA escritura que el héroe (tabla/slice "outbox"),
+// y un proceso aparte (Relay) lo drena hacia el EventBus con reintentos.
+
+// OutboxMessage es un evento pendiente de publicar más su estado de reintentos.
+type OutboxMessage struct {
+	Event       domain.Event // Sobre completo (ID, tipo, snapshot del héroe...)
+	Attempts    int          // Cuántas veces intentó publicarlo el Relay
+	NextAttempt time.Time    // No reintentar antes de este instante (backoff)
+	LastError   string
+}
+
+// Outbox es el lado de LECTURA del outbox, usado por el Relay.
+// La ESCRITURA ocurre dentro de HeroRepository (Save/Update/Delete) para que sea atómica.
+type Outbox interface {
+	// Pending devuelve hasta `limit` mensajes no enviados y listos para (re)intentar, en orden de creación.
+	// Nunca devuelve un mensaje si hay otro anterior sin enviar del mismo héroe (Subject):
+	// así el orden Created -> Updated -> Deleted se mantiene entre pasadas del Relay.
+	Pending(ctx context.Context, limit int) ([]OutboxMessage, error)
+
+	// MarkSent marca el mensaje (por ID de evento) como publicado.
+	MarkSent(ctx context.Context, id string) error
+
+	// MarkFailed registra un intento fallido y cuándo volver a intentarlo.
+	MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error
+}
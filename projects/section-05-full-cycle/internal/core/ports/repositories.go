@@ -1,27 +1,40 @@
 package ports
 
-import "github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+import (
+	"context"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
 
 // HeroRepository define las operaciones de persistencia (Guardar, Leer).
 // Es un Puerto "Driven" (Salida).
+//
+// Las operaciones de escritura reciben los eventos a registrar en el Outbox:
+// la implementación DEBE guardarlos en la misma transacción que el cambio del héroe.
+//
+// 🎓 CONCURRENCIA OPTIMISTA: Update y Delete reciben la versión que el llamador LEYÓ.
+// Si la versión guardada ya no coincide (otro escribió en el medio), no se escribe
+// nada y se devuelve domain.ErrVersionConflict. Nadie "pisa" cambios ajenos en silencio.
+//
+// Todas las operaciones reciben el context.Context de la petición: si el cliente
+// se desconecta o vence el deadline, la consulta se cancela.
 type HeroRepository interface {
 	// Save guarda un héroe.
 	// Recibe un puntero porque podría modificarlo (ej: agregar ID de base de datos),
 	// aunque en este caso solo lo leemos.
-	// Save guarda un héroe.
-	// Recibe un puntero porque podría modificarlo (ej: agregar ID de base de datos),
-	// aunque en este caso solo lo leemos.
-	Save(hero *domain.Hero) error
+	Save(ctx context.Context, hero *domain.Hero, events ...domain.Event) error
 
 	// Get busca un héroe por ID.
-	Get(id string) (*domain.Hero, error)
+	Get(ctx context.Context, id string) (*domain.Hero, error)
 
-	// Update actualiza un héroe existente.
-	Update(hero *domain.Hero) error
+	// Update guarda el héroe (con su nueva Version) solo si la versión guardada
+	// sigue siendo expectedVersion (compare-and-swap).
+	Update(ctx context.Context, hero *domain.Hero, expectedVersion int, events ...domain.Event) error
 
-	// Delete elimina un héroe por ID.
-	Delete(id string) error
+	// Delete elimina un héroe por ID si su versión sigue siendo expectedVersion.
+	Delete(ctx context.Context, id string, expectedVersion int, events ...domain.Event) error
 
-	// List retorna todos los héroes.
-	List() ([]*domain.Hero, error)
+	// List devuelve una página de héroes filtrada y ordenada según la consulta.
+	// Un cursor inválido (o de otro orden) devuelve domain.ErrInvalidCursor.
+	List(ctx context.Context, query HeroQuery) (HeroPage, error)
 }
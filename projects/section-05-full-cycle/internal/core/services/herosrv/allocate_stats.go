@@ -0,0 +1,46 @@
+package herosrv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// AllocateStatsCommand: DTO para repartir puntos de atributo.
+type AllocateStatsCommand struct {
+	HeroID string
+	Points domain.Attributes
+}
+
+// AllocateStats reparte los puntos sin asignar del héroe entre sus atributos.
+func (s *Service) AllocateStats(ctx context.Context, cmd AllocateStatsCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "asignando puntos", "hero_id", cmd.HeroID, "points", cmd.Points.Total())
+
+	// 1. Obtener héroe existente
+	hero, err := s.repo.Get(ctx, cmd.HeroID)
+	if err != nil {
+		s.publishFailure(ctx, domain.EventHeroStatsAllocateFailed, &domain.Hero{ID: cmd.HeroID})
+		return nil, fmt.Errorf("error obteniendo hero: %w", err)
+	}
+
+	// 2. Lógica de dominio (validaciones de puntos y topes)
+	if err := hero.AllocateStats(cmd.Points); err != nil {
+		s.publishFailure(ctx, domain.EventHeroStatsAllocateFailed, hero)
+		return nil, fmt.Errorf("error asignando atributos: %w", err)
+	}
+
+	// 3. Persistir cambios (+ evento en el outbox, atómico)
+	err = s.update(ctx, hero, func() []domain.Event {
+		return []domain.Event{newEvent(ctx, domain.EventHeroStatsAllocated, hero)}
+	})
+	if err != nil {
+		s.publishFailure(ctx, domain.EventHeroStatsAllocateFailed, hero)
+		return nil, fmt.Errorf("error actualizando en DB: %w", err)
+	}
+
+	s.log.InfoContext(ctx, "puntos asignados", "hero_id", hero.ID, "attributes", hero.Attributes,
+		"stat_points", hero.StatPoints, "event", domain.EventHeroStatsAllocated)
+
+	return hero, nil
+}
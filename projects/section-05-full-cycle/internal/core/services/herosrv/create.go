@@ -1,6 +1,7 @@
 package herosrv
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
@@ -10,40 +11,38 @@ import (
 // CreateHeroCommand: DTO (Data Transfer Object).
 type CreateHeroCommand struct {
 	Name  string
-	Power int
+	Class string // warrior | mage | rogue | cleric (define atributos y poder iniciales). Vacío = domain.DefaultClass
 }
 
 // Create ejecuta la lógica de creación de un héroe.
 // Renombrado de Run a Create para mayor claridad.
-func (s *Service) Create(cmd CreateHeroCommand) (*domain.Hero, error) {
-	fmt.Printf("➡️  CORE (Service): Orquestando creación de %s\n", cmd.Name)
+func (s *Service) Create(ctx context.Context, cmd CreateHeroCommand) (*domain.Hero, error) {
+	class := domain.HeroClass(cmd.Class)
+	if class == "" {
+		class = domain.DefaultClass // La clase es opcional en la API
+	}
+	s.log.DebugContext(ctx, "creando héroe", "name", cmd.Name, "class", class)
 
 	// 1. Generar ID único
 	heroID := uuid.New().String()
 
 	// 2. Llamar al Dominio (Factory)
-	hero, err := domain.NewHero(heroID, cmd.Name)
+	hero, err := domain.NewHero(heroID, cmd.Name, class)
 	if err != nil {
 		// Publicar evento de fallo
-		s.eventBus.Publish(&domain.Hero{ID: heroID, Name: cmd.Name}, "HeroCreateFailed")
+		s.publishFailure(ctx, domain.EventHeroCreateFailed, &domain.Hero{ID: heroID, Name: cmd.Name})
 		return nil, fmt.Errorf("error creando hero: %w", err)
 	}
 
-	// 3. Persistencia (Base de Datos)
-	if err := s.repo.Save(hero); err != nil {
+	// 3. Persistencia (Base de Datos + Outbox en la misma escritura)
+	if err := s.repo.Save(ctx, hero, newEvent(ctx, domain.EventHeroCreated, hero)); err != nil {
 		// Publicar evento de fallo
-		s.eventBus.Publish(hero, "HeroCreateFailed")
+		s.publishFailure(ctx, domain.EventHeroCreateFailed, hero)
 		return nil, fmt.Errorf("error guardando en DB: %w", err)
 	}
 
-	fmt.Printf("✅ CORE: Hero %s guardado en la base de datos.\n", hero.Name)
-
-	// 4. Publicar evento de éxito
-	if err := s.eventBus.Publish(hero, "HeroCreated"); err != nil {
-		fmt.Printf("⚠️ WARN: Hero guardado en DB, pero falló publicación del evento: %v\n", err)
-	} else {
-		fmt.Printf("📨 CORE: Evento 'HeroCreated' publicado correctamente.\n")
-	}
+	// 4. El evento de éxito ya quedó en el outbox: el Relay lo publicará (con reintentos).
+	s.log.InfoContext(ctx, "héroe creado", "hero_id", hero.ID, "name", hero.Name, "event", domain.EventHeroCreated)
 
 	return hero, nil
 }
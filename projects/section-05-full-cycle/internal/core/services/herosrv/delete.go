@@ -1,34 +1,39 @@
 package herosrv
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
 
 // Delete elimina un héroe por ID.
-func (s *Service) Delete(id string) error {
-	fmt.Printf("➡️  CORE (Service): Eliminando héroe %s\n", id)
+// expectedVersion es la versión que vio el cliente (If-Match); 0 = no verificar.
+func (s *Service) Delete(ctx context.Context, id string, expectedVersion int) error {
+	s.log.DebugContext(ctx, "eliminando héroe", "hero_id", id)
 
 	// 1. Verificar que existe
-	hero, err := s.repo.Get(id)
+	hero, err := s.repo.Get(ctx, id)
 	if err != nil {
-		// Publicar evento de fallo
-		s.eventBus.Publish(hero, "HeroDeleteFailed")
+		// Publicar evento de fallo (solo conocemos el ID pedido)
+		s.publishFailure(ctx, domain.EventHeroDeleteFailed, &domain.Hero{ID: id})
 		return fmt.Errorf("error obteniendo hero: %w", err)
 	}
 
-	// 2. Eliminar de DB
-	if err := s.repo.Delete(id); err != nil {
+	if expectedVersion != 0 && expectedVersion != hero.Version {
+		s.publishFailure(ctx, domain.EventHeroDeleteFailed, hero)
+		return fmt.Errorf("%w: hero %s expected version %d, current %d", domain.ErrVersionConflict, id, expectedVersion, hero.Version)
+	}
+
+	// 2. Eliminar de DB (+ evento en el outbox, atómico)
+	// Siempre con la versión leída: si cambió desde el Get, el evento estaría desactualizado.
+	if err := s.repo.Delete(ctx, id, hero.Version, newEvent(ctx, domain.EventHeroDeleted, hero)); err != nil {
 		// Publicar evento de fallo
-		s.eventBus.Publish(hero, "HeroDeleteFailed")
+		s.publishFailure(ctx, domain.EventHeroDeleteFailed, hero)
 		return fmt.Errorf("error eliminando en DB: %w", err)
 	}
 
-	fmt.Printf("✅ CORE: Hero %s eliminado de la base de datos.\n", id)
-
-	// 3. Publicar evento de éxito
-	if err := s.eventBus.Publish(hero, "HeroDeleted"); err != nil {
-		fmt.Printf("⚠️ WARN: Hero eliminado en DB, pero falló publicación del evento: %v\n", err)
-	} else {
-		fmt.Printf("📨 CORE: Evento 'HeroDeleted' publicado correctamente.\n")
-	}
+	s.log.InfoContext(ctx, "héroe eliminado", "hero_id", id, "event", domain.EventHeroDeleted)
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package herosrv
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
@@ -8,7 +9,11 @@ import (
 
 // Get recupera un héroe.
 // Renombrado de GetHero a Get.
-func (s *Service) Get(id string) (*domain.Hero, error) {
-	fmt.Printf("➡️  CORE (Service): Buscando héroe %s\n", id)
-	return s.repo.Get(id)
+func (s *Service) Get(ctx context.Context, id string) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "buscando héroe", "hero_id", id)
+	hero, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error obteniendo hero: %w", err)
+	}
+	return hero, nil
 }
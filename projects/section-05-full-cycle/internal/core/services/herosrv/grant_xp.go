@@ -0,0 +1,69 @@
+package herosrv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// GrantXPCommand: DTO para otorgar experiencia.
+type GrantXPCommand struct {
+	HeroID string
+	Amount int
+}
+
+// GrantXPResult: resultado de otorgar experiencia.
+type GrantXPResult struct {
+	Hero         *domain.Hero
+	LevelsGained int
+}
+
+// GrantXP suma experiencia a un héroe y aplica las subidas de nivel.
+// Emite HeroXPGained y un HeroLeveledUp por CADA nivel ganado (con la foto del héroe en ese nivel).
+func (s *Service) GrantXP(ctx context.Context, cmd GrantXPCommand) (*GrantXPResult, error) {
+	s.log.DebugContext(ctx, "otorgando XP", "hero_id", cmd.HeroID, "amount", cmd.Amount)
+
+	// 1. Obtener héroe existente
+	hero, err := s.repo.Get(ctx, cmd.HeroID)
+	if err != nil {
+		s.publishFailure(ctx, domain.EventHeroXPGrantFailed, &domain.Hero{ID: cmd.HeroID})
+		return nil, fmt.Errorf("error obteniendo hero: %w", err)
+	}
+
+	// 2. Lógica de dominio (XP + niveles)
+	levels, err := hero.GainXP(cmd.Amount, s.curve)
+	if err != nil {
+		s.publishFailure(ctx, domain.EventHeroXPGrantFailed, hero)
+		return nil, fmt.Errorf("error otorgando xp: %w", err)
+	}
+
+	// 3. Persistir cambios + eventos (outbox, atómico)
+	var events []domain.Event
+	err = s.update(ctx, hero, func() []domain.Event {
+		events = make([]domain.Event, 0, len(levels)+1)
+		events = append(events, withChange(newEvent(ctx, domain.EventHeroXPGained, hero), domain.EventChange{Amount: cmd.Amount}))
+		for i := range levels {
+			levels[i].Version = hero.Version // Las fotos intermedias se guardan en esta misma escritura
+			events = append(events, newEvent(ctx, domain.EventHeroLeveledUp, &levels[i]))
+		}
+		return events
+	})
+	if err != nil {
+		s.publishFailure(ctx, domain.EventHeroXPGrantFailed, hero)
+		return nil, fmt.Errorf("error actualizando en DB: %w", err)
+	}
+
+	s.log.InfoContext(ctx, "XP otorgada", "hero_id", hero.ID, "amount", cmd.Amount, "levels_gained", len(levels),
+		"level", hero.Level, "power", hero.Power, "events", len(events))
+
+	return &GrantXPResult{Hero: hero, LevelsGained: len(levels)}, nil
+}
+
+// XPForNextLevel devuelve la XP total necesaria para el siguiente nivel (0 si ya está al máximo).
+func (s *Service) XPForNextLevel(hero *domain.Hero) int {
+	if hero.Level >= s.curve.MaxLevel {
+		return 0
+	}
+	return s.curve.XPForLevel(hero.Level + 1)
+}
@@ -0,0 +1,123 @@
+package herosrv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// recordingRepo guarda los eventos que el servicio escribe junto con cada Update.
+type recordingRepo struct {
+	ports.HeroRepository
+	events []domain.Event
+}
+
+func (r *recordingRepo) Update(ctx context.Context, hero *domain.Hero, expectedVersion int, events ...domain.Event) error {
+	r.events = append(r.events, events...)
+	return r.HeroRepository.Update(ctx, hero, expectedVersion, events...)
+}
+
+type nopBus struct{}
+
+func (nopBus) Publish(context.Context, domain.Event) error { return nil }
+
+func newXPService(t *testing.T, curve domain.LevelCurve) (*herosrv.Service, *recordingRepo) {
+	t.Helper()
+	repo := &recordingRepo{HeroRepository: herorepo.NewMemory(discardLogger())}
+	hero, err := domain.NewHero("h-1", "Arthas", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	if err := repo.Save(context.Background(), hero); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return herosrv.New(repo, nopBus{}, curve, discardLogger()), repo
+}
+
+// Subir 3 niveles de una vez emite HeroXPGained (con la XP otorgada) y UN HeroLeveledUp por nivel.
+func TestGrantXPEmitsOneLeveledUpPerLevel(t *testing.T) {
+	ctx := context.Background()
+	curve := domain.DefaultLevelCurve()
+	service, repo := newXPService(t, curve)
+
+	result, err := service.GrantXP(ctx, herosrv.GrantXPCommand{HeroID: "h-1", Amount: curve.XPForLevel(4)})
+	if err != nil {
+		t.Fatalf("GrantXP: %v", err)
+	}
+	if result.LevelsGained != 3 || result.Hero.Level != 4 {
+		t.Errorf("gained %d levels to level %d, want 3 to level 4", result.LevelsGained, result.Hero.Level)
+	}
+
+	if len(repo.events) != 4 {
+		t.Fatalf("got %d events, want 4 (XPGained + 3 LeveledUp)", len(repo.events))
+	}
+	gained := repo.events[0]
+	if gained.Type != domain.EventHeroXPGained {
+		t.Errorf("first event = %s, want %s", gained.Type, domain.EventHeroXPGained)
+	}
+	if gained.Data.Change == nil || gained.Data.Change.Amount != curve.XPForLevel(4) {
+		t.Errorf("HeroXPGained change = %+v, want amount %d", gained.Data.Change, curve.XPForLevel(4))
+	}
+	for i, event := range repo.events[1:] {
+		if event.Type != domain.EventHeroLeveledUp {
+			t.Errorf("event %d = %s, want %s", i+1, event.Type, domain.EventHeroLeveledUp)
+			continue
+		}
+		if event.Data.Level != i+2 {
+			t.Errorf("HeroLeveledUp %d has level %d, want %d", i, event.Data.Level, i+2)
+		}
+		if event.Data.Change != nil {
+			t.Errorf("HeroLeveledUp %d has a change: %+v", i, event.Data.Change)
+		}
+	}
+
+	if next := service.XPForNextLevel(result.Hero); next != curve.XPForLevel(5) {
+		t.Errorf("XPForNextLevel = %d, want %d", next, curve.XPForLevel(5))
+	}
+}
+
+func TestGrantXPStopsAtMaxLevel(t *testing.T) {
+	ctx := context.Background()
+	curve := domain.DefaultLevelCurve()
+	curve.MaxLevel = 3
+	service, repo := newXPService(t, curve)
+
+	result, err := service.GrantXP(ctx, herosrv.GrantXPCommand{HeroID: "h-1", Amount: 1_000_000})
+	if err != nil {
+		t.Fatalf("GrantXP: %v", err)
+	}
+	if result.LevelsGained != 2 || result.Hero.Level != 3 {
+		t.Errorf("gained %d levels to level %d, want 2 to level 3", result.LevelsGained, result.Hero.Level)
+	}
+	if len(repo.events) != 3 {
+		t.Errorf("got %d events, want 3 (XPGained + 2 LeveledUp)", len(repo.events))
+	}
+	if next := service.XPForNextLevel(result.Hero); next != 0 {
+		t.Errorf("XPForNextLevel at max level = %d, want 0", next)
+	}
+}
+
+func TestGrantXPRejectsInvalidAmount(t *testing.T) {
+	ctx := context.Background()
+	service, repo := newXPService(t, domain.DefaultLevelCurve())
+
+	_, err := service.GrantXP(ctx, herosrv.GrantXPCommand{HeroID: "h-1", Amount: 0})
+	if !errors.Is(err, domain.ErrXPAmountInvalid) {
+		t.Errorf("GrantXP(0) error = %v, want ErrXPAmountInvalid", err)
+	}
+	if len(repo.events) != 0 {
+		t.Errorf("rejected grant wrote %d events", len(repo.events))
+	}
+}
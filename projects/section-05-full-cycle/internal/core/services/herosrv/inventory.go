@@ -0,0 +1,118 @@
+package herosrv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// AddItemCommand: DTO para agregar items al inventario.
+type AddItemCommand struct {
+	HeroID   string
+	ItemID   string
+	Quantity int
+}
+
+// RemoveItemCommand: DTO para quitar items del inventario.
+type RemoveItemCommand struct {
+	HeroID   string
+	ItemID   string
+	Quantity int
+}
+
+// EquipItemCommand: DTO para equipar un item del inventario.
+type EquipItemCommand struct {
+	HeroID string
+	ItemID string
+}
+
+// UnequipItemCommand: DTO para desequipar un slot.
+type UnequipItemCommand struct {
+	HeroID string
+	Slot   string // weapon | head | chest | legs | accessory
+}
+
+// AddItem agrega items al inventario del héroe (apilando si se puede).
+func (s *Service) AddItem(ctx context.Context, cmd AddItemCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "agregando item", "hero_id", cmd.HeroID, "item_id", cmd.ItemID, "quantity", cmd.Quantity)
+
+	return s.changeInventory(ctx, cmd.HeroID, domain.EventItemAdded, domain.EventItemAddFailed, func(hero *domain.Hero) (domain.EventChange, error) {
+		return itemChange(cmd.ItemID, cmd.Quantity), hero.AddItem(cmd.ItemID, cmd.Quantity)
+	})
+}
+
+// RemoveItem quita items del inventario del héroe.
+func (s *Service) RemoveItem(ctx context.Context, cmd RemoveItemCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "quitando item", "hero_id", cmd.HeroID, "item_id", cmd.ItemID, "quantity", cmd.Quantity)
+
+	return s.changeInventory(ctx, cmd.HeroID, domain.EventItemRemoved, domain.EventItemRemoveFailed, func(hero *domain.Hero) (domain.EventChange, error) {
+		return itemChange(cmd.ItemID, cmd.Quantity), hero.RemoveItem(cmd.ItemID, cmd.Quantity)
+	})
+}
+
+// EquipItem equipa un item (el poder y las stats derivadas se recalculan solas).
+func (s *Service) EquipItem(ctx context.Context, cmd EquipItemCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "equipando item", "hero_id", cmd.HeroID, "item_id", cmd.ItemID)
+
+	return s.changeInventory(ctx, cmd.HeroID, domain.EventItemEquipped, domain.EventItemEquipFailed, func(hero *domain.Hero) (domain.EventChange, error) {
+		return itemChange(cmd.ItemID, 1), hero.Equip(cmd.ItemID)
+	})
+}
+
+// UnequipItem devuelve al inventario el item de un slot.
+func (s *Service) UnequipItem(ctx context.Context, cmd UnequipItemCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "desequipando slot", "hero_id", cmd.HeroID, "slot", cmd.Slot)
+
+	return s.changeInventory(ctx, cmd.HeroID, domain.EventItemUnequipped, domain.EventItemUnequipFailed, func(hero *domain.Hero) (domain.EventChange, error) {
+		slot, err := domain.ParseSlot(cmd.Slot)
+		if err != nil {
+			return domain.EventChange{}, err
+		}
+		itemID := hero.Equipment[slot] // Hay que leerlo ANTES: Unequip vacía el slot
+		return itemChange(itemID, 1), hero.Unequip(slot)
+	})
+}
+
+// changeInventory es el flujo común de las operaciones de inventario:
+// obtener héroe -> aplicar la regla de dominio -> persistir + evento (outbox).
+// 💡 DRY: las 4 operaciones solo difieren en la regla y en los eventos.
+// `change` aplica la regla y describe el cambio (va en el evento de éxito).
+func (s *Service) changeInventory(ctx context.Context, heroID string, okEvent, failEvent domain.EventType, change func(*domain.Hero) (domain.EventChange, error)) (*domain.Hero, error) {
+	// 1. Obtener héroe existente
+	hero, err := s.repo.Get(ctx, heroID)
+	if err != nil {
+		s.publishFailure(ctx, failEvent, &domain.Hero{ID: heroID})
+		return nil, fmt.Errorf("error obteniendo hero: %w", err)
+	}
+
+	// 2. Lógica de dominio (capacidad, stacks, slots, nivel mínimo...)
+	changed, err := change(hero)
+	if err != nil {
+		s.publishFailure(ctx, failEvent, hero)
+		return nil, fmt.Errorf("error modificando inventario: %w", err)
+	}
+
+	// 3. Persistir cambios (+ evento en el outbox, atómico)
+	err = s.update(ctx, hero, func() []domain.Event {
+		return []domain.Event{withChange(newEvent(ctx, okEvent, hero), changed)}
+	})
+	if err != nil {
+		s.publishFailure(ctx, failEvent, hero)
+		return nil, fmt.Errorf("error actualizando en DB: %w", err)
+	}
+
+	s.log.InfoContext(ctx, "inventario actualizado", "hero_id", hero.ID, "slots_used", len(hero.Inventory),
+		"power", hero.Stats().Power, "event", okEvent)
+
+	return hero, nil
+}
+
+// itemChange describe un cambio de inventario para el evento (el slot sale del catálogo).
+func itemChange(itemID string, quantity int) domain.EventChange {
+	change := domain.EventChange{ItemID: itemID, Quantity: quantity}
+	if def, err := domain.LookupItem(itemID); err == nil {
+		change.Slot = def.Slot
+	}
+	return change
+}
@@ -1,13 +1,80 @@
 package herosrv
 
 import (
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
 )
 
-// List retorna todos los héroes.
-func (s *Service) List() ([]*domain.Hero, error) {
-	fmt.Println("➡️  CORE (Service): Listando todos los héroes")
-	return s.repo.List()
+// Tamaño de página del listado.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ListHeroesQuery: DTO para el listado (tal como llega del adaptador).
+type ListHeroesQuery struct {
+	Limit      int    // 0 = DefaultPageSize
+	Cursor     string // Cursor de la página anterior
+	Sort       string // created_at | name | level | power; con "-" delante es descendente (ej: "-level")
+	MinLevel   int
+	NamePrefix string
+	Class      string
+}
+
+// List retorna una página de héroes.
+func (s *Service) List(ctx context.Context, q ListHeroesQuery) (ports.HeroPage, error) {
+	s.log.DebugContext(ctx, "listando héroes", "query", q)
+
+	query, err := q.toPortQuery()
+	if err != nil {
+		return ports.HeroPage{}, err
+	}
+
+	page, err := s.repo.List(ctx, query)
+	if err != nil {
+		return ports.HeroPage{}, fmt.Errorf("error listando heroes: %w", err)
+	}
+	return page, nil
+}
+
+// toPortQuery valida el DTO y aplica los valores por defecto.
+func (q ListHeroesQuery) toPortQuery() (ports.HeroQuery, error) {
+	query := ports.HeroQuery{
+		Limit:      q.Limit,
+		Cursor:     q.Cursor,
+		MinLevel:   q.MinLevel,
+		NamePrefix: q.NamePrefix,
+		Class:      domain.HeroClass(q.Class),
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxPageSize {
+		return ports.HeroQuery{}, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidQuery, MaxPageSize)
+	}
+	if query.MinLevel < 0 {
+		return ports.HeroQuery{}, fmt.Errorf("%w: min_level cannot be negative", domain.ErrInvalidQuery)
+	}
+	if query.Class != "" {
+		if _, err := domain.LookupClass(query.Class); err != nil {
+			return ports.HeroQuery{}, fmt.Errorf("%w: %w", domain.ErrInvalidQuery, err)
+		}
+	}
+
+	sort, desc := strings.CutPrefix(q.Sort, "-")
+	query.SortBy, query.Desc = ports.HeroSortField(sort), desc
+	if query.SortBy == "" {
+		query.SortBy = ports.SortByCreatedAt
+	}
+	if !slices.Contains(ports.HeroSortFields, query.SortBy) {
+		return ports.HeroQuery{}, fmt.Errorf("%w: unknown sort field %q (use %v)", domain.ErrInvalidQuery, sort, ports.HeroSortFields)
+	}
+
+	return query, nil
 }
@@ -1,7 +1,12 @@
 package herosrv
 
 import (
+	"context"
+	"log/slog"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/google/uuid"
 )
 
 // Service es nuestro "Caso de Uso" o "Handler".
@@ -10,18 +15,66 @@ import (
 // 💡 SOLID (SRP - Single Responsibility Principle):
 // Este servicio tiene UNA responsabilidad: "Orquestar la creación de un héroe".
 // No sabe CÓMO se guarda (Repo) ni CÓMO se notifica (EventBus).
+//
+// 📮 OUTBOX: Los eventos de ÉXITO viajan junto con la escritura en el repositorio
+// (ver outboxsrv.Relay). El eventBus directo solo se usa para eventos de FALLO,
+// que no tienen ninguna escritura con la que ser atómicos (best-effort).
 type Service struct {
 	repo     ports.HeroRepository
 	eventBus ports.EventBus
+	curve    domain.LevelCurve // Reglas de progresión (XP -> nivel)
+	log      *slog.Logger
 }
 
 // New crea una instancia del servicio.
 // 💡 SOLID (DIP - Dependency Inversion Principle):
 // Dependemos de ABSTRACCIONES (Interfaces ports.HeroRepository, ports.EventBus),
 // no de concreciones (structs Kafka o Memory).
-func New(repo ports.HeroRepository, eventBus ports.EventBus) *Service {
+func New(repo ports.HeroRepository, eventBus ports.EventBus, curve domain.LevelCurve, logger *slog.Logger) *Service {
 	return &Service{
 		repo:     repo,
 		eventBus: eventBus,
+		curve:    curve,
+		log:      logger.With("component", "herosrv"),
+	}
+}
+
+// newEvent crea el sobre del evento con un ID único.
+// Los valores de la petición (correlación, actor) viajan en el ctx hasta el sobre.
+func newEvent(ctx context.Context, eventType domain.EventType, hero *domain.Hero) domain.Event {
+	event := domain.NewHeroEvent(uuid.New().String(), eventType, hero)
+	if id := domain.CorrelationIDFrom(ctx); id != "" {
+		event.CorrelationID = id
+	}
+	event.Actor = domain.ActorFrom(ctx)
+	event.RequestID = domain.RequestIDFrom(ctx) // Sobrevive al outbox: el Relay publica con otro ctx
+	return event
+}
+
+// withChange agrega al evento el detalle de QUÉ cambió (además de la foto del héroe).
+func withChange(event domain.Event, change domain.EventChange) domain.Event {
+	if event.Data != nil {
+		event.Data.Change = &change
+	}
+	return event
+}
+
+// publishFailure publica un evento de fallo (best-effort, fuera del outbox).
+func (s *Service) publishFailure(ctx context.Context, eventType domain.EventType, hero *domain.Hero) {
+	if err := s.eventBus.Publish(ctx, newEvent(ctx, eventType, hero)); err != nil {
+		s.log.WarnContext(ctx, "no se pudo publicar el evento de fallo", "event", eventType, "error", err)
+	}
+}
+
+// update persiste un héroe modificado con concurrencia optimista.
+// Sube la versión ANTES de armar los eventos (así el snapshot ya lleva la versión nueva)
+// y le pide al repo que solo escriba si nadie cambió el héroe desde que lo leímos.
+func (s *Service) update(ctx context.Context, hero *domain.Hero, events func() []domain.Event) error {
+	expected := hero.Version
+	hero.Version++
+	if err := s.repo.Update(ctx, hero, expected, events()...); err != nil {
+		hero.Version = expected
+		return err
 	}
+	return nil
 }
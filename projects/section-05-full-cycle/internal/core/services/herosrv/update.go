@@ -1,47 +1,53 @@
 package herosrv
 
 import (
+	"context"
 	"fmt"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
 )
 
 // UpdateHeroCommand: DTO para actualización.
 type UpdateHeroCommand struct {
-	ID   string
-	Name string
+	ID              string
+	Name            string
+	ExpectedVersion int // Versión que vio el cliente (If-Match). 0 = no verificar.
 }
 
-// Update actualiza un héroe existente.
-func (s *Service) Update(cmd UpdateHeroCommand) error {
-	fmt.Printf("➡️  CORE (Service): Actualizando héroe %s\n", cmd.ID)
+// Update actualiza un héroe existente y devuelve el héroe con su nueva versión.
+func (s *Service) Update(ctx context.Context, cmd UpdateHeroCommand) (*domain.Hero, error) {
+	s.log.DebugContext(ctx, "actualizando héroe", "hero_id", cmd.ID)
 
 	// 1. Obtener héroe existente
-	hero, err := s.repo.Get(cmd.ID)
+	hero, err := s.repo.Get(ctx, cmd.ID)
 	if err != nil {
-		// Publicar evento de fallo
-		s.eventBus.Publish(hero, "HeroUpdateFailed")
-		return fmt.Errorf("error obteniendo hero: %w", err)
+		// Publicar evento de fallo (solo conocemos el ID pedido)
+		s.publishFailure(ctx, domain.EventHeroUpdateFailed, &domain.Hero{ID: cmd.ID})
+		return nil, fmt.Errorf("error obteniendo hero: %w", err)
+	}
+
+	// 2. ¿El cliente editó la versión actual? (si no, está pisando cambios que no vio)
+	if cmd.ExpectedVersion != 0 && cmd.ExpectedVersion != hero.Version {
+		s.publishFailure(ctx, domain.EventHeroUpdateFailed, hero)
+		return nil, fmt.Errorf("%w: hero %s expected version %d, current %d", domain.ErrVersionConflict, hero.ID, cmd.ExpectedVersion, hero.Version)
 	}
 
-	// 2. Actualizar campos
+	// 3. Actualizar campos
 	if cmd.Name != "" {
 		hero.Name = cmd.Name
 	}
 
-	// 3. Persistir cambios
-	if err := s.repo.Update(hero); err != nil {
+	// 4. Persistir cambios (+ evento en el outbox, atómico; falla si hubo otra escritura)
+	err = s.update(ctx, hero, func() []domain.Event {
+		return []domain.Event{newEvent(ctx, domain.EventHeroUpdated, hero)}
+	})
+	if err != nil {
 		// Publicar evento de fallo
-		s.eventBus.Publish(hero, "HeroUpdateFailed")
-		return fmt.Errorf("error actualizando en DB: %w", err)
+		s.publishFailure(ctx, domain.EventHeroUpdateFailed, hero)
+		return nil, fmt.Errorf("error actualizando en DB: %w", err)
 	}
 
-	fmt.Printf("✅ CORE: Hero %s actualizado en la base de datos.\n", hero.Name)
-
-	// 4. Publicar evento de éxito
-	if err := s.eventBus.Publish(hero, "HeroUpdated"); err != nil {
-		fmt.Printf("⚠️ WARN: Hero actualizado en DB, pero falló publicación del evento: %v\n", err)
-	} else {
-		fmt.Printf("📨 CORE: Evento 'HeroUpdated' publicado correctamente.\n")
-	}
+	s.log.InfoContext(ctx, "héroe actualizado", "hero_id", hero.ID, "version", hero.Version, "event", domain.EventHeroUpdated)
 
-	return nil
+	return hero, nil
 }
@@ -0,0 +1,162 @@
+package outboxsrv
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+)
+
+// Config agrupa los parámetros del Relay.
+type Config struct {
+	PollInterval   time.Duration // Cada cuánto revisar el outbox
+	BatchSize      int           // Máximo de mensajes por pasada
+	BaseBackoff    time.Duration // Espera tras el primer fallo (se duplica en cada intento)
+	MaxBackoff     time.Duration // Tope de la espera entre reintentos
+	PublishTimeout time.Duration // Máximo por publicación (que un broker colgado no frene el loop)
+}
+
+// DefaultConfig devuelve valores razonables para desarrollo local.
+func DefaultConfig() Config {
+	return Config{
+		PollInterval:   500 * time.Millisecond,
+		BatchSize:      100,
+		BaseBackoff:    1 * time.Second,
+		MaxBackoff:     1 * time.Minute,
+		PublishTimeout: 10 * time.Second,
+	}
+}
+
+// Relay drena el Outbox hacia el EventBus.
+//
+// 🎓 PATRÓN: Transactional Outbox (lado de lectura)
+// El Service solo ESCRIBE en el outbox (atómico con la DB).
+// El Relay es el único que habla con Kafka: si Kafka está caído, el mensaje
+// sigue en el outbox y se reintenta con backoff exponencial. Garantía: at-least-once.
+type Relay struct {
+	outbox   ports.Outbox
+	eventBus ports.EventBus
+	cfg      Config
+	log      *slog.Logger
+}
+
+// New crea el Relay.
+func New(outbox ports.Outbox, eventBus ports.EventBus, cfg Config, logger *slog.Logger) *Relay {
+	return &Relay{
+		outbox:   outbox,
+		eventBus: eventBus,
+		cfg:      cfg,
+		log:      logger.With("component", "outbox-relay"),
+	}
+}
+
+// Run ejecuta el loop de drenado hasta que se cancele el contexto.
+func (r *Relay) Run(ctx context.Context) {
+	r.log.Info("drenando outbox", "poll_interval", r.cfg.PollInterval)
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.log.Info("relay detenido")
+			return
+		case <-ticker.C:
+			if _, err := r.DrainOnce(ctx); err != nil {
+				r.log.Error("error leyendo outbox", "error", err)
+			}
+		}
+	}
+}
+
+// Flush drena el outbox hasta vaciarlo (o hasta que venza ctx) y devuelve cuántos envió.
+// Se usa al apagar: lo que el Relay no alcanzó a publicar sale antes de cerrar el EventBus.
+// Los mensajes que fallan quedan con su backoff y se reintentan en el próximo arranque.
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		sent, err := r.DrainOnce(ctx)
+		total += sent
+		if err != nil || sent == 0 {
+			return total, err
+		}
+	}
+}
+
+// DrainOnce publica un lote de mensajes pendientes y devuelve cuántos se enviaron.
+// Es síncrono para poder probarlo sin goroutines ni tickers.
+// Si se cancela el ctx, corta entre mensajes: lo no enviado queda en el outbox.
+func (r *Relay) DrainOnce(ctx context.Context) (int, error) {
+	pending, err := r.outbox.Pending(ctx, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	// Pending solo trae el mensaje más viejo de cada héroe, pero igual cortamos si uno
+	// falla: si el outbox devolviera varios del mismo héroe, el siguiente no se adelanta.
+	blocked := make(map[string]bool)
+
+	for _, msg := range pending {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		event := msg.Event
+		if blocked[event.Subject] {
+			continue
+		}
+
+		if err := r.publish(ctx, event); err != nil {
+			blocked[event.Subject] = true
+			next := time.Now().Add(r.backoff(msg.Attempts))
+			r.log.WarnContext(eventContext(ctx, event), "publicación fallida", "event", event.Type, "event_id", event.ID,
+				"attempt", msg.Attempts+1, "next_attempt", next.Format(time.TimeOnly), "error", err)
+
+			if errMark := r.outbox.MarkFailed(ctx, event.ID, err.Error(), next); errMark != nil {
+				return sent, fmt.Errorf("error registrando fallo de %s: %w", event.ID, errMark)
+			}
+			continue
+		}
+
+		// Si MarkSent falla, el mensaje se volverá a publicar: por eso los consumers deben ser idempotentes.
+		if err := r.outbox.MarkSent(ctx, event.ID); err != nil {
+			return sent, fmt.Errorf("error marcando %s como enviado: %w", event.ID, err)
+		}
+		sent++
+		r.log.DebugContext(eventContext(ctx, event), "evento publicado desde el outbox", "event", event.Type, "event_id", event.ID)
+	}
+
+	return sent, nil
+}
+
+// publish publica un evento con un deadline propio, derivado del ctx del Relay.
+func (r *Relay) publish(ctx context.Context, event domain.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+	defer cancel()
+	return r.eventBus.Publish(ctx, event)
+}
+
+// backoff calcula la espera exponencial: base * 2^attempts, con tope en MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.cfg.BaseBackoff
+	for i := 0; i < attempts && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.cfg.MaxBackoff)
+}
+
+// eventContext devuelve ctx con los valores de la petición que originó el evento,
+// para que los logs del Relay (que corre en segundo plano) lleven su request_id.
+func eventContext(ctx context.Context, event domain.Event) context.Context {
+	if event.RequestID != "" {
+		ctx = domain.WithRequestID(ctx, event.RequestID)
+	}
+	if event.CorrelationID != "" {
+		ctx = domain.WithCorrelationID(ctx, event.CorrelationID)
+	}
+	return ctx
+}
@@ -0,0 +1,143 @@
+package outboxsrv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/outboxsrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// flakyBus es un EventBus falso que falla UNA vez al publicar el evento failID.
+type flakyBus struct {
+	mu        sync.Mutex
+	failID    string
+	failed    bool
+	published []string // IDs de eventos en el orden en que se publicaron
+}
+
+func (b *flakyBus) Publish(ctx context.Context, event domain.Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.ID == b.failID && !b.failed {
+		b.failed = true
+		return errors.New("broker caído")
+	}
+	b.published = append(b.published, event.ID)
+	return nil
+}
+
+func (b *flakyBus) Published() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.published)
+}
+
+// outboxRepo es lo que el test necesita de un adapter: escribir héroes (con eventos) y leer el outbox.
+type outboxRepo interface {
+	ports.HeroRepository
+	ports.Outbox
+}
+
+func TestRelayKeepsOrderPerHeroAfterFailure(t *testing.T) {
+	adapters := map[string]func(t *testing.T) outboxRepo{
+		"memory": func(t *testing.T) outboxRepo {
+			return herorepo.NewMemory(discardLogger())
+		},
+		"sqlite": func(t *testing.T) outboxRepo {
+			repo, err := herorepo.NewSQLite(filepath.Join(t.TempDir(), "heroes.db"), discardLogger())
+			if err != nil {
+				t.Fatalf("NewSQLite: %v", err)
+			}
+			t.Cleanup(func() { repo.Close() })
+			return repo
+		},
+	}
+
+	for name, newRepo := range adapters {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			ctx := context.Background()
+
+			arthas := mustHero(t, "hero-a", "Arthas")
+			created := domain.NewHeroEvent("evt-a1", domain.EventHeroCreated, arthas)
+			if err := repo.Save(ctx, arthas, created); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			arthas.Name, arthas.Version = "Arthas Menethil", 2
+			updated := domain.NewHeroEvent("evt-a2", domain.EventHeroUpdated, arthas)
+			if err := repo.Update(ctx, arthas, 1, updated); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			jaina := mustHero(t, "hero-b", "Jaina")
+			other := domain.NewHeroEvent("evt-b1", domain.EventHeroCreated, jaina)
+			if err := repo.Save(ctx, jaina, other); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+
+			bus := &flakyBus{failID: created.ID}
+			cfg := outboxsrv.DefaultConfig()
+			cfg.BaseBackoff = 50 * time.Millisecond
+			cfg.MaxBackoff = 50 * time.Millisecond
+			relay := outboxsrv.New(repo, bus, cfg, discardLogger())
+
+			// Pasada 1: Created de Arthas falla; su Updated espera y Jaina no se ve afectada.
+			if _, err := relay.DrainOnce(ctx); err != nil {
+				t.Fatalf("DrainOnce: %v", err)
+			}
+			// Pasada 2: Created sigue en backoff; Updated NO puede adelantarse.
+			if _, err := relay.DrainOnce(ctx); err != nil {
+				t.Fatalf("DrainOnce: %v", err)
+			}
+			if got, want := bus.Published(), []string{"evt-b1"}; !slices.Equal(got, want) {
+				t.Fatalf("published during backoff = %v, want %v", got, want)
+			}
+
+			time.Sleep(cfg.BaseBackoff + 20*time.Millisecond)
+			sent, err := relay.DrainOnce(ctx)
+			if err != nil {
+				t.Fatalf("DrainOnce: %v", err)
+			}
+			if sent != 1 {
+				t.Errorf("DrainOnce sent %d, want 1 (Updated waits for the next pass)", sent)
+			}
+			if _, err := relay.DrainOnce(ctx); err != nil {
+				t.Fatalf("DrainOnce: %v", err)
+			}
+			if got, want := bus.Published(), []string{"evt-b1", "evt-a1", "evt-a2"}; !slices.Equal(got, want) {
+				t.Errorf("published = %v, want %v", got, want)
+			}
+
+			pending, err := repo.Pending(ctx, 10)
+			if err != nil {
+				t.Fatalf("Pending: %v", err)
+			}
+			if len(pending) != 0 {
+				t.Errorf("Pending after flush = %d messages, want 0", len(pending))
+			}
+		})
+	}
+}
+
+func mustHero(t *testing.T, id, name string) *domain.Hero {
+	t.Helper()
+	hero, err := domain.NewHero(id, name, domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	return hero
+}
@@ -0,0 +1,27 @@
+package herohdl
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithAccessLog es un middleware que escribe una línea por petición atendida.
+// Va entre WithRequestContext (así la línea lleva el request_id) y el mux (para leer r.Pattern).
+// 💡 Reemplaza los "Recibido ..." de cada handler: un solo lugar, mismo formato para todas las rutas.
+func WithAccessLog(logger *slog.Logger, next http.Handler) http.Handler {
+	logger = logger.With("component", "http")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		logger.InfoContext(r.Context(), "petición HTTP",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", routeLabel(r.Pattern),
+			"status", sw.status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
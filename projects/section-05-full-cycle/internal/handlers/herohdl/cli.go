@@ -1,7 +1,8 @@
 package herohdl
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"time"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
@@ -12,35 +13,37 @@ import (
 // y llamar al SERVICIO.
 type CLIHandler struct {
 	service *herosrv.Service
+	log     *slog.Logger
 }
 
 // NewCLIHandler crea el handler inyectándole el servicio.
-func NewCLIHandler(service *herosrv.Service) *CLIHandler {
+func NewCLIHandler(service *herosrv.Service, logger *slog.Logger) *CLIHandler {
 	return &CLIHandler{
 		service: service,
+		log:     logger.With("component", "cli"),
 	}
 }
 
 // CreateHeroSimulated simula que un usuario tipea un comando en la terminal.
 // Recibe "strings" crudos (simulando argv) y orquesta la llamada.
-func (h *CLIHandler) CreateHeroSimulated(name string) {
-	fmt.Printf("\n🎮 HANDLER (CLI): Recibido input usuario -> Name: %s\n", name)
+func (h *CLIHandler) CreateHeroSimulated(name string, class string) {
+	ctx := context.Background()
+	h.log.InfoContext(ctx, "input recibido", "name", name, "class", class)
 
 	// 1. DTO/Command Mapping: Convertir input externo a Estructura de Dominio (Command)
 	cmd := herosrv.CreateHeroCommand{
 		Name:  name,
-		Power: 90, // En un caso real, esto podría venir de un flag o default
+		Class: class,
 	}
 
 	// 2. Llamar al Servicio (Use Case)
 	start := time.Now()
-	hero, err := h.service.Create(cmd)
+	hero, err := h.service.Create(ctx, cmd)
 
 	// 3. Manejar Respuesta (Output)
 	if err != nil {
-		fmt.Printf("❌ HANDLER: Error: %v\n", err)
+		h.log.ErrorContext(ctx, "no se pudo crear el héroe", "error", err)
 	} else {
-		duration := time.Since(start)
-		fmt.Printf("✅ HANDLER: Héroe %s creado con ID %s en %v.\n", hero.Name, hero.ID, duration)
+		h.log.InfoContext(ctx, "héroe creado", "hero_id", hero.ID, "name", hero.Name, "duration", time.Since(start))
 	}
 }
@@ -2,93 +2,293 @@ package herohdl
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
 	"github.com/segmentio/kafka-go"
 )
 
+// EventHandler es la lógica de negocio que reacciona a un evento.
+// Para que un error NO se reintente, devolvé Permanent(err).
+type EventHandler func(ctx context.Context, event domain.Event) error
+
+// MessageReader es lo que el consumer usa de un *kafka.Reader.
+// Es una interfaz para poder probar el pipeline de retry/DLQ sin un broker.
+type MessageReader interface {
+	Config() kafka.ReaderConfig
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Stats() kafka.ReaderStats
+}
+
+// MessageWriter es lo que el consumer usa de un *kafka.Writer (topics de retry y DLQ).
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
 // ConsumerHandler es un "Driving Adapter" asíncrono.
 // Reacciona a eventos en vez de peticiones HTTP.
 type ConsumerHandler struct {
-	reader *kafka.Reader
+	reader    MessageReader
+	processed ports.ProcessedEventStore // Idempotencia: IDs de eventos ya procesados
+	handle    EventHandler
+	policy    RetryPolicy
+	producer  MessageWriter // Escribe en los topics de retry y DLQ (el topic va en cada mensaje)
+	metrics   ConsumerMetrics
+	log       *slog.Logger
+
+	running    atomic.Bool  // El loop de Start está activo (para /readyz)
+	lastCommit atomic.Int64 // Último commit del topic principal (UnixNano, 0 = ninguno)
+}
+
+// ConsumerMetrics son contadores del consumer (seguros entre goroutines).
+type ConsumerMetrics struct {
+	Processed         atomic.Int64 // Eventos procesados con éxito
+	DuplicatesSkipped atomic.Int64 // Eventos ignorados por ya haber sido procesados
+	Failed            atomic.Int64 // Intentos que terminaron en error (retry o DLQ)
+	Retried           atomic.Int64 // Mensajes enviados a un topic de retry
+	DeadLettered      atomic.Int64 // Mensajes enviados al DLQ
 }
 
 // NewConsumerHandler crea el consumidor.
-func NewConsumerHandler(reader *kafka.Reader) *ConsumerHandler {
+// El producer (para retry y DLQ) se inyecta: debe tener Topic vacío porque el topic
+// va en cada mensaje. Quien lo crea lo cierra (el handler no es dueño de la conexión).
+func NewConsumerHandler(reader MessageReader, producer MessageWriter, processed ports.ProcessedEventStore, handle EventHandler, policy RetryPolicy, logger *slog.Logger) *ConsumerHandler {
 	return &ConsumerHandler{
-		reader: reader,
+		reader:    reader,
+		processed: processed,
+		handle:    handle,
+		policy:    policy,
+		producer:  producer,
+		log:       logger.With("component", "consumer"),
 	}
 }
 
-// Start inicia el loop de consumo.
-// 🎓 PATRÓN: Consumer Group + DLQ (Robustness)
-func (h *ConsumerHandler) Start(ctx context.Context) {
-	fmt.Println("🎧 HANDLER (Consumer): Esperando eventos en Kafka...")
+// Metrics expone los contadores del consumer.
+func (h *ConsumerHandler) Metrics() *ConsumerMetrics {
+	return &h.metrics
+}
 
-	// 1. DLQ Writer (Producer para errores)
-	// En prod, esto debería inyectarse como dependencia. Lo creamos aquí por simplicidad.
-	dlqWriter := &kafka.Writer{
-		Addr:     kafka.TCP("localhost:9094"),
-		Topic:    "hero-events-05-dlq",
-		Balancer: &kafka.LeastBytes{},
-	}
-	defer dlqWriter.Close()
+// Start inicia el loop de consumo. Bloquea hasta que se cancela ctx (devuelve nil)
+// o hasta que Kafka falla (devuelve el error).
+// 🎓 PATRÓN: Consumer Group + Retry Topics + DLQ (Robustness)
+//
+// 💡 APAGADO ORDENADO: cancelar ctx solo interrumpe la ESPERA del próximo mensaje.
+// El mensaje que ya se está procesando termina y se commitea (con un ctx sin cancelación):
+// si lo cortáramos a la mitad, se re-procesaría después del reinicio.
+//
+// ⚠️ Si no se puede escribir el mensaje fallido en su topic de retry/DLQ, NO se commitea:
+// Start devuelve el error y el mensaje se vuelve a leer al reiniciar (mejor repetido que perdido).
+func (h *ConsumerHandler) Start(ctx context.Context) error {
+	h.log.Info("esperando eventos", "topic", h.reader.Config().Topic, "group_id", h.reader.Config().GroupID)
 
+	h.running.Store(true)
+	defer h.running.Store(false)
+
+	work := context.WithoutCancel(ctx)
 	for {
-		// 2. Leer Mensaje (Bloqueante)
+		// 1. Leer Mensaje (Bloqueante)
 		m, err := h.reader.FetchMessage(ctx)
 		if err != nil {
-			log.Printf("❌ CRITICAL: Error de conexión con Kafka: %v\n", err)
-			break // Romper el loop si Kafka se cae
+			if ctx.Err() != nil {
+				h.log.Info("consumer detenido")
+				return nil
+			}
+			h.log.Error("error de conexión con Kafka", "error", err)
+			return err // Romper el loop si Kafka se cae
 		}
 
-		// 3. Procesar (Simulación de fallo aleatorio o validación)
-		err = h.processMessage(m)
-
-		if err != nil {
-			// 💀 DEAD LETTER QUEUE (DLQ)
-			// Si fallamos, no reintentamos infinitamente. Lo movemos al DLQ.
-			log.Printf("⚠️ ERROR PROCESANDO (Offset %d): %v. Enviando a DLQ...\n", m.Offset, err)
-
-			errDLQ := dlqWriter.WriteMessages(ctx, kafka.Message{
-				Key:   m.Key,   // Mantenemos la Key original
-				Value: m.Value, // Mantenemos el Payload original
-				Headers: []kafka.Header{
-					{Key: "original-topic", Value: []byte(m.Topic)},
-					{Key: "error-reason", Value: []byte(err.Error())},
-				},
-			})
-
-			if errDLQ != nil {
-				log.Printf("🔥 FATAL: No se pudo escribir en DLQ: %v\n", errDLQ)
-				// Aquí sí podríamos reintentar o pausar.
-			} else {
-				log.Printf("🗑️ Enviado a DLQ: hero-events-05-dlq\n")
-			}
+		// 2. Procesar (y si falla: retry o DLQ)
+		if err := h.handleMessage(work, m); err != nil {
+			h.log.Error("mensaje sin commit: no se pudo derivar", "offset", m.Offset, "error", err)
+			return err
 		}
 
-		// 4. COMMIT (Siempre avanzamos, ya sea éxito o DLQ)
-		// Si no hiciéramos commit tras el DLQ, leeríamos el mensaje venenoso infinitamente.
-		if err := h.reader.CommitMessages(ctx, m); err != nil {
-			log.Printf("❌ Error haciendo commit: %v\n", err)
+		// 3. COMMIT (Avanzamos ya sea éxito, retry o DLQ: el mensaje ya está a salvo en otro topic)
+		// Si no hiciéramos commit tras el fallo, leeríamos el mensaje venenoso infinitamente.
+		if err := h.reader.CommitMessages(work, m); err != nil {
+			h.log.Error("error haciendo commit", "offset", m.Offset, "error", err)
+			continue
 		}
+		h.lastCommit.Store(time.Now().UnixNano())
+	}
+}
+
+// ConsumerStatus es el estado del consumer que se informa en /readyz.
+type ConsumerStatus struct {
+	Topic      string     `json:"topic"`
+	GroupID    string     `json:"group_id"`
+	Lag        int64      `json:"lag"`         // Mensajes pendientes de leer (según el último fetch)
+	Offset     int64      `json:"offset"`      // Próximo offset a leer
+	LastCommit *time.Time `json:"last_commit"` // null = todavía no commiteó nada
+	Processed  int64      `json:"processed"`
+	Retried    int64      `json:"retried"`
+	DLQ        int64      `json:"dead_lettered"`
+}
+
+// RegisterMetrics expone los contadores del consumer (y el lag) en /metrics.
+// 💡 Los contadores ya existen (ConsumerMetrics): las métricas los LEEN, no duplican estado.
+func (h *ConsumerHandler) RegisterMetrics(reg *metrics.Registry) {
+	counters := []struct {
+		name, help string
+		value      *atomic.Int64
+	}{
+		{"consumer_events_processed_total", "Eventos procesados con éxito.", &h.metrics.Processed},
+		{"consumer_events_duplicates_total", "Eventos ignorados por duplicados.", &h.metrics.DuplicatesSkipped},
+		{"consumer_events_failed_total", "Intentos de procesamiento que fallaron.", &h.metrics.Failed},
+		{"consumer_events_retried_total", "Mensajes enviados a un topic de retry.", &h.metrics.Retried},
+		{"consumer_events_dead_lettered_total", "Mensajes enviados al DLQ.", &h.metrics.DeadLettered},
+	}
+	for _, c := range counters {
+		reg.CounterFunc(c.name, c.help, func() float64 { return float64(c.value.Load()) })
+	}
+	reg.GaugeFunc("consumer_lag", "Mensajes pendientes de leer en el topic principal.", func() float64 {
+		return float64(h.reader.Stats().Lag)
+	})
+}
+
+// Check implementa health.Checker: el consumer está sano mientras su loop corre.
+// 💡 Un lag alto NO es "down" (sacarlo de servicio no lo baja): se informa en Report.
+func (h *ConsumerHandler) Check(ctx context.Context) error {
+	if !h.running.Load() {
+		return errors.New("consumer loop is not running")
+	}
+	return nil
+}
+
+// Report implementa health.Reporter.
+func (h *ConsumerHandler) Report() any {
+	stats := h.reader.Stats() // Ojo: reinicia los contadores de kafka-go; acá solo usamos los gauges
+	status := ConsumerStatus{
+		Topic:     stats.Topic,
+		GroupID:   h.reader.Config().GroupID,
+		Lag:       stats.Lag,
+		Offset:    stats.Offset,
+		Processed: h.metrics.Processed.Load(),
+		Retried:   h.metrics.Retried.Load(),
+		DLQ:       h.metrics.DeadLettered.Load(),
+	}
+	if ns := h.lastCommit.Load(); ns != 0 {
+		t := time.Unix(0, ns).UTC()
+		status.LastCommit = &t
 	}
+	return status
 }
 
-// processMessage simula la lógica de negocio.
-func (h *ConsumerHandler) processMessage(m kafka.Message) error {
-	// Log de Auditoría Completo
-	fmt.Printf("\n📨 CONSUMER (P:%d | O:%d) Key[%s]\n", m.Partition, m.Offset, string(m.Key))
-	fmt.Printf("   Payload: %s\n", string(m.Value))
+// handleMessage procesa un mensaje y, si falla, lo deriva al siguiente escalón.
+// Lo comparten el topic principal (Start) y los topics de retry (StartRetryWorker).
+// Devuelve error solo si el mensaje no quedó a salvo (la derivación falló): en ese caso NO se commitea.
+func (h *ConsumerHandler) handleMessage(ctx context.Context, m kafka.Message) error {
+	// El request ID viaja como header plano: lo recuperamos ANTES de decodificar
+	// para que hasta un mensaje venenoso se pueda rastrear hasta la petición que lo originó.
+	if id, ok := headerValue(m.Headers, domain.RequestIDHeader); ok && id != "" {
+		ctx = domain.WithRequestID(ctx, id)
+	}
+
+	err := h.processMessage(ctx, m)
+	if err == nil {
+		return nil
+	}
+
+	h.metrics.Failed.Add(1)
+	kind := "transitorio"
+	if IsPermanent(err) {
+		kind = "permanente"
+	}
+	h.log.WarnContext(ctx, "error procesando mensaje", "kind", kind, "topic", m.Topic,
+		"offset", m.Offset, "attempt", retryAttempt(m), "error", err)
+
+	return h.routeFailure(ctx, m, err)
+}
+
+// processMessage decodifica el evento, descarta duplicados y ejecuta la lógica de negocio.
+func (h *ConsumerHandler) processMessage(ctx context.Context, m kafka.Message) error {
+	h.log.DebugContext(ctx, "mensaje recibido", "topic", m.Topic, "partition", m.Partition,
+		"offset", m.Offset, "key", string(m.Key))
+
+	// 1. Decodificar el sobre CloudEvents (structured o binary, se detecta solo).
+	// Un "Poison Message" (ej: {"fail":true}) no pasa la validación: es PERMANENTE -> DLQ.
+	event, err := domain.DecodeEvent(headersToMap(m.Headers), m.Value)
+	if err != nil {
+		return Permanent(fmt.Errorf("error decodificando evento: %w", err))
+	}
+	ctx = eventContext(ctx, event)
+
+	// 2. IDEMPOTENCIA: ¿Ya lo procesamos? (rebalance o crash antes del commit)
+	done, err := h.processed.IsProcessed(ctx, event.ID)
+	if err != nil {
+		return fmt.Errorf("error consultando eventos procesados: %w", err)
+	}
+	if done {
+		total := h.metrics.DuplicatesSkipped.Add(1)
+		h.log.InfoContext(ctx, "duplicado ignorado", "event", event.Type, "event_id", event.ID, "duplicates", total)
+		return nil
+	}
+
+	// 3. Lógica de negocio
+	if err := h.handle(ctx, event); err != nil {
+		return err
+	}
 
-	// Simulación de "Poison Message": Si el payload contiene "error", fallamos.
-	payload := string(m.Value)
-	if payload == `{"fail":true}` {
-		return fmt.Errorf("simulated business error")
+	// 4. Registrar como procesado DESPUÉS de la lógica.
+	// Si caemos entre 3 y 4 el evento se re-procesará: para efectos en DB,
+	// lo ideal es guardar el ID en la MISMA transacción que la proyección.
+	if err := h.processed.MarkProcessed(ctx, event.ID); err != nil {
+		return fmt.Errorf("error registrando evento procesado: %w", err)
 	}
 
 	// Éxito
-	fmt.Println("   ✅ Procesado correctamente.")
+	h.metrics.Processed.Add(1)
+	h.log.InfoContext(ctx, "evento procesado", "event", event.Type, "event_id", event.ID, "offset", m.Offset)
 	return nil
 }
+
+// LogEvent devuelve un EventHandler de ejemplo: solo registra el evento en el log.
+func LogEvent(logger *slog.Logger) EventHandler {
+	logger = logger.With("component", "event-log")
+	return func(ctx context.Context, event domain.Event) error {
+		attrs := []any{
+			"event", event.Type, "schema_version", event.SchemaVersion, "event_id", event.ID,
+			"source", event.Source, "causation_id", event.CausationID,
+		}
+		if event.Data != nil && event.Data.Hero != nil {
+			attrs = append(attrs, "hero_id", event.Data.ID, "hero", event.Data.Name,
+				"level", event.Data.Level, "power", event.Data.Power)
+		}
+		if event.Data != nil && event.Data.Change != nil {
+			attrs = append(attrs, "change", *event.Data.Change)
+		}
+		logger.InfoContext(ctx, "evento recibido", attrs...)
+		return nil
+	}
+}
+
+// eventContext agrega al ctx los valores de la petición que viajan en el sobre del evento,
+// para que todos los logs del procesamiento lleven request_id, correlation_id y actor.
+func eventContext(ctx context.Context, event domain.Event) context.Context {
+	if event.RequestID != "" {
+		ctx = domain.WithRequestID(ctx, event.RequestID)
+	}
+	if event.CorrelationID != "" {
+		ctx = domain.WithCorrelationID(ctx, event.CorrelationID)
+	}
+	if event.Actor != "" {
+		ctx = domain.WithActor(ctx, event.Actor)
+	}
+	return ctx
+}
+
+// headersToMap convierte los headers de kafka-go a un mapa (para domain.DecodeEvent).
+func headersToMap(headers []kafka.Header) map[string]string {
+	out := make(map[string]string, len(headers))
+	for _, h := range headers {
+		out[h.Key] = string(h.Value)
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package herohdl
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+	"github.com/segmentio/kafka-go"
+)
+
+func eventMessage(t *testing.T, event domain.Event, mode domain.ContentMode) kafka.Message {
+	t.Helper()
+	headers, value, err := domain.EncodeEvent(event, mode)
+	if err != nil {
+		t.Fatalf("EncodeEvent: %v", err)
+	}
+	m := kafka.Message{Topic: "hero-events-05", Key: []byte(event.Subject), Value: value}
+	for k, v := range headers {
+		m.Headers = append(m.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return m
+}
+
+// Kafka puede re-entregar un mensaje (rebalance, crash antes del commit):
+// la segunda entrega se ignora aunque llegue en otro content mode.
+func TestProcessMessageSkipsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	processed := herorepo.NewProcessedMemory(time.Hour)
+	h := NewConsumerHandler(&fakeReader{topic: "hero-events-05"}, nil, processed, LogEvent(discardLogger()), DefaultRetryPolicy("hero-events-05"), discardLogger())
+	reg := metrics.NewRegistry()
+	h.RegisterMetrics(reg)
+
+	hero, err := domain.NewHero("hero-1", "Arthas", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	event := domain.NewHeroEvent("evt-1", domain.EventHeroCreated, hero)
+
+	deliveries := []kafka.Message{
+		eventMessage(t, event, domain.ContentModeStructured),
+		eventMessage(t, event, domain.ContentModeStructured),
+		eventMessage(t, event, domain.ContentModeBinary),
+	}
+	for i, m := range deliveries {
+		if err := h.processMessage(ctx, m); err != nil {
+			t.Fatalf("delivery %d: processMessage: %v", i, err)
+		}
+	}
+
+	if got := h.Metrics().Processed.Load(); got != 1 {
+		t.Errorf("Processed = %d, want 1", got)
+	}
+	if got := h.Metrics().DuplicatesSkipped.Load(); got != 2 {
+		t.Errorf("DuplicatesSkipped = %d, want 2", got)
+	}
+	exposition := reg.String()
+	for _, want := range []string{"consumer_events_processed_total 1\n", "consumer_events_duplicates_total 2\n"} {
+		if !strings.Contains(exposition, want) {
+			t.Errorf("/metrics is missing %q:\n%s", want, exposition)
+		}
+	}
+	if done, _ := processed.IsProcessed(ctx, "evt-1"); !done {
+		t.Error("evt-1 not marked as processed")
+	}
+}
+
+// Un mensaje que no decodifica es un error permanente y no queda marcado como procesado.
+func TestProcessMessageRejectsPoisonMessage(t *testing.T) {
+	processed := herorepo.NewProcessedMemory(time.Hour)
+	h := NewConsumerHandler(nil, nil, processed, LogEvent(discardLogger()), DefaultRetryPolicy("hero-events-05"), discardLogger())
+
+	err := h.processMessage(context.Background(), kafka.Message{Value: []byte(`{"fail":true}`)})
+	if !IsPermanent(err) {
+		t.Fatalf("processMessage(poison) = %v, want a permanent error", err)
+	}
+	if got := h.Metrics().Processed.Load() + h.Metrics().DuplicatesSkipped.Load(); got != 0 {
+		t.Errorf("processed+duplicates = %d, want 0", got)
+	}
+}
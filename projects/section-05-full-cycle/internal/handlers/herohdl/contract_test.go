@@ -0,0 +1,351 @@
+package herohdl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+)
+
+// 🎓 TEST DE CONTRATO
+// Recorre TODAS las operaciones de openapi.json contra la API real (httptest + herorepo.Memory),
+// incluidas las respuestas de error, y falla si:
+//   - el status no está documentado para esa operación,
+//   - el Content-Type no es el documentado,
+//   - el body no cumple el schema,
+//   - alguna combinación operación + status del contrato quedó sin probar.
+
+// contractCase es una petición y el status documentado que debe provocar.
+type contractCase struct {
+	method  string
+	pattern string // Path del spec (ej: /v1/heroes/{id})
+	path    string // URL concreta
+	body    string
+	headers map[string]string
+	fault   error // Error que devuelve el repositorio (nil = funciona normal)
+	status  int
+}
+
+// seedID es el héroe que existe antes de cada caso:
+// nivel 1, 5 stat points, una Rusty Sword en el inventario y un Oak Staff equipado.
+const seedID = "h-1"
+
+var (
+	unavailable = fmt.Errorf("%w: base caída", domain.ErrUnavailable)
+	conflict    = fmt.Errorf("%w: otro escribió antes", domain.ErrVersionConflict)
+	hugeBody    = `{"name":"` + strings.Repeat("x", maxBodyBytes) + `"}`
+)
+
+func TestContract(t *testing.T) {
+	const (
+		heroes    = "/v1/heroes"
+		hero      = "/v1/heroes/{id}"
+		xp        = "/v1/heroes/{id}/xp"
+		stats     = "/v1/heroes/{id}/stats"
+		inventory = "/v1/heroes/{id}/inventory"
+		stack     = "/v1/heroes/{id}/inventory/{item_id}"
+		equipment = "/v1/heroes/{id}/equipment"
+		slot      = "/v1/heroes/{id}/equipment/{slot}"
+		items     = "/v1/items"
+	)
+	ifMatch := func(v string) map[string]string { return map[string]string{"If-Match": v} }
+
+	cases := []contractCase{
+		{method: "GET", pattern: heroes, path: "/v1/heroes?sort=-level&limit=1&name_prefix=ar&class=warrior", status: 200},
+		{method: "GET", pattern: heroes, path: "/v1/heroes?sort=bogus", status: 400},
+		{method: "GET", pattern: heroes, path: "/v1/heroes", fault: unavailable, status: 503},
+
+		{method: "POST", pattern: heroes, path: "/v1/heroes", body: `{"name":"Jaina"}`, status: 201}, // Sin class: warrior
+		{method: "POST", pattern: heroes, path: "/v1/heroes", body: `{"name":"Jaina","class":"mage"}`, status: 201},
+		{method: "POST", pattern: heroes, path: "/v1/heroes", body: `{"name":"Jaina","class":"bard"}`, status: 400},
+		{method: "POST", pattern: heroes, path: "/v1/heroes", body: hugeBody, status: 413},
+		{method: "POST", pattern: heroes, path: "/v1/heroes", body: `{"name":"Jaina"}`, fault: unavailable, status: 503},
+
+		{method: "GET", pattern: hero, path: "/v1/heroes/h-1", status: 200},
+		{method: "GET", pattern: hero, path: "/v1/heroes/nope", status: 404},
+		{method: "GET", pattern: hero, path: "/v1/heroes/h-1", fault: unavailable, status: 503},
+
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, headers: ifMatch(`"1"`), status: 200},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: `{}`, status: 400},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/nope", body: `{"name":"Thrall"}`, status: 404},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, fault: conflict, status: 409},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, headers: ifMatch(`"9"`), status: 412},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: hugeBody, status: 413},
+		{method: "PUT", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, fault: unavailable, status: 503},
+
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, status: 200},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":""}`, status: 400},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/nope", body: `{}`, status: 404},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, fault: conflict, status: 409},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: `{"name":"Thrall"}`, headers: ifMatch(`W/"9"`), status: 412},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: hugeBody, status: 413},
+		{method: "PATCH", pattern: hero, path: "/v1/heroes/h-1", body: `{}`, fault: unavailable, status: 503},
+
+		{method: "DELETE", pattern: hero, path: "/v1/heroes/h-1", headers: ifMatch(`"1"`), status: 200},
+		{method: "DELETE", pattern: hero, path: "/v1/heroes/nope", status: 404},
+		{method: "DELETE", pattern: hero, path: "/v1/heroes/h-1", fault: conflict, status: 409},
+		{method: "DELETE", pattern: hero, path: "/v1/heroes/h-1", headers: ifMatch(`"9"`), status: 412},
+		{method: "DELETE", pattern: hero, path: "/v1/heroes/h-1", fault: unavailable, status: 503},
+
+		{method: "POST", pattern: xp, path: "/v1/heroes/h-1/xp", body: `{"amount":300}`, status: 200},
+		{method: "POST", pattern: xp, path: "/v1/heroes/h-1/xp", body: `{"amount":0}`, status: 400},
+		{method: "POST", pattern: xp, path: "/v1/heroes/nope/xp", body: `{"amount":300}`, status: 404},
+		{method: "POST", pattern: xp, path: "/v1/heroes/h-1/xp", body: `{"amount":300}`, fault: conflict, status: 409},
+		{method: "POST", pattern: xp, path: "/v1/heroes/h-1/xp", body: hugeBody, status: 413},
+		{method: "POST", pattern: xp, path: "/v1/heroes/h-1/xp", body: `{"amount":300}`, fault: unavailable, status: 503},
+
+		{method: "POST", pattern: stats, path: "/v1/heroes/h-1/stats", body: `{"str":3,"vit":2}`, status: 200},
+		{method: "POST", pattern: stats, path: "/v1/heroes/h-1/stats", body: `{"str":-1}`, status: 400},
+		{method: "POST", pattern: stats, path: "/v1/heroes/nope/stats", body: `{"str":1}`, status: 404},
+		{method: "POST", pattern: stats, path: "/v1/heroes/h-1/stats", body: `{"str":6}`, status: 409}, // Solo tiene 5
+		{method: "POST", pattern: stats, path: "/v1/heroes/h-1/stats", body: hugeBody, status: 413},
+		{method: "POST", pattern: stats, path: "/v1/heroes/h-1/stats", body: `{"str":1}`, fault: unavailable, status: 503},
+
+		{method: "GET", pattern: inventory, path: "/v1/heroes/h-1/inventory", status: 200},
+		{method: "GET", pattern: inventory, path: "/v1/heroes/nope/inventory", status: 404},
+		{method: "GET", pattern: inventory, path: "/v1/heroes/h-1/inventory", fault: unavailable, status: 503},
+
+		{method: "POST", pattern: inventory, path: "/v1/heroes/h-1/inventory", body: `{"item_id":"health-potion","quantity":3}`, status: 200},
+		{method: "POST", pattern: inventory, path: "/v1/heroes/h-1/inventory", body: `{"item_id":"excalibur"}`, status: 400},
+		{method: "POST", pattern: inventory, path: "/v1/heroes/nope/inventory", body: `{"item_id":"health-potion"}`, status: 404},
+		{method: "POST", pattern: inventory, path: "/v1/heroes/h-1/inventory", body: `{"item_id":"rusty-sword","quantity":25}`, status: 409}, // No entra
+		{method: "POST", pattern: inventory, path: "/v1/heroes/h-1/inventory", body: hugeBody, status: 413},
+		{method: "POST", pattern: inventory, path: "/v1/heroes/h-1/inventory", body: `{"item_id":"health-potion"}`, fault: unavailable, status: 503},
+
+		{method: "DELETE", pattern: stack, path: "/v1/heroes/h-1/inventory/rusty-sword", status: 200},
+		{method: "DELETE", pattern: stack, path: "/v1/heroes/h-1/inventory/rusty-sword?quantity=0", status: 400},
+		{method: "DELETE", pattern: stack, path: "/v1/heroes/nope/inventory/rusty-sword", status: 404},
+		{method: "DELETE", pattern: stack, path: "/v1/heroes/h-1/inventory/twin-daggers", status: 409},
+		{method: "DELETE", pattern: stack, path: "/v1/heroes/h-1/inventory/rusty-sword", fault: unavailable, status: 503},
+
+		{method: "POST", pattern: equipment, path: "/v1/heroes/h-1/equipment", body: `{"item_id":"rusty-sword"}`, status: 200},
+		{method: "POST", pattern: equipment, path: "/v1/heroes/h-1/equipment", body: `{"item_id":"health-potion"}`, status: 400},
+		{method: "POST", pattern: equipment, path: "/v1/heroes/nope/equipment", body: `{"item_id":"rusty-sword"}`, status: 404},
+		{method: "POST", pattern: equipment, path: "/v1/heroes/h-1/equipment", body: `{"item_id":"leather-cap"}`, status: 409}, // No lo tiene
+		{method: "POST", pattern: equipment, path: "/v1/heroes/h-1/equipment", body: hugeBody, status: 413},
+		{method: "POST", pattern: equipment, path: "/v1/heroes/h-1/equipment", body: `{"item_id":"rusty-sword"}`, fault: unavailable, status: 503},
+
+		{method: "DELETE", pattern: slot, path: "/v1/heroes/h-1/equipment/weapon", status: 200},
+		{method: "DELETE", pattern: slot, path: "/v1/heroes/h-1/equipment/tail", status: 400},
+		{method: "DELETE", pattern: slot, path: "/v1/heroes/nope/equipment/weapon", status: 404},
+		{method: "DELETE", pattern: slot, path: "/v1/heroes/h-1/equipment/head", status: 409}, // Slot vacío
+		{method: "DELETE", pattern: slot, path: "/v1/heroes/h-1/equipment/weapon", fault: unavailable, status: 503},
+
+		{method: "GET", pattern: items, path: "/v1/items", status: 200},
+	}
+
+	tested := make(map[string]bool)
+	for _, tc := range cases {
+		name := fmt.Sprintf("%s %s %d", tc.method, tc.path, tc.status)
+		if len(name) > 80 {
+			name = name[:80]
+		}
+		t.Run(name, func(t *testing.T) {
+			runContractCase(t, tc)
+		})
+		tested[operationKey(tc.method, tc.pattern, tc.status)] = true
+	}
+
+	// Cada status documentado de cada operación tiene que estar cubierto
+	for _, key := range documentedResponses(t) {
+		if !tested[key] {
+			t.Errorf("contract response not exercised: %s", key)
+		}
+	}
+}
+
+// runContractCase levanta una API nueva (con el héroe semilla), hace la petición y valida la respuesta.
+func runContractCase(t *testing.T, tc contractCase) {
+	t.Helper()
+	repo := &faultyRepo{HeroRepository: herorepo.NewMemory(discardLogger())}
+	seedHero(t, repo)
+	repo.fault = tc.fault
+
+	handler := NewHTTPHandler(herosrv.New(repo, nopBus{}, domain.DefaultLevelCurve(), discardLogger()), discardLogger())
+	mux := http.NewServeMux()
+	handler.RegisterRoutes(mux)
+	server := httptest.NewServer(WithRequestContext(mux))
+	defer server.Close()
+
+	req, err := http.NewRequest(tc.method, server.URL+tc.path, strings.NewReader(tc.body))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	for k, v := range tc.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", tc.method, tc.path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+
+	if resp.StatusCode != tc.status {
+		t.Fatalf("status = %d, want %d; body: %s", resp.StatusCode, tc.status, body)
+	}
+	target, documented := spec.responseSchema(tc.method, tc.pattern, resp.StatusCode)
+	if !documented {
+		t.Fatalf("status %d is not documented for %s %s", resp.StatusCode, tc.method, tc.pattern)
+	}
+	if target == nil {
+		return // Respuesta documentada sin body
+	}
+
+	wantType := documentedMediaType(t, tc.method, tc.pattern, resp.StatusCode)
+	if got, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); got != wantType {
+		t.Errorf("Content-Type = %q, want %q", got, wantType)
+	}
+	value, err := parseJSON(body)
+	if err != nil {
+		t.Fatalf("response is not JSON: %v; body: %s", err, body)
+	}
+	if err := target.validate(value, "", spec.resolve); err != nil {
+		t.Errorf("response does not match the contract: %v; body: %s", err, body)
+	}
+}
+
+// seedHero guarda el héroe del que parten todos los casos.
+func seedHero(t *testing.T, repo ports.HeroRepository) {
+	t.Helper()
+	hero, err := domain.NewHero(seedID, "Arthas", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	hero.StatPoints = 5
+	for _, item := range []string{"rusty-sword", "oak-staff"} {
+		if err := hero.AddItem(item, 1); err != nil {
+			t.Fatalf("AddItem(%s): %v", item, err)
+		}
+	}
+	if err := hero.Equip("oak-staff"); err != nil {
+		t.Fatalf("Equip: %v", err)
+	}
+	if err := repo.Save(context.Background(), hero); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+// documentedResponses lista "MÉTODO path status" de todas las respuestas del spec.
+func documentedResponses(t *testing.T) []string {
+	t.Helper()
+	var keys []string
+	for path, ops := range spec.Paths {
+		for method, raw := range ops {
+			if method == "parameters" {
+				continue
+			}
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+			for status := range op.Responses {
+				code, err := strconv.Atoi(status)
+				if err != nil {
+					t.Fatalf("%s %s: non-numeric status %q", method, path, status)
+				}
+				keys = append(keys, operationKey(method, path, code))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// documentedMediaType devuelve el (único) media type documentado para una respuesta.
+func documentedMediaType(t *testing.T, method, path string, status int) string {
+	t.Helper()
+	var op struct {
+		Responses map[string]struct {
+			Ref     string                     `json:"$ref"`
+			Content map[string]json.RawMessage `json:"content"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(spec.Paths[path][strings.ToLower(method)], &op); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	resp := op.Responses[strconv.Itoa(status)]
+	types := make([]string, 0, len(resp.Content))
+	for media := range resp.Content {
+		types = append(types, media)
+	}
+	if resp.Ref != "" {
+		for media := range spec.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")].Content {
+			types = append(types, media)
+		}
+	}
+	if len(types) != 1 {
+		t.Fatalf("%s %s %d: want exactly one documented media type, got %v", method, path, status, types)
+	}
+	return types[0]
+}
+
+func operationKey(method, path string, status int) string {
+	return fmt.Sprintf("%s %s %d", strings.ToUpper(method), path, status)
+}
+
+// faultyRepo envuelve un repositorio real y, si fault != nil, falla con ese error.
+// Con domain.ErrVersionConflict solo fallan las escrituras (el héroe se lee bien, alguien
+// escribió en el medio); con cualquier otro error falla todo (la base no responde).
+type faultyRepo struct {
+	ports.HeroRepository
+	fault error
+}
+
+func (r *faultyRepo) readFault() error {
+	if slices.Contains([]error{nil, conflict}, r.fault) {
+		return nil
+	}
+	return r.fault
+}
+
+func (r *faultyRepo) Save(ctx context.Context, hero *domain.Hero, events ...domain.Event) error {
+	if r.fault != nil {
+		return r.fault
+	}
+	return r.HeroRepository.Save(ctx, hero, events...)
+}
+
+func (r *faultyRepo) Get(ctx context.Context, id string) (*domain.Hero, error) {
+	if err := r.readFault(); err != nil {
+		return nil, err
+	}
+	return r.HeroRepository.Get(ctx, id)
+}
+
+func (r *faultyRepo) Update(ctx context.Context, hero *domain.Hero, expectedVersion int, events ...domain.Event) error {
+	if r.fault != nil {
+		return r.fault
+	}
+	return r.HeroRepository.Update(ctx, hero, expectedVersion, events...)
+}
+
+func (r *faultyRepo) Delete(ctx context.Context, id string, expectedVersion int, events ...domain.Event) error {
+	if r.fault != nil {
+		return r.fault
+	}
+	return r.HeroRepository.Delete(ctx, id, expectedVersion, events...)
+}
+
+func (r *faultyRepo) List(ctx context.Context, query ports.HeroQuery) (ports.HeroPage, error) {
+	if err := r.readFault(); err != nil {
+		return ports.HeroPage{}, err
+	}
+	return r.HeroRepository.List(ctx, query)
+}
@@ -2,61 +2,117 @@ package herohdl
 
 import (
 	"encoding/json"
-	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/google/uuid"
 )
 
 // HTTPHandler es un "Driving Adapter" para Web API.
 type HTTPHandler struct {
-	service *herosrv.Service
+	service       *herosrv.Service
+	contractCheck bool // Validar respuestas contra openapi.json (ver ContractCheck)
+	log           *slog.Logger
 }
 
 // NewHTTPHandler crea el handler.
-func NewHTTPHandler(service *herosrv.Service) *HTTPHandler {
+func NewHTTPHandler(service *herosrv.Service, logger *slog.Logger) *HTTPHandler {
 	return &HTTPHandler{
 		service: service,
+		log:     logger.With("component", "http"),
 	}
 }
 
-// CreateHero maneja POST /heroes.
-func (h *HTTPHandler) CreateHero(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// Headers de petición que se copian al context (y de ahí al sobre de los eventos).
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+	HeaderActor         = "X-Actor"
+)
+
+// maxRequestIDLen limita el X-Request-ID que aceptamos del cliente (termina en logs y en Kafka).
+const maxRequestIDLen = 128
+
+// WithRequestContext es un middleware que pasa los valores de la petición al context.
+// 💡 El r.Context() de net/http ya se cancela si el cliente se desconecta:
+// los handlers se lo pasan al servicio y este a los adaptadores (DB, Kafka).
+//
+// 🎓 REQUEST ID: toda petición lleva uno. Si el cliente (o un proxy) manda X-Request-ID
+// lo respetamos; si no, lo generamos. Se devuelve en la respuesta y viaja en el evento,
+// así un mismo ID aparece en los logs de la API, del Relay y del consumer.
+func WithRequestContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		requestID := sanitizeRequestID(r.Header.Get(HeaderRequestID))
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+		ctx = domain.WithRequestID(ctx, requestID)
+		w.Header().Set(HeaderRequestID, requestID)
+
+		if id := r.Header.Get(HeaderCorrelationID); id != "" {
+			ctx = domain.WithCorrelationID(ctx, id)
+			w.Header().Set(HeaderCorrelationID, id)
+		}
+		if actor := r.Header.Get(HeaderActor); actor != "" {
+			ctx = domain.WithActor(ctx, actor)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// sanitizeRequestID descarta un X-Request-ID que no podamos loguear tal cual:
+// demasiado largo o con caracteres fuera de [A-Za-z0-9._:-] (evita inyectar líneas en los logs).
+func sanitizeRequestID(id string) string {
+	if len(id) > maxRequestIDLen {
+		return ""
 	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return ""
+		}
+	}
+	return id
+}
 
+// CreateHero maneja POST /v1/heroes.
+func (h *HTTPHandler) CreateHero(w http.ResponseWriter, r *http.Request) {
 	// 1. Parsear Input (JSON)
 	var req struct {
-		Name string `json:"name" binding:"required"`
+		Name  string `json:"name"`
+		Class string `json:"class"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeBody(w, r, "CreateHeroRequest", &req) {
 		return
 	}
 
-	fmt.Printf("\n🌐 HANDLER (HTTP): Recibido POST /heroes -> %v\n", req)
-
 	// 2. Map Input -> Command
 	cmd := herosrv.CreateHeroCommand{
 		Name:  req.Name,
-		Power: 90,
+		Class: req.Class,
 	}
 
 	// 3. Llamar Servicio
 	start := time.Now()
-	hero, err := h.service.Create(cmd)
+	hero, err := h.service.Create(r.Context(), cmd)
 
 	// 4. Mapear Output -> HTTP Response
 	if err != nil {
-		fmt.Printf("❌ HANDLER: Error: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(hero))
+	w.Header().Set("Location", heroPath(hero.ID))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"status": "created",
@@ -65,118 +121,202 @@ func (h *HTTPHandler) CreateHero(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetHero maneja GET /heroes?id=...
+// GetHero maneja GET /v1/heroes/{id} (y HEAD: mismos headers, sin body).
 func (h *HTTPHandler) GetHero(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	id := r.URL.Query().Get("id")
+	id := param(r, "id")
 	if id == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Printf("\n🌐 HANDLER (HTTP): Recibido GET /heroes?id=%s\n", id)
-
 	// Renombrado: GetHero -> Get
-	hero, err := h.service.Get(id)
+	hero, err := h.service.Get(r.Context(), id)
 	if err != nil {
-		fmt.Printf("❌ HANDLER: Not Found: %v\n", err)
-		http.Error(w, "Hero not found", http.StatusNotFound)
+		h.writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(hero))
 	json.NewEncoder(w).Encode(hero)
 }
 
-// UpdateHero maneja PUT /heroes?id=...
+// UpdateHero maneja PUT /v1/heroes/{id}: reemplaza la representación editable
+// del héroe, así que "name" es obligatorio (lo exige el schema ReplaceHeroRequest).
+// Con header If-Match (el ETag de un GET previo) solo actualiza si nadie lo cambió antes: si no, 412.
 func (h *HTTPHandler) UpdateHero(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPut {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !decodeBody(w, r, "ReplaceHeroRequest", &req) {
 		return
 	}
 
-	id := r.URL.Query().Get("id")
+	h.update(w, r, herosrv.UpdateHeroCommand{ID: id, Name: req.Name})
+}
+
+// PatchHero maneja PATCH /v1/heroes/{id}: actualización parcial (JSON Merge Patch,
+// RFC 7396). Solo cambian los campos presentes en el body.
+// 💡 *string distingue "no vino" (nil) de "vino con valor".
+func (h *HTTPHandler) PatchHero(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
 	if id == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
-		Name string `json:"name"`
+		Name *string `json:"name"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+	if !decodeBody(w, r, "PatchHeroRequest", &req) {
 		return
 	}
 
-	fmt.Printf("\n🌐 HANDLER (HTTP): Recibido PUT /heroes?id=%s\n", id)
-
-	cmd := herosrv.UpdateHeroCommand{
-		ID:   id,
-		Name: req.Name,
+	cmd := herosrv.UpdateHeroCommand{ID: id}
+	if req.Name != nil {
+		cmd.Name = *req.Name // El schema ya garantiza que no está vacío
 	}
 
-	if err := h.service.Update(cmd); err != nil {
-		fmt.Printf("❌ HANDLER: Error: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	h.update(w, r, cmd)
+}
+
+// update es la parte común de PUT y PATCH: aplica If-Match y responde con el nuevo ETag.
+func (h *HTTPHandler) update(w http.ResponseWriter, r *http.Request, cmd herosrv.UpdateHeroCommand) {
+
+	expected, conditional := ifMatchVersion(r)
+	cmd.ExpectedVersion = expected
+
+	hero, err := h.service.Update(r.Context(), cmd)
+	if err != nil {
+		h.writeVersionError(w, r, err, conditional)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(hero))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "updated",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "updated",
+		"version": hero.Version,
 	})
 }
 
-// DeleteHero maneja DELETE /heroes?id=...
+// DeleteHero maneja DELETE /v1/heroes/{id}
+// Acepta If-Match igual que UpdateHero.
 func (h *HTTPHandler) DeleteHero(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
 		return
 	}
 
-	id := r.URL.Query().Get("id")
+	expected, conditional := ifMatchVersion(r)
+	if err := h.service.Delete(r.Context(), id, expected); err != nil {
+		h.writeVersionError(w, r, err, conditional)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status": "deleted",
+	})
+}
+
+// GrantXP maneja POST /v1/heroes/{id}/xp
+func (h *HTTPHandler) GrantXP(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
 	if id == "" {
-		http.Error(w, "Missing 'id' query parameter", http.StatusBadRequest)
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Amount int `json:"amount"`
+	}
+	if !decodeBody(w, r, "GrantXPRequest", &req) {
 		return
 	}
 
-	fmt.Printf("\n🌐 HANDLER (HTTP): Recibido DELETE /heroes?id=%s\n", id)
+	cmd := herosrv.GrantXPCommand{
+		HeroID: id,
+		Amount: req.Amount,
+	}
 
-	if err := h.service.Delete(id); err != nil {
-		fmt.Printf("❌ HANDLER: Error: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	result, err := h.service.GrantXP(r.Context(), cmd)
+	if err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "deleted",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":        "xp_granted",
+		"levels_gained": result.LevelsGained,
+		"next_level_xp": h.service.XPForNextLevel(result.Hero),
+		"hero":          result.Hero,
 	})
 }
 
-// ListHeroes maneja GET /heroes (sin query params)
-func (h *HTTPHandler) ListHeroes(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// AllocateStats maneja POST /v1/heroes/{id}/stats
+func (h *HTTPHandler) AllocateStats(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
 		return
 	}
 
-	fmt.Println("\n🌐 HANDLER (HTTP): Recibido GET /heroes (list)")
+	var req domain.Attributes
+	if !decodeBody(w, r, "AllocateStatsRequest", &req) {
+		return
+	}
+
+	cmd := herosrv.AllocateStatsCommand{
+		HeroID: id,
+		Points: req,
+	}
 
-	heroes, err := h.service.List()
+	hero, err := h.service.AllocateStats(r.Context(), cmd)
 	if err != nil {
-		fmt.Printf("❌ HANDLER: Error: %v\n", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(heroes)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "stats_allocated",
+		"hero":   hero,
+	})
+}
+
+// etag convierte la versión del héroe en un ETag (strong, entre comillas: "3").
+func etag(hero *domain.Hero) string {
+	return strconv.Quote(strconv.Itoa(hero.Version))
+}
+
+// ifMatchVersion lee el header If-Match.
+// Devuelve (0, false) si no hay header o es "*" (cualquier versión sirve).
+// Un ETag que no entendemos devuelve -1: no coincide con ninguna versión -> 412.
+func ifMatchVersion(r *http.Request) (int, bool) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return 0, false
+	}
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(v, "W/"))
+	if err != nil {
+		return -1, true
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version <= 0 {
+		return -1, true
+	}
+	return version, true
 }
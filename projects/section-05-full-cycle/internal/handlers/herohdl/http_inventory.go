@@ -0,0 +1,212 @@
+package herohdl
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+)
+
+// inventoryItemView es una casilla del inventario con la definición del item resuelta.
+type inventoryItemView struct {
+	domain.InventoryStack
+	Item *domain.ItemDefinition `json:"item,omitempty"`
+}
+
+// inventoryView es la respuesta de los endpoints de inventario.
+type inventoryView struct {
+	HeroID    string                                     `json:"hero_id"`
+	Capacity  int                                        `json:"capacity"`
+	Used      int                                        `json:"used"`
+	Items     []inventoryItemView                        `json:"items"`
+	Equipment map[domain.ItemSlot]*domain.ItemDefinition `json:"equipment"`
+	Stats     domain.DerivedStats                        `json:"stats"`
+}
+
+func newInventoryView(hero *domain.Hero) inventoryView {
+	view := inventoryView{
+		HeroID:    hero.ID,
+		Capacity:  domain.InventoryCapacity,
+		Used:      len(hero.Inventory),
+		Items:     make([]inventoryItemView, 0, len(hero.Inventory)),
+		Equipment: make(map[domain.ItemSlot]*domain.ItemDefinition, len(hero.Equipment)),
+		Stats:     hero.Stats(),
+	}
+	for _, stack := range hero.Inventory {
+		item := inventoryItemView{InventoryStack: stack}
+		if def, err := domain.LookupItem(stack.ItemID); err == nil {
+			item.Item = &def
+		}
+		view.Items = append(view.Items, item)
+	}
+	for slot, itemID := range hero.Equipment {
+		if def, err := domain.LookupItem(itemID); err == nil {
+			view.Equipment[slot] = &def
+		}
+	}
+	return view
+}
+
+// ListItems maneja GET /v1/items (catálogo).
+func (h *HTTPHandler) ListItems(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.ItemCatalog())
+}
+
+// ListInventory maneja GET /v1/heroes/{id}/inventory
+func (h *HTTPHandler) ListInventory(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
+		return
+	}
+
+	hero, err := h.service.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newInventoryView(hero))
+}
+
+// AddItem maneja POST /v1/heroes/{id}/inventory con body {"item_id": "...", "quantity": N}
+func (h *HTTPHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ItemID   string `json:"item_id"`
+		Quantity int    `json:"quantity"`
+	}
+	if !decodeBody(w, r, "AddItemRequest", &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1 // Por defecto, una unidad
+	}
+
+	cmd := herosrv.AddItemCommand{
+		HeroID:   id,
+		ItemID:   req.ItemID,
+		Quantity: req.Quantity,
+	}
+
+	hero, err := h.service.AddItem(r.Context(), cmd)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "item_added",
+		"inventory": newInventoryView(hero),
+	})
+}
+
+// RemoveItem maneja DELETE /v1/heroes/{id}/inventory/{item_id}?quantity=N
+func (h *HTTPHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID := param(r, "id"), param(r, "item_id")
+	if id == "" || itemID == "" {
+		httpError(w, r, "Missing hero id or item_id", http.StatusBadRequest)
+		return
+	}
+	quantity := 1
+	if v := r.URL.Query().Get("quantity"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			httpError(w, r, "Invalid 'quantity' query parameter", http.StatusBadRequest)
+			return
+		}
+		quantity = n
+	}
+
+	cmd := herosrv.RemoveItemCommand{
+		HeroID:   id,
+		ItemID:   itemID,
+		Quantity: quantity,
+	}
+
+	hero, err := h.service.RemoveItem(r.Context(), cmd)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "item_removed",
+		"inventory": newInventoryView(hero),
+	})
+}
+
+// EquipItem maneja POST /v1/heroes/{id}/equipment con body {"item_id": "..."}
+func (h *HTTPHandler) EquipItem(w http.ResponseWriter, r *http.Request) {
+	id := param(r, "id")
+	if id == "" {
+		httpError(w, r, "Missing hero id", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ItemID string `json:"item_id"`
+	}
+	if !decodeBody(w, r, "EquipItemRequest", &req) {
+		return
+	}
+
+	cmd := herosrv.EquipItemCommand{
+		HeroID: id,
+		ItemID: req.ItemID,
+	}
+
+	hero, err := h.service.EquipItem(r.Context(), cmd)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "item_equipped",
+		"inventory": newInventoryView(hero),
+	})
+}
+
+// UnequipItem maneja DELETE /v1/heroes/{id}/equipment/{slot}
+func (h *HTTPHandler) UnequipItem(w http.ResponseWriter, r *http.Request) {
+	id, slot := param(r, "id"), param(r, "slot")
+	if id == "" || slot == "" {
+		httpError(w, r, "Missing hero id or slot", http.StatusBadRequest)
+		return
+	}
+
+	cmd := herosrv.UnequipItemCommand{
+		HeroID: id,
+		Slot:   slot,
+	}
+
+	hero, err := h.service.UnequipItem(r.Context(), cmd)
+	if err != nil {
+		h.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "item_unequipped",
+		"inventory": newInventoryView(hero),
+	})
+}
@@ -0,0 +1,113 @@
+package herohdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+)
+
+// heroListView es la respuesta paginada de GET /v1/heroes.
+type heroListView struct {
+	Items      []*domain.Hero `json:"items"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Links      listLinks      `json:"links"`
+}
+
+type listLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+}
+
+// ListHeroes maneja GET /v1/heroes?limit=&cursor=&sort=&min_level=&name_prefix=&class=
+// Ej: GET /v1/heroes?sort=-level&min_level=5&limit=10
+func (h *HTTPHandler) ListHeroes(w http.ResponseWriter, r *http.Request) {
+
+	page, ok := h.listPage(w, r)
+	if !ok {
+		return
+	}
+
+	view := heroListView{
+		Items:      page.Heroes,
+		Total:      page.Total,
+		NextCursor: page.NextCursor,
+		Links:      listLinks{Self: r.URL.RequestURI(), Next: nextPageURL(r, page)},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(view)
+}
+
+// listHeroesLegacy maneja GET /heroes: el formato viejo era un array "pelado",
+// así que la paginación viaja solo en headers (Link rel="next" y X-Total-Count).
+func (h *HTTPHandler) listHeroesLegacy(w http.ResponseWriter, r *http.Request) {
+
+	page, ok := h.listPage(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page.Heroes)
+}
+
+// listPage parsea la query, llama al servicio y escribe los headers de paginación.
+// Si algo falla ya respondió el error y devuelve ok=false.
+func (h *HTTPHandler) listPage(w http.ResponseWriter, r *http.Request) (ports.HeroPage, bool) {
+	q := r.URL.Query()
+	query := herosrv.ListHeroesQuery{
+		Cursor:     q.Get("cursor"),
+		Sort:       q.Get("sort"),
+		NamePrefix: q.Get("name_prefix"),
+		Class:      q.Get("class"),
+	}
+	var err error
+	if query.Limit, err = queryInt(r, "limit"); err != nil {
+		httpError(w, r, err.Error(), http.StatusBadRequest)
+		return ports.HeroPage{}, false
+	}
+	if query.MinLevel, err = queryInt(r, "min_level"); err != nil {
+		httpError(w, r, err.Error(), http.StatusBadRequest)
+		return ports.HeroPage{}, false
+	}
+
+	page, err := h.service.List(r.Context(), query)
+	if err != nil {
+		h.writeError(w, r, err)
+		return ports.HeroPage{}, false
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if next := nextPageURL(r, page); next != "" {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"next\"", next))
+	}
+	return page, true
+}
+
+// nextPageURL es la misma petición (filtros, orden, limit) con el cursor siguiente.
+func nextPageURL(r *http.Request, page ports.HeroPage) string {
+	if page.NextCursor == "" {
+		return ""
+	}
+	q := r.URL.Query()
+	q.Set("cursor", page.NextCursor)
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// queryInt lee un parámetro entero opcional de la query (0 si no viene).
+func queryInt(r *http.Request, name string) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid '%s' query parameter", name)
+	}
+	return n, nil
+}
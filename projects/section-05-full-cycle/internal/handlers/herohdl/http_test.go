@@ -0,0 +1,110 @@
+package herohdl
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// nopBus descarta los eventos de fallo (los de éxito van al outbox de Memory).
+type nopBus struct{}
+
+func (nopBus) Publish(context.Context, domain.Event) error { return nil }
+
+// racingRepo simula otra escritura que se cuela entre el Get y el Update del servicio.
+type racingRepo struct {
+	ports.HeroRepository
+	t *testing.T
+}
+
+func (r *racingRepo) Get(ctx context.Context, id string) (*domain.Hero, error) {
+	hero, err := r.HeroRepository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	other := hero.Clone()
+	other.Name, other.Version = "Otro cliente", hero.Version+1
+	if err := r.HeroRepository.Update(ctx, other, hero.Version); err != nil {
+		r.t.Fatalf("concurrent Update: %v", err)
+	}
+	return hero, nil
+}
+
+func newTestHTTPHandler(t *testing.T, wrap func(ports.HeroRepository) ports.HeroRepository) *HTTPHandler {
+	t.Helper()
+	repo := herorepo.NewMemory(discardLogger())
+	hero, err := domain.NewHero("h-1", "Arthas", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	if err := repo.Save(context.Background(), hero); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	var heroes ports.HeroRepository = repo
+	if wrap != nil {
+		heroes = wrap(repo)
+	}
+	return NewHTTPHandler(herosrv.New(heroes, nopBus{}, domain.DefaultLevelCurve(), discardLogger()), discardLogger())
+}
+
+func putHero(h *HTTPHandler, ifMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/heroes?id=h-1", strings.NewReader(`{"name":"Lich King"}`))
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	h.UpdateHero(rec, req)
+	return rec
+}
+
+func TestUpdateHeroIfMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		ifMatch  string
+		want     int
+		wantETag string
+	}{
+		{"current version", `"1"`, http.StatusOK, `"2"`},
+		{"weak etag", `W/"1"`, http.StatusOK, `"2"`},
+		{"any version", `*`, http.StatusOK, `"2"`},
+		{"stale version", `"5"`, http.StatusPreconditionFailed, ""},
+		{"unparseable etag", `"abc"`, http.StatusPreconditionFailed, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := putHero(newTestHTTPHandler(t, nil), tt.ifMatch)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %q)", rec.Code, tt.want, rec.Body.String())
+			}
+			if got := rec.Header().Get("ETag"); got != tt.wantETag {
+				t.Errorf("ETag = %q, want %q", got, tt.wantETag)
+			}
+		})
+	}
+}
+
+// Sin If-Match el cliente no pidió precondición: perder la carrera es 409, no 412.
+func TestUpdateHeroConflictWithoutIfMatch(t *testing.T) {
+	h := newTestHTTPHandler(t, func(repo ports.HeroRepository) ports.HeroRepository {
+		return &racingRepo{HeroRepository: repo, t: t}
+	})
+
+	rec := putHero(h, "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d (body %q)", rec.Code, http.StatusConflict, rec.Body.String())
+	}
+}
@@ -0,0 +1,58 @@
+package herohdl
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
+)
+
+// WithMetrics es un middleware que cuenta peticiones y mide su latencia POR RUTA.
+// Va ADENTRO de WithRequestContext y directamente sobre el mux: el mux anota en r.Pattern
+// qué ruta atendió la petición (ej: "GET /v1/heroes/{id}").
+//
+// 💡 CARDINALIDAD: el label es la ruta con {id}, NO la URL real. Con la URL, cada héroe
+// crearía una serie nueva y Prometheus se quedaría sin memoria.
+func WithMetrics(reg *metrics.Registry, next http.Handler) http.Handler {
+	requests := reg.Counter("http_requests_total", "Peticiones HTTP atendidas.", "method", "route", "status")
+	duration := reg.Histogram("http_request_duration_seconds", "Latencia de las peticiones HTTP.", nil, "method", "route")
+	inFlight := reg.Gauge("http_requests_in_flight", "Peticiones HTTP en curso.")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(sw, r)
+
+		route := routeLabel(r.Pattern)
+		requests.With(r.Method, route, strconv.Itoa(sw.status)).Inc()
+		duration.With(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeLabel quita el método del patrón ("GET /v1/heroes" -> "/v1/heroes").
+// Sin patrón (404 o 405 del mux) todo cae en una sola serie.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return "unmatched"
+	}
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		return path
+	}
+	return pattern
+}
+
+// statusWriter recuerda el status que escribió el handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,197 @@
+package herohdl
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 🎓 CONTRATO OPENAPI
+// openapi.json es la fuente de verdad de la API: lo leen los clientes (Swagger UI,
+// generadores de SDK) y también este adaptador, que lo usa para:
+//  1. Validar los bodies de entrada (decodeBody) antes de armar los comandos.
+//  2. Opcionalmente, verificar que cada respuesta cumpla el contrato (ContractCheck).
+//
+// go:embed lo mete dentro del binario: no hay archivo que desplegar aparte.
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// openAPI es la parte del documento que usamos al validar.
+type openAPI struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"` // path -> método (o "parameters") -> operación
+	Components struct {
+		Schemas   map[string]*schema `json:"schemas"`
+		Responses map[string]struct {
+			Content map[string]struct {
+				Schema *schema `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Responses map[string]struct {
+		Ref     string `json:"$ref"`
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+// spec se parsea una sola vez al arrancar: un openapi.json roto es un bug de build.
+var spec = mustParseSpec(openAPIDocument)
+
+func mustParseSpec(data []byte) *openAPI {
+	var doc openAPI
+	if err := json.Unmarshal(data, &doc); err != nil {
+		panic(fmt.Sprintf("openapi.json inválido: %v", err))
+	}
+	return &doc
+}
+
+// resolve traduce "#/components/schemas/Hero" al schema.
+func (doc *openAPI) resolve(ref string) *schema {
+	return doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")]
+}
+
+// responseSchema busca el schema de la respuesta (método + path del patrón + status).
+// ok=false si la operación no documenta ese status.
+func (doc *openAPI) responseSchema(method, path string, status int) (s *schema, ok bool) {
+	raw, found := doc.Paths[path][strings.ToLower(method)]
+	if !found {
+		return nil, false
+	}
+	var op operation
+	if err := json.Unmarshal(raw, &op); err != nil {
+		return nil, false
+	}
+	resp, found := op.Responses[strconv.Itoa(status)]
+	if !found {
+		return nil, false
+	}
+	content := resp.Content
+	if resp.Ref != "" {
+		content = doc.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")].Content
+	}
+	for _, media := range content {
+		return media.Schema, true
+	}
+	return nil, true // Respuesta documentada sin body
+}
+
+// validateBody valida un JSON contra un schema de components/schemas.
+func (doc *openAPI) validateBody(data []byte, schemaName string) error {
+	value, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	target := doc.Components.Schemas[schemaName]
+	if target == nil {
+		return fmt.Errorf("unknown schema %q", schemaName)
+	}
+	return target.validate(value, "", doc.resolve)
+}
+
+// OpenAPI maneja GET /openapi.json.
+func (h *HTTPHandler) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+// maxBodyBytes limita el tamaño de los bodies (ningún request legítimo se acerca).
+const maxBodyBytes = 64 << 10
+
+// decodeBody lee el body, lo valida contra components/schemas/<schemaName> y lo
+// decodifica en dst. Si algo falla ya respondió el error (400/413) y devuelve false.
+func decodeBody(w http.ResponseWriter, r *http.Request, schemaName string, dst any) bool {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			httpError(w, r, fmt.Sprintf("Request body larger than %d bytes", maxBodyBytes), http.StatusRequestEntityTooLarge)
+			return false
+		}
+		httpError(w, r, "Error reading request body", http.StatusBadRequest)
+		return false
+	}
+
+	if err := spec.validateBody(data, schemaName); err != nil {
+		var invalid *schemaError
+		if !errors.As(err, &invalid) {
+			httpError(w, r, "Invalid JSON", http.StatusBadRequest)
+			return false
+		}
+		writeProblem(w, r, Problem{
+			Type:   "/problems/validation",
+			Title:  "Invalid request",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("request body does not match %s: %v", schemaName, invalid),
+		})
+		return false
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		httpError(w, r, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// 🎓 CONTRACT CHECK
+// Con el flag -contract-check, cada respuesta de /v1 se compara contra el openapi.json
+// y las diferencias se loguean. Sirve en desarrollo para detectar que el código y el
+// contrato se separaron (un campo nuevo sin documentar, un status inesperado...).
+
+// ContractCheck activa la verificación de respuestas (desactivada por defecto: tiene costo).
+func (h *HTTPHandler) ContractCheck(enabled bool) {
+	h.contractCheck = enabled
+}
+
+// checkContract envuelve un handler y valida su respuesta contra la operación del spec.
+func (h *HTTPHandler) checkContract(method, path string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		if r.Method == http.MethodHead {
+			return // Sin body que validar
+		}
+		target, documented := spec.responseSchema(method, path, rec.status)
+		switch {
+		case !documented:
+			h.log.WarnContext(r.Context(), "status no documentado en el contrato", "method", method, "path", path, "status", rec.status)
+		case target != nil:
+			value, err := parseJSON(rec.body.Bytes())
+			if err == nil {
+				err = target.validate(value, "", spec.resolve)
+			}
+			if err != nil {
+				h.log.WarnContext(r.Context(), "respuesta fuera de contrato", "method", method, "path", path, "status", rec.status, "error", err)
+			}
+		}
+	}
+}
+
+// responseRecorder copia status y body mientras los escribe al cliente.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
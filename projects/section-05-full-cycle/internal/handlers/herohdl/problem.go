@@ -0,0 +1,111 @@
+package herohdl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// 🎓 RFC 7807 (Problem Details for HTTP APIs)
+// En vez de un texto plano ("hero not found"), los errores se responden con un JSON
+// estándar que cualquier cliente sabe leer:
+//
+//	HTTP/1.1 404 Not Found
+//	Content-Type: application/problem+json
+//	{"type":"/problems/not-found","title":"Resource not found","status":404,
+//	 "detail":"error obteniendo hero: hero not found with id h-1","instance":"/v1/heroes/h-1"}
+
+// Problem es el cuerpo de un error según RFC 7807.
+type Problem struct {
+	Type     string `json:"type"`               // URI que identifica el TIPO de problema
+	Title    string `json:"title"`              // Resumen corto del tipo (no cambia entre ocurrencias)
+	Status   int    `json:"status"`             // Código HTTP (repetido para quien solo lee el body)
+	Detail   string `json:"detail,omitempty"`   // Explicación de ESTA ocurrencia
+	Instance string `json:"instance,omitempty"` // Petición que lo provocó
+}
+
+const problemContentType = "application/problem+json"
+
+// problemKind asocia cada categoría de error del dominio con su respuesta HTTP.
+type problemKind struct {
+	err    error
+	status int
+	typ    string
+	title  string
+}
+
+var problemKinds = []problemKind{
+	{domain.ErrValidation, http.StatusBadRequest, "/problems/validation", "Invalid request"},
+	{domain.ErrNotFound, http.StatusNotFound, "/problems/not-found", "Resource not found"},
+	{domain.ErrConflict, http.StatusConflict, "/problems/conflict", "Conflict with current state"},
+	{domain.ErrUnavailable, http.StatusServiceUnavailable, "/problems/unavailable", "Service unavailable"},
+}
+
+// writeError traduce un error del servicio a un Problem según su categoría.
+// Lo que no tiene categoría es un bug nuestro: 500 sin detalles internos.
+func (h *HTTPHandler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := problemFor(err)
+	h.logError(r, p.Status, err)
+	writeProblem(w, r, p)
+}
+
+// problemFor elige el Problem que corresponde a la categoría del error.
+func problemFor(err error) Problem {
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			return Problem{Type: k.typ, Title: k.title, Status: k.status, Detail: err.Error()}
+		}
+	}
+	// Un deadline vencido sin categoría (ej: dentro de un driver) también es "no disponible".
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Problem{Type: "/problems/unavailable", Title: "Service unavailable", Status: http.StatusServiceUnavailable, Detail: err.Error()}
+	}
+	return Problem{Type: "about:blank", Title: http.StatusText(http.StatusInternalServerError), Status: http.StatusInternalServerError}
+}
+
+// logError registra la causa del error (el access log solo ve el status).
+// 💡 Un 4xx es culpa del cliente: Info. Un 5xx es nuestro (o de una dependencia): Error.
+func (h *HTTPHandler) logError(r *http.Request, status int, err error) {
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	h.log.Log(r.Context(), level, "petición fallida", "status", status, "error", err)
+}
+
+// writeVersionError es writeError con la regla de If-Match: un conflicto de versión
+// es 412 si el cliente mandó If-Match (su precondición falló) y 409 si no
+// (perdió una carrera contra otra escritura).
+func (h *HTTPHandler) writeVersionError(w http.ResponseWriter, r *http.Request, err error, conditional bool) {
+	if conditional && errors.Is(err, domain.ErrVersionConflict) {
+		h.logError(r, http.StatusPreconditionFailed, err)
+		writeProblem(w, r, Problem{
+			Type:   "/problems/precondition-failed",
+			Title:  "Precondition failed",
+			Status: http.StatusPreconditionFailed,
+			Detail: err.Error(),
+		})
+		return
+	}
+	h.writeError(w, r, err)
+}
+
+// httpError es el reemplazo de http.Error para errores del propio adaptador
+// (JSON mal formado, falta un parámetro, método no permitido...).
+func httpError(w http.ResponseWriter, r *http.Request, detail string, status int) {
+	writeProblem(w, r, Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail})
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = r.URL.RequestURI()
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false) // "must be >= 0" en vez de "must be \u003e= 0"
+	enc.Encode(p)
+}
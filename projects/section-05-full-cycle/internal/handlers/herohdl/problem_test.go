@@ -0,0 +1,91 @@
+package herohdl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != problemContentType {
+		t.Fatalf("Content-Type = %q, want %q", ct, problemContentType)
+	}
+	var p Problem
+	if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+		t.Fatalf("decoding problem: %v", err)
+	}
+	return p
+}
+
+func TestWriteErrorMapsCategories(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantType   string
+		wantDetail bool // Los 500 no exponen el error interno
+	}{
+		{"validation", fmt.Errorf("error creando hero: %w", domain.ErrHeroNameEmpty), http.StatusBadRequest, "/problems/validation", true},
+		{"attribute error", &domain.AttributeError{Attribute: "str", Value: 99, Limit: 20, Err: domain.ErrAttributeCapExceeded}, http.StatusBadRequest, "/problems/validation", true},
+		{"not found", fmt.Errorf("error obteniendo hero: %w with id h-1", domain.ErrHeroNotFound), http.StatusNotFound, "/problems/not-found", true},
+		{"conflict", domain.ErrInventoryFull, http.StatusConflict, "/problems/conflict", true},
+		{"version conflict without If-Match", domain.ErrVersionConflict, http.StatusConflict, "/problems/conflict", true},
+		{"unavailable", fmt.Errorf("%w: disk I/O error", domain.ErrUnavailable), http.StatusServiceUnavailable, "/problems/unavailable", true},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusServiceUnavailable, "/problems/unavailable", true},
+		{"uncategorized", errors.New("nil pointer somewhere"), http.StatusInternalServerError, "about:blank", false},
+	}
+	h := newTestHTTPHandler(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.writeError(rec, httptest.NewRequest(http.MethodGet, "/heroes?id=h-1", nil), tt.err)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			p := decodeProblem(t, rec)
+			if p.Type != tt.wantType || p.Status != tt.wantStatus || p.Title == "" {
+				t.Errorf("problem = %+v, want type %s and status %d with a title", p, tt.wantType, tt.wantStatus)
+			}
+			if p.Instance != "/heroes?id=h-1" {
+				t.Errorf("instance = %q, want /heroes?id=h-1", p.Instance)
+			}
+			if hasDetail := p.Detail == tt.err.Error(); hasDetail != tt.wantDetail {
+				t.Errorf("detail = %q (want the error text: %v)", p.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestWriteVersionErrorWithIfMatchIs412(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newTestHTTPHandler(t, nil).writeVersionError(rec, httptest.NewRequest(http.MethodPut, "/heroes?id=h-1", nil), domain.ErrVersionConflict, true)
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+	if p := decodeProblem(t, rec); p.Type != "/problems/precondition-failed" {
+		t.Errorf("type = %q, want /problems/precondition-failed", p.Type)
+	}
+}
+
+// Un 404 real, de punta a punta: servicio + repo en memoria + handler.
+func TestGetHeroNotFoundIsProblem(t *testing.T) {
+	h := newTestHTTPHandler(t, nil)
+	rec := httptest.NewRecorder()
+	h.GetHero(rec, httptest.NewRequest(http.MethodGet, "/heroes?id=nope", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if p := decodeProblem(t, rec); p.Type != "/problems/not-found" || p.Instance != "/heroes?id=nope" {
+		t.Errorf("problem = %+v", p)
+	}
+}
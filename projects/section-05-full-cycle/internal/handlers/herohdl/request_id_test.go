@@ -0,0 +1,149 @@
+package herohdl
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/services/herosrv"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/logging"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+	"github.com/segmentio/kafka-go"
+)
+
+func TestSanitizeRequestID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"8f2c1e0a-4b7d-4c55-9a11-0c2f1d9e3b6a", "8f2c1e0a-4b7d-4c55-9a11-0c2f1d9e3b6a"},
+		{"lb:req_1.2", "lb:req_1.2"},
+		{"", ""},
+		{"with space", ""},
+		{"evil\nlevel=ERROR msg=pwned", ""}, // Inyectaría una línea falsa en los logs
+		{"ñandú", ""},
+		{strings.Repeat("a", maxRequestIDLen), strings.Repeat("a", maxRequestIDLen)},
+		{strings.Repeat("a", maxRequestIDLen+1), ""},
+	}
+	for _, tt := range tests {
+		if got := sanitizeRequestID(tt.id); got != tt.want {
+			t.Errorf("sanitizeRequestID(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func headerOf(m kafka.Message, key string) string {
+	v, _ := headerValue(m.Headers, key)
+	return v
+}
+
+// El mismo request ID recorre todo el camino:
+// X-Request-ID -> context -> evento en el outbox -> header de Kafka -> ctx del consumer.
+func TestRequestIDRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		sent     string
+		wantSame bool // false: el ID del cliente se descarta y se genera uno nuevo
+	}{
+		{"client id", "req-42", true},
+		{"no id", "", false},
+		{"invalid id", "evil\nlevel=ERROR", false},
+	}
+	for _, mode := range []domain.ContentMode{domain.ContentModeStructured, domain.ContentModeBinary} {
+		for _, tt := range tests {
+			t.Run(string(mode)+"/"+tt.name, func(t *testing.T) {
+				ctx := context.Background()
+				repo := herorepo.NewMemory(discardLogger())
+				h := NewHTTPHandler(herosrv.New(repo, nopBus{}, domain.DefaultLevelCurve(), discardLogger()), discardLogger())
+				mux := http.NewServeMux()
+				h.RegisterRoutes(mux)
+
+				// 1. HTTP: el middleware fija el ID y lo devuelve en la respuesta
+				req := httptest.NewRequest(http.MethodPost, "/v1/heroes", strings.NewReader(`{"name":"Jaina","class":"mage"}`))
+				if tt.sent != "" {
+					req.Header.Set(HeaderRequestID, tt.sent)
+				}
+				rec := httptest.NewRecorder()
+				WithRequestContext(mux).ServeHTTP(rec, req)
+				if rec.Code != http.StatusCreated {
+					t.Fatalf("POST status = %d, want %d (body %q)", rec.Code, http.StatusCreated, rec.Body.String())
+				}
+				id := rec.Header().Get(HeaderRequestID)
+				if tt.wantSame && id != tt.sent {
+					t.Fatalf("response %s = %q, want the client's %q", HeaderRequestID, id, tt.sent)
+				}
+				if !tt.wantSame && (id == "" || id == tt.sent || sanitizeRequestID(id) != id) {
+					t.Fatalf("response %s = %q, want a fresh valid ID", HeaderRequestID, id)
+				}
+
+				// 2. Outbox: el evento guarda el ID (el Relay publica después, con otro ctx)
+				pending, err := repo.Pending(ctx, 10)
+				if err != nil || len(pending) != 1 {
+					t.Fatalf("Pending = %d messages, %v; want 1", len(pending), err)
+				}
+				event := pending[0].Event
+				if event.RequestID != id {
+					t.Errorf("event.RequestID = %q, want %q", event.RequestID, id)
+				}
+
+				// 3. Kafka: viaja como header plano (y dentro del sobre)
+				m, err := herorepo.EncodeMessage(event, mode)
+				if err != nil {
+					t.Fatalf("EncodeMessage: %v", err)
+				}
+				if got := headerOf(m, domain.RequestIDHeader); got != id {
+					t.Errorf("kafka header %s = %q, want %q", domain.RequestIDHeader, got, id)
+				}
+
+				// 4. Consumer: la lógica de negocio lo recibe en el ctx
+				var seen string
+				handle := func(ctx context.Context, _ domain.Event) error {
+					seen = domain.RequestIDFrom(ctx)
+					return nil
+				}
+				consumer := NewConsumerHandler(&fakeReader{topic: "hero-events-05"}, nil, herorepo.NewProcessedMemory(time.Hour),
+					handle, DefaultRetryPolicy("hero-events-05"), discardLogger())
+				m.Topic = "hero-events-05"
+				if err := consumer.handleMessage(ctx, m); err != nil {
+					t.Fatalf("handleMessage: %v", err)
+				}
+				if seen != id {
+					t.Errorf("consumer ctx request ID = %q, want %q", seen, id)
+				}
+			})
+		}
+	}
+}
+
+// Un mensaje que no decodifica igual se puede rastrear: el header plano llega a los logs y al DLQ.
+func TestPoisonMessageKeepsRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	logger, err := logging.New(&logs, "json", "info")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+	writer := &fakeWriter{}
+	consumer := NewConsumerHandler(&fakeReader{topic: "hero-events-05"}, writer, herorepo.NewProcessedMemory(time.Hour),
+		LogEvent(logger), fastPolicy(), logger)
+
+	poison := kafka.Message{
+		Topic:   "hero-events-05",
+		Value:   []byte(`{"fail":true}`),
+		Headers: []kafka.Header{{Key: domain.RequestIDHeader, Value: []byte("req-42")}},
+	}
+	if err := consumer.handleMessage(context.Background(), poison); err != nil {
+		t.Fatalf("handleMessage: %v", err)
+	}
+
+	if len(writer.written) != 1 || headerOf(writer.written[0], domain.RequestIDHeader) != "req-42" {
+		t.Fatalf("DLQ messages = %+v, want one with the request ID header", writer.written)
+	}
+	if !strings.Contains(logs.String(), `"request_id":"req-42"`) {
+		t.Errorf("logs do not carry the request ID:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,247 @@
+package herohdl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// 🎓 PATRÓN: Retry Topics + DLQ
+// Un error TRANSITORIO (DB caída, timeout) merece otra oportunidad, pero no
+// bloqueando la partición principal. El mensaje viaja por topics de espera
+// cada vez más largos (5s -> 1m -> 10m) y solo si agota todos termina en el DLQ.
+// Un error PERMANENTE (payload inválido) va directo al DLQ: reintentar no lo arregla.
+
+// Headers de control que viajan con el mensaje por los topics de retry/DLQ.
+const (
+	HeaderOriginalTopic  = "original-topic"   // Topic donde se publicó por primera vez
+	HeaderErrorReason    = "error-reason"     // Último error
+	HeaderRetryAttempt   = "retry-attempt"    // Cuántos reintentos lleva (0 = topic principal)
+	HeaderRetryNotBefore = "retry-not-before" // No reprocesar antes de este instante (RFC3339)
+)
+
+// ErrPermanent marca un error que NO se debe reintentar.
+var ErrPermanent = errors.New("permanent error")
+
+// Permanent envuelve un error para que vaya directo al DLQ.
+func Permanent(err error) error {
+	return fmt.Errorf("%w: %w", ErrPermanent, err)
+}
+
+// IsPermanent indica si el error fue clasificado como permanente.
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrPermanent)
+}
+
+// RetryTier es un escalón de reintento: un topic y cuánto esperar antes de reprocesar.
+type RetryTier struct {
+	Topic string
+	Delay time.Duration
+}
+
+// RetryPolicy define los escalones de reintento y el destino final.
+type RetryPolicy struct {
+	Tiers    []RetryTier
+	DLQTopic string
+}
+
+// DefaultRetryPolicy genera los topics de retry a partir del topic principal.
+// Ej: hero-events-05 -> hero-events-05-retry-5s, -retry-1m, -retry-10m, -dlq
+func DefaultRetryPolicy(topic string) RetryPolicy {
+	return RetryPolicy{
+		Tiers: []RetryTier{
+			{Topic: topic + "-retry-5s", Delay: 5 * time.Second},
+			{Topic: topic + "-retry-1m", Delay: 1 * time.Minute},
+			{Topic: topic + "-retry-10m", Delay: 10 * time.Minute},
+		},
+		DLQTopic: topic + "-dlq",
+	}
+}
+
+// next decide a dónde va un mensaje que falló en el intento `attempt`.
+// Devuelve el topic destino y el instante a partir del cual se puede reprocesar.
+func (p RetryPolicy) next(attempt int, err error) (string, time.Time, bool) {
+	if IsPermanent(err) || attempt >= len(p.Tiers) {
+		return p.DLQTopic, time.Time{}, false
+	}
+	tier := p.Tiers[attempt]
+	return tier.Topic, time.Now().Add(tier.Delay), true
+}
+
+// StartRetryWorker consume un topic de retry y re-entrega cada mensaje cuando le toca.
+// `reader` debe leer el topic del escalón correspondiente (ver RetryPolicy.Tiers).
+//
+// Como todos los mensajes de un mismo topic tienen el mismo Delay, llegan ordenados
+// por "not-before": basta con dormir hasta que venza el primero.
+//
+// Igual que Start: bloquea hasta que se cancela ctx (nil) o falla Kafka (error).
+// Un mensaje que todavía estaba esperando su turno NO se commitea: se re-entrega al reiniciar.
+// Tampoco uno que no se pudo derivar al siguiente escalón: el worker devuelve el error.
+func (h *ConsumerHandler) StartRetryWorker(ctx context.Context, reader MessageReader) error {
+	topic := reader.Config().Topic
+	logger := h.log.With("retry_topic", topic)
+	logger.Info("esperando reintentos")
+
+	work := context.WithoutCancel(ctx)
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			logger.Error("error de conexión con Kafka", "error", err)
+			return err
+		}
+
+		// 1. Esperar a que venza el "not-before" (respetando la cancelación)
+		if notBefore, ok := retryNotBefore(m); ok {
+			if wait := time.Until(notBefore); wait > 0 {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		// 2. Re-entregar al mismo pipeline que el topic principal
+		if err := h.handleMessage(work, m); err != nil {
+			logger.Error("mensaje sin commit: no se pudo derivar", "offset", m.Offset, "error", err)
+			return err
+		}
+
+		// 3. COMMIT (el mensaje ya terminó aquí, en el siguiente escalón o en el DLQ)
+		if err := reader.CommitMessages(work, m); err != nil {
+			logger.Error("error haciendo commit", "offset", m.Offset, "error", err)
+		}
+	}
+}
+
+// Reintentos de la escritura en el topic de retry/DLQ (además de los internos del kafka.Writer).
+// routeBackoff es variable solo para que los tests no esperen segundos.
+const routeAttempts = 3
+
+var routeBackoff = 1 * time.Second // Se duplica en cada intento
+
+// routeFailure envía el mensaje al siguiente escalón de retry o al DLQ.
+// Si la escritura falla, espera y reintenta; si agota los intentos (o se cancela ctx)
+// devuelve error y el llamador NO debe commitear: el mensaje se perdería.
+func (h *ConsumerHandler) routeFailure(ctx context.Context, m kafka.Message, procErr error) error {
+	attempt := retryAttempt(m)
+	topic, notBefore, isRetry := h.policy.next(attempt, procErr)
+
+	// Conservamos los headers originales (ej: CloudEvents "ce_*" en modo binary)
+	// y reemplazamos solo los de control.
+	headers := withoutHeaders(m.Headers, HeaderOriginalTopic, HeaderErrorReason, HeaderRetryAttempt, HeaderRetryNotBefore)
+	headers = append(headers,
+		kafka.Header{Key: HeaderOriginalTopic, Value: []byte(originalTopic(m))},
+		kafka.Header{Key: HeaderErrorReason, Value: []byte(procErr.Error())},
+	)
+	if isRetry {
+		headers = append(headers,
+			kafka.Header{Key: HeaderRetryAttempt, Value: []byte(strconv.Itoa(attempt + 1))},
+			kafka.Header{Key: HeaderRetryNotBefore, Value: []byte(notBefore.UTC().Format(time.RFC3339Nano))},
+		)
+	} else {
+		headers = append(headers, kafka.Header{Key: HeaderRetryAttempt, Value: []byte(strconv.Itoa(attempt))})
+	}
+
+	msg := kafka.Message{
+		Topic:   topic,
+		Key:     m.Key,   // Mantenemos la Key original
+		Value:   m.Value, // Mantenemos el Payload original
+		Headers: headers,
+	}
+	wait := routeBackoff
+	for attempt := 1; ; attempt++ {
+		err := h.producer.WriteMessages(ctx, msg)
+		if err == nil {
+			break
+		}
+		if attempt == routeAttempts {
+			return fmt.Errorf("error derivando mensaje a %s tras %d intentos: %w", topic, attempt, err)
+		}
+		h.log.WarnContext(ctx, "no se pudo derivar el mensaje, reintentando", "to", topic, "offset", m.Offset,
+			"attempt", attempt, "wait", wait, "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("derivación a %s cancelada: %w", topic, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+
+	if isRetry {
+		h.metrics.Retried.Add(1)
+		h.log.InfoContext(ctx, "reintento programado", "attempt", attempt+1, "max_attempts", len(h.policy.Tiers),
+			"to", topic, "not_before", notBefore.Format(time.TimeOnly))
+	} else {
+		h.metrics.DeadLettered.Add(1)
+		h.log.WarnContext(ctx, "mensaje enviado al DLQ", "to", topic, "attempt", attempt)
+	}
+	return nil
+}
+
+// retryAttempt lee el contador de reintentos (0 si el mensaje viene del topic principal).
+func retryAttempt(m kafka.Message) int {
+	v, ok := headerValue(m.Headers, HeaderRetryAttempt)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// retryNotBefore lee el instante a partir del cual se puede reprocesar.
+func retryNotBefore(m kafka.Message) (time.Time, bool) {
+	v, ok := headerValue(m.Headers, HeaderRetryNotBefore)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// originalTopic devuelve el topic de origen, aunque el mensaje venga de un topic de retry.
+func originalTopic(m kafka.Message) string {
+	if v, ok := headerValue(m.Headers, HeaderOriginalTopic); ok && v != "" {
+		return v
+	}
+	return m.Topic
+}
+
+func headerValue(headers []kafka.Header, key string) (string, bool) {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value), true
+		}
+	}
+	return "", false
+}
+
+func withoutHeaders(headers []kafka.Header, keys ...string) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers))
+	for _, h := range headers {
+		skip := false
+		for _, k := range keys {
+			if h.Key == k {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			out = append(out, h)
+		}
+	}
+	return out
+}
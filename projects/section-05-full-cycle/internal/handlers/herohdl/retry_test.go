@@ -0,0 +1,222 @@
+package herohdl
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/repositories/herorepo"
+	"github.com/segmentio/kafka-go"
+)
+
+// fakeReader entrega los mensajes de la cola y luego io.EOF (que corta el loop).
+type fakeReader struct {
+	topic     string
+	queue     []kafka.Message
+	committed []kafka.Message
+}
+
+func (r *fakeReader) Config() kafka.ReaderConfig {
+	return kafka.ReaderConfig{Topic: r.topic}
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	if len(r.queue) == 0 {
+		return kafka.Message{}, io.EOF
+	}
+	m := r.queue[0]
+	r.queue = r.queue[1:]
+	return m, nil
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.committed = append(r.committed, msgs...)
+	return nil
+}
+
+func (r *fakeReader) Stats() kafka.ReaderStats {
+	return kafka.ReaderStats{Topic: r.topic}
+}
+
+// fakeWriter guarda lo que se escribe; si err != nil, toda escritura falla.
+type fakeWriter struct {
+	mu      sync.Mutex
+	err     error
+	calls   int
+	written []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	if w.err != nil {
+		return w.err
+	}
+	w.written = append(w.written, msgs...)
+	return nil
+}
+
+// fastPolicy usa los topics reales pero con esperas de milisegundos.
+func fastPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy("hero-events-05")
+	for i := range policy.Tiers {
+		policy.Tiers[i].Delay = time.Millisecond
+	}
+	return policy
+}
+
+func newTestConsumer(t *testing.T, reader MessageReader, writer MessageWriter, handle EventHandler) *ConsumerHandler {
+	t.Helper()
+	return NewConsumerHandler(reader, writer, herorepo.NewProcessedMemory(time.Hour), handle, fastPolicy(), discardLogger())
+}
+
+func heroMessage(t *testing.T) kafka.Message {
+	t.Helper()
+	hero, err := domain.NewHero("hero-1", "Arthas", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	m := eventMessage(t, domain.NewHeroEvent("evt-1", domain.EventHeroCreated, hero), domain.ContentModeBinary)
+	m.Offset = 42
+	return m
+}
+
+func header(t *testing.T, m kafka.Message, key string) string {
+	t.Helper()
+	v, ok := headerValue(m.Headers, key)
+	if !ok {
+		t.Fatalf("message to %s has no %q header", m.Topic, key)
+	}
+	return v
+}
+
+// Un error transitorio recorre retry-5s -> retry-1m -> retry-10m y termina en el DLQ.
+// Cada escalón commitea su mensaje solo después de dejarlo en el siguiente topic.
+func TestTransientFailureWalksRetryTiersToDLQ(t *testing.T) {
+	broken := func(context.Context, domain.Event) error { return errors.New("db caída") }
+	writer := &fakeWriter{}
+	original := heroMessage(t)
+	reader := &fakeReader{topic: "hero-events-05", queue: []kafka.Message{original}}
+	h := newTestConsumer(t, reader, writer, broken)
+
+	h.Start(context.Background())
+	if len(reader.committed) != 1 {
+		t.Fatalf("main topic committed %d messages, want 1", len(reader.committed))
+	}
+
+	wantTopics := []string{
+		"hero-events-05-retry-5s",
+		"hero-events-05-retry-1m",
+		"hero-events-05-retry-10m",
+		"hero-events-05-dlq",
+	}
+	for i, want := range wantTopics {
+		if len(writer.written) != i+1 {
+			t.Fatalf("step %d: %d messages written, want %d", i, len(writer.written), i+1)
+		}
+		m := writer.written[i]
+		if m.Topic != want {
+			t.Fatalf("step %d: written to %s, want %s", i, m.Topic, want)
+		}
+		if string(m.Key) != string(original.Key) || string(m.Value) != string(original.Value) {
+			t.Errorf("step %d: key/value changed on the way to %s", i, m.Topic)
+		}
+		if got := header(t, m, "ce_id"); got != "evt-1" {
+			t.Errorf("step %d: ce_id = %q, want evt-1 (original headers must survive)", i, got)
+		}
+		if got := header(t, m, HeaderOriginalTopic); got != "hero-events-05" {
+			t.Errorf("step %d: %s = %q, want hero-events-05", i, HeaderOriginalTopic, got)
+		}
+		if got := header(t, m, HeaderErrorReason); got != "db caída" {
+			t.Errorf("step %d: %s = %q, want the handler error", i, HeaderErrorReason, got)
+		}
+
+		isDLQ := i == len(wantTopics)-1
+		wantAttempt := i + 1
+		if isDLQ {
+			wantAttempt = len(wantTopics) - 1 // El DLQ conserva cuántos reintentos agotó
+		}
+		if got := header(t, m, HeaderRetryAttempt); got != strconv.Itoa(wantAttempt) {
+			t.Errorf("step %d: %s = %q, want %d", i, HeaderRetryAttempt, got, wantAttempt)
+		}
+		_, hasNotBefore := headerValue(m.Headers, HeaderRetryNotBefore)
+		if isDLQ {
+			if hasNotBefore {
+				t.Errorf("DLQ message has a %s header", HeaderRetryNotBefore)
+			}
+			break
+		}
+		if _, ok := retryNotBefore(m); !ok {
+			t.Errorf("step %d: %s missing or not RFC3339", i, HeaderRetryNotBefore)
+		}
+
+		// El worker del escalón lo vuelve a procesar y lo deriva al siguiente.
+		tier := &fakeReader{topic: m.Topic, queue: []kafka.Message{m}}
+		h.StartRetryWorker(context.Background(), tier)
+		if len(tier.committed) != 1 {
+			t.Errorf("step %d: %s committed %d messages, want 1", i, m.Topic, len(tier.committed))
+		}
+	}
+
+	metrics := h.Metrics()
+	if metrics.Failed.Load() != 4 || metrics.Retried.Load() != 3 || metrics.DeadLettered.Load() != 1 {
+		t.Errorf("metrics failed=%d retried=%d dead=%d, want 4, 3, 1",
+			metrics.Failed.Load(), metrics.Retried.Load(), metrics.DeadLettered.Load())
+	}
+}
+
+// Un error permanente no pasa por los topics de retry.
+func TestPermanentFailureGoesStraightToDLQ(t *testing.T) {
+	invalid := func(context.Context, domain.Event) error { return Permanent(errors.New("héroe inválido")) }
+	writer := &fakeWriter{}
+	reader := &fakeReader{topic: "hero-events-05", queue: []kafka.Message{heroMessage(t)}}
+	h := newTestConsumer(t, reader, writer, invalid)
+
+	h.Start(context.Background())
+
+	if len(writer.written) != 1 {
+		t.Fatalf("%d messages written, want 1", len(writer.written))
+	}
+	m := writer.written[0]
+	if m.Topic != "hero-events-05-dlq" {
+		t.Errorf("written to %s, want hero-events-05-dlq", m.Topic)
+	}
+	if got := header(t, m, HeaderRetryAttempt); got != "0" {
+		t.Errorf("%s = %q, want 0", HeaderRetryAttempt, got)
+	}
+	if len(reader.committed) != 1 {
+		t.Errorf("committed %d messages, want 1", len(reader.committed))
+	}
+}
+
+// Si el DLQ no acepta el mensaje, el offset NO avanza: se vuelve a leer al reiniciar.
+func TestFailedDLQWriteLeavesOffsetUncommitted(t *testing.T) {
+	defer func(d time.Duration) { routeBackoff = d }(routeBackoff)
+	routeBackoff = time.Millisecond
+
+	invalid := func(context.Context, domain.Event) error { return Permanent(errors.New("héroe inválido")) }
+	writer := &fakeWriter{err: errors.New("broker caído")}
+	reader := &fakeReader{topic: "hero-events-05", queue: []kafka.Message{heroMessage(t), heroMessage(t)}}
+	h := newTestConsumer(t, reader, writer, invalid)
+
+	h.Start(context.Background())
+
+	if len(reader.committed) != 0 {
+		t.Errorf("committed %d messages, want 0", len(reader.committed))
+	}
+	if writer.calls != routeAttempts {
+		t.Errorf("DLQ write attempted %d times, want %d", writer.calls, routeAttempts)
+	}
+	if len(reader.queue) != 1 {
+		t.Errorf("consumer kept reading after the failed write (%d left in queue, want 1)", len(reader.queue))
+	}
+	if h.Metrics().DeadLettered.Load() != 0 {
+		t.Errorf("DeadLettered = %d, want 0", h.Metrics().DeadLettered.Load())
+	}
+}
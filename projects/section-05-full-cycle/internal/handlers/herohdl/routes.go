@@ -0,0 +1,147 @@
+package herohdl
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 🎓 RUTAS REST VERSIONADAS
+// Desde Go 1.22 el http.ServeMux entiende método y parámetros de ruta:
+//
+//	mux.HandleFunc("GET /v1/heroes/{id}", ...)  // r.PathValue("id")
+//
+// El recurso vive en la URL (/v1/heroes/42) y el verbo HTTP dice qué hacer con él.
+// El mux responde solo 404 (ruta inexistente) y 405 + header Allow (método no soportado),
+// y un patrón GET también atiende HEAD.
+
+// RegisterRoutes registra la API v1 en el mux.
+func (h *HTTPHandler) RegisterRoutes(mux *http.ServeMux) {
+	h.resource(mux, "/v1/heroes", methods{
+		http.MethodGet:  h.ListHeroes,
+		http.MethodPost: h.CreateHero,
+	})
+	h.resource(mux, "/v1/heroes/{id}", methods{
+		http.MethodGet:    h.GetHero,
+		http.MethodPut:    h.UpdateHero,
+		http.MethodPatch:  h.PatchHero,
+		http.MethodDelete: h.DeleteHero,
+	})
+	h.resource(mux, "/v1/heroes/{id}/xp", methods{http.MethodPost: h.GrantXP})
+	h.resource(mux, "/v1/heroes/{id}/stats", methods{http.MethodPost: h.AllocateStats})
+	h.resource(mux, "/v1/heroes/{id}/inventory", methods{
+		http.MethodGet:  h.ListInventory,
+		http.MethodPost: h.AddItem,
+	})
+	h.resource(mux, "/v1/heroes/{id}/inventory/{item_id}", methods{http.MethodDelete: h.RemoveItem})
+	h.resource(mux, "/v1/heroes/{id}/equipment", methods{http.MethodPost: h.EquipItem})
+	h.resource(mux, "/v1/heroes/{id}/equipment/{slot}", methods{http.MethodDelete: h.UnequipItem})
+	h.resource(mux, "/v1/items", methods{http.MethodGet: h.ListItems})
+
+	mux.HandleFunc("GET /openapi.json", h.OpenAPI)
+}
+
+// methods asocia cada verbo HTTP de un recurso con su handler.
+type methods map[string]http.HandlerFunc
+
+// resource registra "MÉTODO path" por cada verbo, más OPTIONS con los verbos permitidos.
+func (h *HTTPHandler) resource(mux *http.ServeMux, path string, handlers methods) {
+	allow := []string{http.MethodOptions}
+	for method, handler := range handlers {
+		if h.contractCheck {
+			handler = h.checkContract(method, path, handler)
+		}
+		mux.HandleFunc(method+" "+path, handler)
+		allow = append(allow, method)
+		if method == http.MethodGet {
+			allow = append(allow, http.MethodHead)
+		}
+	}
+	slices.Sort(allow)
+	allowHeader := strings.Join(allow, ", ")
+
+	mux.HandleFunc(http.MethodOptions+" "+path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allowHeader)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// 🎓 RUTAS LEGACY (shim de compatibilidad)
+// Las rutas viejas (/heroes?id=...) siguen andando para no romper clientes, pero
+// avisan que están deprecadas con headers estándar:
+//
+//	Deprecation: @1792281600                        (RFC 9745: desde cuándo)
+//	Sunset: Sun, 18 Apr 2027 00:00:00 GMT           (RFC 8594: cuándo se apagan)
+//	Link: </v1/heroes/42>; rel="successor-version"  (a dónde migrar)
+
+var (
+	legacyDeprecatedAt = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	legacySunsetAt     = time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC)
+)
+
+// RegisterLegacyRoutes registra las rutas con query params anteriores a /v1.
+// 💡 Los handlers son los mismos: param() lee el id del path o de la query.
+func (h *HTTPHandler) RegisterLegacyRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /heroes", deprecated(func(w http.ResponseWriter, r *http.Request) {
+		// Con ?id= es un héroe puntual; sin él, la colección
+		if r.URL.Query().Get("id") != "" {
+			h.GetHero(w, r)
+			return
+		}
+		h.listHeroesLegacy(w, r)
+	}))
+	mux.HandleFunc("POST /heroes", deprecated(h.CreateHero))
+	mux.HandleFunc("PUT /heroes", deprecated(h.PatchHero)) // El PUT viejo ignoraba los campos ausentes
+	mux.HandleFunc("DELETE /heroes", deprecated(h.DeleteHero))
+	mux.HandleFunc("POST /heroes/xp", deprecated(h.GrantXP))
+	mux.HandleFunc("POST /heroes/stats", deprecated(h.AllocateStats))
+	mux.HandleFunc("GET /heroes/inventory", deprecated(h.ListInventory))
+	mux.HandleFunc("POST /heroes/inventory", deprecated(h.AddItem))
+	mux.HandleFunc("DELETE /heroes/inventory", deprecated(h.RemoveItem))
+	mux.HandleFunc("POST /heroes/equipment", deprecated(h.EquipItem))
+	mux.HandleFunc("DELETE /heroes/equipment", deprecated(h.UnequipItem))
+	mux.HandleFunc("GET /items", deprecated(h.ListItems))
+}
+
+// deprecated agrega los headers de deprecación antes de atender la petición.
+func deprecated(next http.HandlerFunc) http.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(legacyDeprecatedAt.Unix(), 10)
+	sunset := legacySunsetAt.Format(http.TimeFormat)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		w.Header().Set("Sunset", sunset)
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successorPath(r)))
+		next(w, r)
+	}
+}
+
+// successorPath traduce una ruta legacy a su equivalente en /v1.
+// Ej: /heroes/xp?id=42 -> /v1/heroes/42/xp
+func successorPath(r *http.Request) string {
+	if r.URL.Path == "/items" {
+		return "/v1/items"
+	}
+	suffix := strings.TrimPrefix(r.URL.Path, "/heroes")
+	if id := r.URL.Query().Get("id"); id != "" {
+		return heroPath(id) + suffix
+	}
+	return "/v1/heroes" + suffix
+}
+
+// heroPath es la URL canónica de un héroe (header Location, links).
+func heroPath(id string) string {
+	return "/v1/heroes/" + url.PathEscape(id)
+}
+
+// param lee un parámetro de la ruta (/v1/heroes/{id}) o, en las rutas legacy, de la query (?id=).
+func param(r *http.Request, name string) string {
+	if v := r.PathValue(name); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(name)
+}
@@ -0,0 +1,127 @@
+package herohdl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newTestMux(t *testing.T) *http.ServeMux {
+	t.Helper()
+	mux := http.NewServeMux()
+	h := newTestHTTPHandler(t, nil) // Con el héroe h-1 guardado
+	h.RegisterRoutes(mux)
+	h.RegisterLegacyRoutes(mux)
+	return mux
+}
+
+func serve(mux *http.ServeMux, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestOptionsListsAllowedMethods(t *testing.T) {
+	mux := newTestMux(t)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/v1/heroes", "GET, HEAD, OPTIONS, POST"},
+		{"/v1/heroes/h-1", "DELETE, GET, HEAD, OPTIONS, PATCH, PUT"},
+		{"/v1/heroes/h-1/xp", "OPTIONS, POST"},
+		{"/v1/heroes/h-1/equipment/weapon", "DELETE, OPTIONS"},
+	}
+	for _, tt := range tests {
+		rec := serve(mux, http.MethodOptions, tt.path)
+		if rec.Code != http.StatusNoContent {
+			t.Errorf("OPTIONS %s: status %d, want %d", tt.path, rec.Code, http.StatusNoContent)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.want {
+			t.Errorf("OPTIONS %s: Allow = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// Un verbo que el recurso no soporta es 405 con Allow (lo arma el mux con los patrones registrados).
+func TestUnsupportedMethodIs405(t *testing.T) {
+	rec := serve(newTestMux(t), http.MethodPut, "/v1/heroes/h-1/xp")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if rec.Header().Get("Allow") == "" {
+		t.Error("405 without Allow header")
+	}
+}
+
+// HEAD responde lo mismo que GET pero sin body.
+// 💡 Con un servidor real: quien descarta el body de HEAD es net/http, no el handler.
+func TestHeadMatchesGetWithoutBody(t *testing.T) {
+	srv := httptest.NewServer(newTestMux(t))
+	defer srv.Close()
+
+	get, err := http.Get(srv.URL + "/v1/heroes/h-1")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	get.Body.Close()
+	head, err := http.Head(srv.URL + "/v1/heroes/h-1")
+	if err != nil {
+		t.Fatalf("HEAD: %v", err)
+	}
+	body, _ := io.ReadAll(head.Body)
+	head.Body.Close()
+
+	if get.StatusCode != http.StatusOK || head.StatusCode != http.StatusOK {
+		t.Fatalf("GET %d, HEAD %d; want 200 for both", get.StatusCode, head.StatusCode)
+	}
+	for _, key := range []string{"ETag", "Content-Type"} {
+		if head.Header.Get(key) != get.Header.Get(key) {
+			t.Errorf("HEAD %s = %q, GET %s = %q", key, head.Header.Get(key), key, get.Header.Get(key))
+		}
+	}
+	if len(body) != 0 {
+		t.Errorf("HEAD returned a %d byte body", len(body))
+	}
+}
+
+func TestLegacyRouteIsDeprecated(t *testing.T) {
+	mux := newTestMux(t)
+	rec := serve(mux, http.MethodGet, "/heroes?id=h-1")
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got, want := rec.Header().Get("Deprecation"), "@"+strconv.FormatInt(legacyDeprecatedAt.Unix(), 10); got != want {
+		t.Errorf("Deprecation = %q, want %q", got, want)
+	}
+	if got, want := rec.Header().Get("Sunset"), legacySunsetAt.Format(http.TimeFormat); got != want {
+		t.Errorf("Sunset = %q, want %q", got, want)
+	}
+	if got, want := rec.Header().Get("Link"), `</v1/heroes/h-1>; rel="successor-version"`; got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	// La ruta nueva no lleva headers de deprecación
+	if rec := serve(mux, http.MethodGet, "/v1/heroes/h-1"); rec.Header().Get("Deprecation") != "" {
+		t.Errorf("/v1 route has Deprecation = %q", rec.Header().Get("Deprecation"))
+	}
+}
+
+func TestSuccessorPath(t *testing.T) {
+	tests := map[string]string{
+		"/heroes":                    "/v1/heroes",
+		"/heroes?id=h-1":             "/v1/heroes/h-1",
+		"/heroes/xp?id=h-1":          "/v1/heroes/h-1/xp",
+		"/heroes/inventory?id=a%20b": "/v1/heroes/a%20b/inventory",
+		"/items":                     "/v1/items",
+	}
+	for target, want := range tests {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if got := successorPath(r); got != want {
+			t.Errorf("successorPath(%s) = %q, want %q", target, got, want)
+		}
+	}
+}
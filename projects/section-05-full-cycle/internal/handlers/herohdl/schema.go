@@ -0,0 +1,192 @@
+package herohdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 🎓 JSON SCHEMA (subconjunto)
+// OpenAPI 3 describe los bodies con JSON Schema. Acá validamos la parte que usa
+// nuestro openapi.json: type, properties, required, additionalProperties, items,
+// enum, minLength/maxLength, minimum/maximum, format date-time y $ref.
+// 💡 Se valida el JSON "crudo" (any) ANTES de pasarlo al struct: así detectamos
+// campos desconocidos o de otro tipo que json.Unmarshal ignoraría o convertiría en silencio.
+
+// schema es un JSON Schema (solo las palabras clave que soportamos).
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+// additional es additionalProperties: un booleano o un schema para los valores extra.
+type additional struct {
+	Allowed bool
+	Schema  *schema
+}
+
+func (a *additional) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+// schemaError dice QUÉ campo no cumple el schema y por qué.
+type schemaError struct {
+	Path   string // Ej: "attributes.str" ("" = el body entero)
+	Reason string
+}
+
+func (e *schemaError) Error() string {
+	if e.Path == "" {
+		return e.Reason
+	}
+	return e.Path + ": " + e.Reason
+}
+
+// parseJSON decodifica sin convertir los números a float64 (así 1.5 no "parece" un entero).
+func parseJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return v, nil
+}
+
+// validate comprueba value contra s. resolve traduce los $ref.
+func (s *schema) validate(value any, path string, resolve func(string) *schema) error {
+	if s.Ref != "" {
+		target := resolve(s.Ref)
+		if target == nil {
+			return &schemaError{path, fmt.Sprintf("unknown schema %s", s.Ref)}
+		}
+		return target.validate(value, path, resolve)
+	}
+
+	if err := s.validateType(value, path); err != nil {
+		return err
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		return &schemaError{path, fmt.Sprintf("must be one of %v", s.Enum)}
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return &schemaError{path, fmt.Sprintf("must be at least %d characters", *s.MinLength)}
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return &schemaError{path, fmt.Sprintf("must be at most %d characters", *s.MaxLength)}
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, v); err != nil {
+				return &schemaError{path, "must be an RFC 3339 date-time"}
+			}
+		}
+	case json.Number:
+		f, _ := v.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			return &schemaError{path, fmt.Sprintf("must be >= %v", *s.Minimum)}
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			return &schemaError{path, fmt.Sprintf("must be <= %v", *s.Maximum)}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				if err := s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), resolve); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]any:
+		return s.validateObject(v, path, resolve)
+	}
+	return nil
+}
+
+func (s *schema) validateType(value any, path string) error {
+	ok := true
+	switch s.Type {
+	case "":
+		return nil
+	case "object":
+		_, ok = value.(map[string]any)
+	case "array":
+		_, ok = value.([]any)
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(json.Number)
+	case "integer":
+		n, isNumber := value.(json.Number)
+		f, err := n.Float64()
+		ok = isNumber && err == nil && f == math.Trunc(f)
+	}
+	if !ok {
+		return &schemaError{path, fmt.Sprintf("must be of type %s", s.Type)}
+	}
+	return nil
+}
+
+func (s *schema) validateObject(obj map[string]any, path string, resolve func(string) *schema) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return &schemaError{join(path, name), "is required"}
+		}
+	}
+
+	// Orden fijo: el mismo body siempre reporta el mismo error
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		prop, known := s.Properties[k]
+		switch {
+		case known:
+		case s.AdditionalProperties == nil || s.AdditionalProperties.Allowed && s.AdditionalProperties.Schema == nil:
+			continue // Cualquier valor sirve
+		case s.AdditionalProperties.Schema != nil:
+			prop = s.AdditionalProperties.Schema
+		default:
+			return &schemaError{join(path, k), "unknown field"}
+		}
+		if err := prop.validate(obj[k], join(path, k), resolve); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func join(path, field string) string {
+	return strings.TrimPrefix(path+"."+field, ".")
+}
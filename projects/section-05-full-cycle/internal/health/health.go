@@ -0,0 +1,178 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 🎓 HEALTH CHECKS (Kubernetes / balanceadores)
+// Dos preguntas distintas, dos endpoints:
+//
+//	/healthz (liveness)  -> ¿El proceso está vivo? Si no responde, REINICIARLO.
+//	                        NO mira dependencias: si Kafka se cae, reiniciar la API no lo arregla.
+//	/readyz  (readiness) -> ¿Puede atender tráfico? Si no, sacarlo del balanceador (sin reiniciar).
+//	                        Mira cada dependencia (DB, Kafka...) y responde 503 si alguna falla.
+//
+// Respuesta de /readyz:
+//
+//	{"status":"down","uptime":"1m2s","checks":{
+//	   "kafka":      {"status":"down","latency":"2s","error":"context deadline exceeded"},
+//	   "repository": {"status":"up","latency":"120µs"}}}
+
+// Checker verifica UNA dependencia: nil = sana.
+// 💡 Es una interfaz para poder usar un fake en tests (o un CheckerFunc).
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapta una función (ej: repo.Ping) a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Reporter es opcional: un Checker que además informa su estado (ej: lag del consumer).
+type Reporter interface {
+	Report() any
+}
+
+// Status es el estado de una dependencia (o del servicio completo).
+type Status string
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result es el detalle de UNA dependencia.
+type Result struct {
+	Status  Status `json:"status"`
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Report es la respuesta de los endpoints.
+type Report struct {
+	Status Status            `json:"status"`
+	Uptime string            `json:"uptime"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Handler expone /healthz y /readyz.
+type Handler struct {
+	started time.Time
+	timeout time.Duration // Máximo por check (un broker colgado no debe colgar al probe)
+	checks  []namedChecker
+	log     *slog.Logger
+}
+
+// New crea el handler. Cada check tiene como máximo `timeout`.
+func New(timeout time.Duration, logger *slog.Logger) *Handler {
+	return &Handler{started: time.Now(), timeout: timeout, log: logger.With("component", "health")}
+}
+
+// Add registra una dependencia (llamar antes de servir tráfico).
+func (h *Handler) Add(name string, checker Checker) {
+	h.checks = append(h.checks, namedChecker{name: name, checker: checker})
+}
+
+// RegisterRoutes registra GET /healthz y GET /readyz.
+func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.Liveness)
+	mux.HandleFunc("GET /readyz", h.Readiness)
+}
+
+// Liveness maneja GET /healthz: si el proceso puede responder, está vivo.
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, Report{Status: StatusUp, Uptime: h.uptime()})
+}
+
+// Readiness maneja GET /readyz: 200 si todas las dependencias están sanas, 503 si no.
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := h.Check(r.Context())
+	for name, result := range report.Checks {
+		if result.Status == StatusDown {
+			h.log.WarnContext(r.Context(), "dependencia no lista", "check", name, "error", result.Error)
+		}
+	}
+	writeReport(w, report)
+}
+
+// Check ejecuta todos los checks EN PARALELO (la latencia total es la del más lento).
+func (h *Handler) Check(ctx context.Context) Report {
+	report := Report{Status: StatusUp, Uptime: h.uptime(), Checks: make(map[string]Result, len(h.checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := h.run(ctx, c.checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (h *Handler) run(ctx context.Context, checker Checker) Result {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	// ⚠️ Un check que ignora el ctx no puede colgar al probe: se deja de esperar al vencer
+	// el timeout (la goroutine termina sola cuando el check vuelva).
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- checker.Check(ctx)
+	}()
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result := Result{Status: StatusUp, Latency: time.Since(start).Round(time.Microsecond).String()}
+	if err != nil {
+		result.Status, result.Error = StatusDown, err.Error()
+	}
+	if reporter, ok := checker.(Reporter); ok {
+		result.Details = reporter.Report()
+	}
+	return result
+}
+
+func (h *Handler) uptime() string {
+	return time.Since(h.started).Round(time.Second).String()
+}
+
+func writeReport(w http.ResponseWriter, report Report) {
+	status := http.StatusOK
+	if report.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store") // El estado de hace 5s no sirve
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// fakeChecker devuelve err; con wait != nil, antes espera a que se cierre wait
+// (respetando el ctx salvo que ignoreCtx).
+type fakeChecker struct {
+	err       error
+	wait      <-chan struct{}
+	ignoreCtx bool
+	details   any
+}
+
+func (f fakeChecker) Check(ctx context.Context) error {
+	if f.wait != nil {
+		if f.ignoreCtx {
+			<-f.wait
+		} else {
+			select {
+			case <-f.wait:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return f.err
+}
+
+// reportingChecker además implementa Reporter.
+type reportingChecker struct{ fakeChecker }
+
+func (r reportingChecker) Report() any { return r.details }
+
+func readyz(t *testing.T, h *Handler) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", got)
+	}
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     map[string]Checker
+		wantStatus int
+		wantDown   []string
+	}{
+		{"no checks", nil, http.StatusOK, nil},
+		{"all up", map[string]Checker{"repository": fakeChecker{}, "kafka": fakeChecker{}}, http.StatusOK, nil},
+		{"one down", map[string]Checker{"repository": fakeChecker{}, "kafka": fakeChecker{err: errors.New("dial tcp: connection refused")}}, http.StatusServiceUnavailable, []string{"kafka"}},
+		{"all down", map[string]Checker{"repository": fakeChecker{err: errors.New("disk I/O error")}, "kafka": fakeChecker{err: errors.New("no brokers")}}, http.StatusServiceUnavailable, []string{"repository", "kafka"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(time.Second, discardLogger())
+			for name, c := range tt.checks {
+				h.Add(name, c)
+			}
+
+			code, report := readyz(t, h)
+			if code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", code, tt.wantStatus)
+			}
+			wantOverall := StatusUp
+			if len(tt.wantDown) > 0 {
+				wantOverall = StatusDown
+			}
+			if report.Status != wantOverall {
+				t.Errorf("report.Status = %q, want %q", report.Status, wantOverall)
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Errorf("%d checks in the report, want %d", len(report.Checks), len(tt.checks))
+			}
+			for name, result := range report.Checks {
+				down := slices.Contains(tt.wantDown, name)
+				if (result.Status == StatusDown) != down || (result.Error != "") != down {
+					t.Errorf("check %s = %+v, want down=%v", name, result, down)
+				}
+			}
+		})
+	}
+}
+
+// /healthz no mira las dependencias: con Kafka caído el proceso sigue vivo.
+func TestLivenessIgnoresChecks(t *testing.T) {
+	h := New(time.Second, discardLogger())
+	h.Add("kafka", fakeChecker{err: errors.New("no brokers")})
+
+	rec := httptest.NewRecorder()
+	h.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// Cada check espera a que los demás hayan arrancado: en serie, el primero se quedaría
+// esperando hasta el timeout.
+func TestCheckRunsInParallel(t *testing.T) {
+	const n = 3
+	var started sync.WaitGroup
+	started.Add(n)
+	allStarted := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(allStarted)
+	}()
+
+	h := New(2*time.Second, discardLogger())
+	for _, name := range []string{"a", "b", "c"} {
+		h.Add(name, CheckerFunc(func(ctx context.Context) error {
+			started.Done()
+			select {
+			case <-allStarted:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}))
+	}
+
+	if report := h.Check(context.Background()); report.Status != StatusUp {
+		t.Fatalf("report = %+v, want every check up", report)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	hung := make(chan struct{})
+	defer close(hung)
+
+	tests := []struct {
+		name    string
+		checker Checker
+	}{
+		{"respects ctx", fakeChecker{wait: hung}},
+		{"ignores ctx", fakeChecker{wait: hung, ignoreCtx: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(20*time.Millisecond, discardLogger())
+			h.Add("kafka", tt.checker)
+			h.Add("repository", fakeChecker{})
+
+			start := time.Now()
+			code, report := readyz(t, h)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("readyz took %v with a 20ms timeout", elapsed)
+			}
+			if code != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want %d", code, http.StatusServiceUnavailable)
+			}
+			if got := report.Checks["kafka"]; got.Status != StatusDown || !strings.Contains(got.Error, "deadline exceeded") {
+				t.Errorf("kafka = %+v, want down by deadline", got)
+			}
+			if got := report.Checks["repository"]; got.Status != StatusUp {
+				t.Errorf("repository = %+v, want up (a slow check must not affect the others)", got)
+			}
+		})
+	}
+}
+
+func TestCheckIncludesReporterDetails(t *testing.T) {
+	h := New(time.Second, discardLogger())
+	h.Add("consumer", reportingChecker{fakeChecker{details: map[string]int{"lag": 7}}})
+
+	_, report := readyz(t, h)
+	details, ok := report.Checks["consumer"].Details.(map[string]any)
+	if !ok || details["lag"] != float64(7) {
+		t.Errorf("details = %#v, want the Report() of the checker", report.Checks["consumer"].Details)
+	}
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka verifica que el broker responda y que los topics existan
+// (un topic borrado también deja al servicio sin poder trabajar).
+type Kafka struct {
+	client *kafka.Client
+	topics []string
+}
+
+// NewKafka crea el check. El client trae brokers y SASL (ver config.KafkaConfig.NewClient).
+func NewKafka(client *kafka.Client, topics ...string) *Kafka {
+	return &Kafka{client: client, topics: topics}
+}
+
+// Check pide la metadata de los topics al broker.
+func (k *Kafka) Check(ctx context.Context) error {
+	meta, err := k.client.Metadata(ctx, &kafka.MetadataRequest{Topics: k.topics})
+	if err != nil {
+		return fmt.Errorf("broker unreachable: %w", err)
+	}
+
+	var errs []error
+	for _, topic := range meta.Topics {
+		if topic.Error != nil {
+			errs = append(errs, fmt.Errorf("topic %s: %w", topic.Name, topic.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
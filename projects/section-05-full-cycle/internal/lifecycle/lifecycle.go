@@ -0,0 +1,196 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 🎓 GRACEFUL SHUTDOWN
+// Matar un proceso a mitad de camino deja cosas a medias: peticiones HTTP cortadas,
+// un mensaje de Kafka procesado pero sin commit (se re-procesa), eventos en el buffer
+// del writer que nunca salen. Al recibir SIGINT (Ctrl+C) o SIGTERM (docker stop,
+// Kubernetes) hacemos lo contrario, en orden:
+//
+//  1. Dejar de aceptar trabajo nuevo  -> se cancela el ctx de cada Component.Run
+//  2. Terminar lo que está en vuelo   -> Component.Shutdown (ej: http.Server.Shutdown)
+//  3. Esperar a que cada Run termine
+//  4. Liberar recursos, al revés de como se crearon -> OnStop (flush de Kafka, cerrar DB)
+//
+// Todo con un deadline: si algo se cuelga, Run devuelve error y el proceso sale igual.
+
+// Component es una pieza de larga duración (servidor HTTP, consumer, relay...).
+type Component struct {
+	Name string
+
+	// Run bloquea hasta que ctx se cancela (devuelve nil) o hasta que falla (devuelve el error).
+	Run func(ctx context.Context) error
+
+	// Shutdown (opcional) termina lo que está en vuelo. Se llama después de cancelar el ctx de Run.
+	Shutdown func(ctx context.Context) error
+}
+
+type stopHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Lifecycle arranca los componentes y los detiene ordenadamente.
+type Lifecycle struct {
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+	components      []Component
+	hooks           []stopHook
+	log             *slog.Logger
+}
+
+// New crea un Lifecycle que escucha SIGINT y SIGTERM.
+// shutdownTimeout es el tiempo máximo para TODO el apagado.
+func New(shutdownTimeout time.Duration, logger *slog.Logger) *Lifecycle {
+	return &Lifecycle{
+		shutdownTimeout: shutdownTimeout,
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+		log:             logger.With("component", "lifecycle"),
+	}
+}
+
+// Add registra un componente. Se apagan en orden inverso al de registro.
+func (l *Lifecycle) Add(c Component) {
+	l.components = append(l.components, c)
+}
+
+// OnStop registra una limpieza que corre cuando ya terminaron todos los componentes.
+// 💡 Como un defer: la última registrada es la primera en correr.
+func (l *Lifecycle) OnStop(name string, fn func(ctx context.Context) error) {
+	l.hooks = append(l.hooks, stopHook{name: name, fn: fn})
+}
+
+// Run arranca los componentes y bloquea hasta recibir una señal, hasta que se cancela
+// ctx o hasta que algún componente termina; entonces apaga todo.
+// Devuelve el primer error de un componente, de una limpieza o del deadline.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	sigCtx, stopSignals := signal.NotifyContext(ctx, l.signals...)
+	defer stopSignals()
+
+	runCtx, cancelRun := context.WithCancel(context.Background())
+	defer cancelRun()
+
+	// 1. Arrancar
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		errs     []error
+		finished = make(chan string, len(l.components))
+	)
+	record := func(err error) {
+		errMu.Lock()
+		defer errMu.Unlock()
+		errs = append(errs, err)
+	}
+	for _, c := range l.components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Run(runCtx); err != nil && !errors.Is(err, context.Canceled) {
+				record(fmt.Errorf("%s: %w", c.Name, err))
+			}
+			finished <- c.Name
+		}()
+	}
+
+	// 2. Esperar el motivo del apagado
+	select {
+	case <-sigCtx.Done():
+		l.log.Info("señal recibida, apagando")
+	case name := <-finished:
+		l.log.Warn("un componente terminó, apagando el resto", "name", name)
+	}
+	stopSignals() // Un segundo Ctrl+C mata el proceso sin esperar
+
+	deadline, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	// 3. Dejar de aceptar trabajo y terminar lo que está en vuelo (orden inverso)
+	cancelRun()
+	for i := len(l.components) - 1; i >= 0; i-- {
+		c := l.components[i]
+		if c.Shutdown == nil {
+			continue
+		}
+		l.log.Info("deteniendo componente", "name", c.Name)
+		if err := callWithDeadline(deadline, c.Shutdown); err != nil {
+			record(fmt.Errorf("%s shutdown: %w", c.Name, err))
+		}
+	}
+
+	// 4. Esperar a que todos los Run terminen (o vencer el deadline)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-deadline.Done():
+		record(fmt.Errorf("shutdown timed out after %s waiting for components", l.shutdownTimeout))
+	}
+
+	// 5. Liberar recursos (LIFO)
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		h := l.hooks[i]
+		if err := callWithDeadline(deadline, h.fn); err != nil {
+			record(fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err == nil {
+		l.log.Info("apagado completo")
+	}
+	return err
+}
+
+// callWithDeadline corre fn pero deja de esperarla cuando vence ctx.
+// ⚠️ Un Shutdown o una limpieza que ignora el ctx no puede colgar el apagado: queda
+// corriendo en su goroutine, pero Run sigue y devuelve el error del deadline.
+func callWithDeadline(ctx context.Context, fn func(context.Context) error) error {
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fn(ctx)
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HTTPServer adapta un *http.Server a Component.
+// Shutdown deja de aceptar conexiones y espera a que terminen las peticiones en curso.
+func HTTPServer(name string, srv *http.Server) Component {
+	return Component{
+		Name: name,
+		Run: func(context.Context) error {
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		Shutdown: srv.Shutdown,
+	}
+}
+
+// Closer adapta un Close() error (writers, readers, DB) a un hook de OnStop.
+func Closer(c interface{ Close() error }) func(context.Context) error {
+	return func(context.Context) error {
+		return c.Close()
+	}
+}
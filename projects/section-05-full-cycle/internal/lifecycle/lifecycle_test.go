@@ -0,0 +1,209 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/lifecycle"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// untilDone es un Run que trabaja hasta que le cancelan el ctx.
+func untilDone(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// runAsync corre lc.Run en una goroutine y devuelve un canal con su resultado.
+func runAsync(ctx context.Context, lc *lifecycle.Lifecycle) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- lc.Run(ctx)
+	}()
+	return result
+}
+
+// waitRun espera el resultado de Run; falla si tarda más de within.
+func waitRun(t *testing.T, result <-chan error, within time.Duration) error {
+	t.Helper()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(within):
+		t.Fatalf("Run did not return within %s", within)
+		return nil
+	}
+}
+
+func TestRunReturnsWhenContextIsCancelled(t *testing.T) {
+	lc := lifecycle.New(time.Second, discardLogger())
+	var shutdown bool
+	lc.Add(lifecycle.Component{
+		Name: "worker",
+		Run:  untilDone,
+		Shutdown: func(context.Context) error {
+			shutdown = true
+			return nil
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runAsync(ctx, lc)
+	cancel()
+
+	if err := waitRun(t, result, time.Second); err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if !shutdown {
+		t.Error("Shutdown was not called")
+	}
+}
+
+func TestOnStopHooksRunInReverseOrder(t *testing.T) {
+	lc := lifecycle.New(time.Second, discardLogger())
+
+	var (
+		mu      sync.Mutex
+		order   []string
+		running = true
+	)
+	lc.Add(lifecycle.Component{
+		Name: "worker",
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			mu.Lock()
+			running = false
+			mu.Unlock()
+			return nil
+		},
+	})
+	for _, name := range []string{"db", "kafka-writer", "tracer"} {
+		lc.OnStop(name, func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if running {
+				t.Errorf("hook %s ran before the components stopped", name)
+			}
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runAsync(ctx, lc)
+	cancel()
+	if err := waitRun(t, result, time.Second); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+
+	if want := []string{"tracer", "kafka-writer", "db"}; !slices.Equal(order, want) {
+		t.Errorf("hooks ran as %v, want %v", order, want)
+	}
+}
+
+func TestShutdownDeadlineIsEnforced(t *testing.T) {
+	hang := make(chan struct{})
+	t.Cleanup(func() { close(hang) }) // Libera las goroutines colgadas al terminar
+
+	cases := map[string]struct {
+		component lifecycle.Component
+		wantErr   string
+	}{
+		"shutdown ignores ctx": {
+			component: lifecycle.Component{
+				Name: "stuck",
+				Run:  untilDone,
+				Shutdown: func(context.Context) error {
+					<-hang
+					return nil
+				},
+			},
+			wantErr: "stuck shutdown: context deadline exceeded",
+		},
+		"run ignores ctx": {
+			component: lifecycle.Component{
+				Name: "stuck",
+				Run: func(context.Context) error {
+					<-hang
+					return nil
+				},
+			},
+			wantErr: "shutdown timed out",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			const timeout = 50 * time.Millisecond
+			lc := lifecycle.New(timeout, discardLogger())
+			lc.Add(tc.component)
+			closed := make(chan struct{})
+			lc.OnStop("db", func(context.Context) error {
+				close(closed)
+				return nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			result := runAsync(ctx, lc)
+			start := time.Now()
+			cancel()
+
+			err := waitRun(t, result, 20*timeout)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Run = %v, want an error containing %q", err, tc.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed < timeout {
+				t.Errorf("Run returned after %s, before the %s deadline", elapsed, timeout)
+			}
+			// Con el deadline vencido Run no espera a las limpiezas, pero las tiene que llamar igual
+			select {
+			case <-closed:
+			case <-time.After(time.Second):
+				t.Error("OnStop hook was not called after the deadline")
+			}
+		})
+	}
+}
+
+func TestRunReturnsComponentError(t *testing.T) {
+	lc := lifecycle.New(time.Second, discardLogger())
+	boom := errors.New("broker caído")
+	lc.Add(lifecycle.Component{
+		Name: "consumer",
+		Run: func(context.Context) error {
+			return boom
+		},
+	})
+	var apiStopped bool
+	lc.Add(lifecycle.Component{
+		Name: "api",
+		Run:  untilDone,
+		Shutdown: func(context.Context) error {
+			apiStopped = true
+			return nil
+		},
+	})
+
+	// Nadie cancela el ctx: el fallo del consumer tiene que apagar todo
+	err := waitRun(t, runAsync(context.Background(), lc), time.Second)
+	if !errors.Is(err, boom) {
+		t.Fatalf("Run = %v, want %v", err, boom)
+	}
+	if !strings.Contains(err.Error(), "consumer") {
+		t.Errorf("error %q does not name the failing component", err)
+	}
+	if !apiStopped {
+		t.Error("the other components were not shut down")
+	}
+}
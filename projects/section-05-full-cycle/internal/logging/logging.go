@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// 🎓 LOGS ESTRUCTURADOS (log/slog)
+// Un fmt.Printf("💾 Guardando Hero %s") se lee bien en la terminal, pero no se puede
+// filtrar ("solo errores"), ni buscar ("todo lo de la petición X"), ni mandar a un
+// sistema de logs. Con slog cada línea es un mensaje + pares clave=valor:
+//
+//	time=... level=INFO msg="héroe creado" component=herosrv hero_id=h-1 request_id=8f2c...
+//	{"time":"...","level":"INFO","msg":"héroe creado","component":"herosrv","hero_id":"h-1","request_id":"8f2c..."}
+//
+// 💡 El logger se INYECTA (como el repo o el event bus): cada capa recibe un *slog.Logger
+// y le agrega su "component". Nada de variables globales.
+
+// New crea el logger de la aplicación.
+// format: text (legible, desarrollo) | json (para enviar a un sistema de logs).
+// level: debug | info | warn | error.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q (use text | json)", format)
+	}
+	return slog.New(contextHandler{handler}), nil
+}
+
+// contextHandler agrega a cada registro los valores de la petición que viajan en el ctx
+// (request_id, correlation_id, actor). Por eso las capas loguean con InfoContext(ctx, ...):
+// no hace falta pasar el request ID a mano, sale solo en cada línea.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := domain.RequestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if id := domain.CorrelationIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("correlation_id", id))
+	}
+	if actor := domain.ActorFrom(ctx); actor != "" {
+		r.AddAttrs(slog.String("actor", actor))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,354 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 🎓 MÉTRICAS (formato de Prometheus)
+// Los fmt.Printf sirven para leer UNA petición; para saber "¿cuántas peticiones por
+// segundo?" o "¿cuánto tarda el p99?" hacen falta números que se puedan sumar.
+// Prometheus lee cada N segundos GET /metrics, que devuelve texto plano:
+//
+//	# HELP http_requests_total Peticiones HTTP atendidas.
+//	# TYPE http_requests_total counter
+//	http_requests_total{method="GET",route="/v1/heroes/{id}",status="200"} 42
+//
+// Tres tipos alcanzan para casi todo:
+//   - Counter:   solo sube (peticiones, errores). Prometheus calcula la tasa con rate().
+//   - Gauge:     sube y baja (jugadores conectados, lag).
+//   - Histogram: cuenta observaciones por "bucket" (latencias) -> percentiles con histogram_quantile().
+//
+// 💡 Es una implementación mínima del formato de texto (sin dependencias):
+// los nombres y labels siguen las convenciones de Prometheus, así que migrar a
+// client_golang el día de mañana no cambia los dashboards.
+
+// DefBuckets son los buckets por defecto para latencias en segundos (los mismos de client_golang).
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry agrupa las métricas de un proceso y las expone en /metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric es cualquier cosa que sabe escribirse en el formato de texto.
+type metric interface {
+	write(sb *strings.Builder)
+}
+
+// NewRegistry crea un registro vacío.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register guarda la métrica. Registrar dos veces el mismo nombre es un bug del programa.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.metrics[name]; exists {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.metrics[name] = m
+}
+
+// Handler responde GET /metrics con todas las métricas (ordenadas por nombre).
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(r.String()))
+	})
+}
+
+// String devuelve todas las métricas en el formato de texto de Prometheus.
+func (r *Registry) String() string {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	all := make([]metric, len(names))
+	for i, name := range names {
+		all[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	var sb strings.Builder
+	for _, m := range all {
+		m.write(&sb)
+	}
+	return sb.String()
+}
+
+// Counter es un contador (solo sube), con labels opcionales.
+type Counter struct{ vec *vec }
+
+// Counter registra un contador. Los labels se completan en cada uso con With.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(name, help, "counter", labels)}
+	r.register(name, c.vec)
+	return c
+}
+
+// With devuelve la serie para esos valores de labels (en el mismo orden que al registrar).
+func (c *Counter) With(values ...string) *CounterSeries {
+	return &CounterSeries{s: c.vec.series(values)}
+}
+
+// Inc suma 1 (atajo para contadores sin labels).
+func (c *Counter) Inc() { c.With().Inc() }
+
+// Add suma v (atajo para contadores sin labels).
+func (c *Counter) Add(v float64) { c.With().Add(v) }
+
+// CounterSeries es un contador con sus labels ya resueltos.
+type CounterSeries struct{ s *series }
+
+func (c *CounterSeries) Inc() { c.Add(1) }
+
+// Add suma v (debe ser >= 0: un counter nunca baja).
+func (c *CounterSeries) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	c.s.add(v)
+}
+
+// Gauge es un valor que sube y baja, con labels opcionales.
+type Gauge struct{ vec *vec }
+
+// Gauge registra un gauge.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec: newVec(name, help, "gauge", labels)}
+	r.register(name, g.vec)
+	return g
+}
+
+// With devuelve la serie para esos valores de labels.
+func (g *Gauge) With(values ...string) *GaugeSeries {
+	return &GaugeSeries{s: g.vec.series(values)}
+}
+
+// Set, Inc, Dec y Add son atajos para gauges sin labels.
+func (g *Gauge) Set(v float64) { g.With().Set(v) }
+func (g *Gauge) Inc()          { g.With().Add(1) }
+func (g *Gauge) Dec()          { g.With().Add(-1) }
+func (g *Gauge) Add(v float64) { g.With().Add(v) }
+
+// GaugeSeries es un gauge con sus labels ya resueltos.
+type GaugeSeries struct{ s *series }
+
+func (g *GaugeSeries) Set(v float64) { g.s.set(v) }
+func (g *GaugeSeries) Add(v float64) { g.s.add(v) }
+func (g *GaugeSeries) Inc()          { g.s.add(1) }
+func (g *GaugeSeries) Dec()          { g.s.add(-1) }
+
+// GaugeFunc registra un gauge cuyo valor se calcula al leer /metrics
+// (ej: jugadores conectados = len(mapa)). Evita duplicar estado que ya existe.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, funcMetric{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+// CounterFunc es GaugeFunc para valores que solo suben (ej: contadores atómicos existentes).
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(name, funcMetric{name: name, help: help, typ: "counter", fn: fn})
+}
+
+// Histogram cuenta observaciones por bucket, con labels opcionales.
+type Histogram struct{ vec *vec }
+
+// Histogram registra un histograma. buckets = límites superiores ordenados (nil = DefBuckets).
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	if !slices.IsSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s buckets must be sorted", name))
+	}
+	v := newVec(name, help, "histogram", labels)
+	v.buckets = buckets
+	h := &Histogram{vec: v}
+	r.register(name, v)
+	return h
+}
+
+// With devuelve la serie para esos valores de labels.
+func (h *Histogram) With(values ...string) *HistogramSeries {
+	return &HistogramSeries{s: h.vec.series(values)}
+}
+
+// Observe registra una observación (atajo para histogramas sin labels).
+func (h *Histogram) Observe(v float64) { h.With().Observe(v) }
+
+// HistogramSeries es un histograma con sus labels ya resueltos.
+type HistogramSeries struct{ s *series }
+
+// Observe registra una observación (ej: segundos que tardó una petición).
+func (h *HistogramSeries) Observe(v float64) { h.s.observe(v) }
+
+// vec es una familia de series con el mismo nombre y distintos valores de labels.
+type vec struct {
+	name, help, typ string
+	labels          []string
+	buckets         []float64 // Solo histogramas
+
+	mu       sync.Mutex
+	byLabels map[string]*series // Clave: valores de labels unidos
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{name: name, help: help, typ: typ, labels: labels, byLabels: make(map[string]*series)}
+}
+
+// series busca (o crea) la serie para esos valores de labels.
+func (v *vec) series(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	s, ok := v.byLabels[key]
+	if !ok {
+		s = &series{labels: formatLabels(v.labels, values), bounds: v.buckets}
+		if v.buckets != nil {
+			s.counts = make([]uint64, len(v.buckets))
+		}
+		v.byLabels[key] = s
+	}
+	return s
+}
+
+func (v *vec) write(sb *strings.Builder) {
+	v.mu.Lock()
+	all := make([]*series, 0, len(v.byLabels))
+	for _, s := range v.byLabels {
+		all = append(all, s)
+	}
+	v.mu.Unlock()
+	slices.SortFunc(all, func(a, b *series) int { return strings.Compare(a.labels, b.labels) })
+
+	writeHeader(sb, v.name, v.help, v.typ)
+	for _, s := range all {
+		if v.buckets == nil {
+			writeSample(sb, v.name, s.labels, s.get())
+			continue
+		}
+		s.writeHistogram(sb, v.name)
+	}
+}
+
+// series es UNA serie temporal: un valor (counter/gauge) o buckets+suma+cantidad (histograma).
+type series struct {
+	labels string    // Ya formateados: method="GET",route="/v1/heroes"
+	bounds []float64 // Límites de los buckets (solo histogramas)
+
+	mu     sync.Mutex
+	value  float64
+	counts []uint64 // Observaciones por bucket (NO acumuladas; se acumulan al escribir)
+	sum    float64
+	count  uint64
+}
+
+func (s *series) add(v float64) {
+	s.mu.Lock()
+	s.value += v
+	s.mu.Unlock()
+}
+
+func (s *series) set(v float64) {
+	s.mu.Lock()
+	s.value = v
+	s.mu.Unlock()
+}
+
+func (s *series) get() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value
+}
+
+func (s *series) observe(v float64) {
+	i, _ := slices.BinarySearch(s.bounds, v) // Primer bucket con límite >= v
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// writeHistogram escribe los buckets ACUMULADOS (le = "menor o igual que"), +Inf, suma y cantidad.
+func (s *series) writeHistogram(sb *strings.Builder, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var cumulative uint64
+	for i, bound := range s.bounds {
+		cumulative += s.counts[i]
+		writeSample(sb, name+"_bucket", joinLabels(s.labels, `le="`+formatFloat(bound)+`"`), float64(cumulative))
+	}
+	writeSample(sb, name+"_bucket", joinLabels(s.labels, `le="+Inf"`), float64(s.count))
+	writeSample(sb, name+"_sum", s.labels, s.sum)
+	writeSample(sb, name+"_count", s.labels, float64(s.count))
+}
+
+// funcMetric es una métrica sin labels cuyo valor se calcula al leer.
+type funcMetric struct {
+	name, help, typ string
+	fn              func() float64
+}
+
+func (m funcMetric) write(sb *strings.Builder) {
+	writeHeader(sb, m.name, m.help, m.typ)
+	writeSample(sb, m.name, "", m.fn())
+}
+
+func writeHeader(sb *strings.Builder, name, help, typ string) {
+	help = strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeSample(sb *strings.Builder, name, labels string, value float64) {
+	sb.WriteString(name)
+	if labels != "" {
+		sb.WriteString("{" + labels + "}")
+	}
+	sb.WriteString(" " + formatFloat(value) + "\n")
+}
+
+// formatLabels arma `a="1",b="2"` escapando los valores (\, " y salto de línea).
+func formatLabels(names, values []string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + `="` + escape.Replace(values[i]) + `"`
+	}
+	return strings.Join(parts, ",")
+}
+
+func joinLabels(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, +1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
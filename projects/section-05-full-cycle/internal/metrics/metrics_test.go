@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"flag"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// go test ./internal/metrics -update reescribe los .golden con la salida actual.
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// checkGolden compara got con testdata/<name>.golden.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("writing %s: %v", path, err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("exposition does not match %s\n--- got ---\n%s--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+
+	requests := r.Counter("http_requests_total", "Peticiones HTTP atendidas.", "method", "route")
+	requests.With("GET", "/v1/heroes").Add(3)
+	requests.With("POST", `/v1/"quoted"\path`+"\nnext").Inc() // \, " y \n se escapan
+	r.Counter("events_total", "Help con \\ y\nsalto de línea.").Inc()
+
+	lag := r.Gauge("consumer_lag", "Mensajes sin leer.", "partition")
+	lag.With("1").Set(7)
+	lag.With("0").Set(2)
+	lag.With("0").Dec()
+
+	latency := r.Histogram("request_seconds", "Latencia.", []float64{0.1, 0.5, 1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.25, 0.5, 0.75, 3} { // 0.1 y 0.5 caen en su propio bucket (le = <=)
+		latency.With("/v1/items").Observe(v)
+	}
+	r.Histogram("empty_seconds", "Sin observaciones.", []float64{1}).With()
+
+	r.GaugeFunc("players_online", "Jugadores conectados.", func() float64 { return 12 })
+	r.CounterFunc("outbox_sent_total", "Eventos publicados.", func() float64 { return 1.5e9 })
+
+	checkGolden(t, "exposition", r.String())
+}
+
+func TestHandlerServesTextFormat(t *testing.T) {
+	r := NewRegistry()
+	r.GaugeFunc("up", "Siempre 1.", func() float64 { return 1 })
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got, want := rec.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; got != want {
+		t.Errorf("Content-Type = %q, want %q", got, want)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	if got, want := string(body), "# HELP up Siempre 1.\n# TYPE up gauge\nup 1\n"; got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
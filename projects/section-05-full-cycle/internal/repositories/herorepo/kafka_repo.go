@@ -2,11 +2,12 @@ package herorepo
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/metrics"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -14,69 +15,88 @@ import (
 // Renombrado de KafkaHeroRepository para ser más idiomático: herorepo.Kafka
 type Kafka struct {
 	writer *kafka.Writer
+	mode   domain.ContentMode // structured (sobre en el body) o binary (atributos en headers)
+
+	published *metrics.Counter   // Publicaciones por topic y resultado (success | failure)
+	latency   *metrics.Histogram // Cuánto tarda el broker en confirmar
+	log       *slog.Logger
 }
 
-// NewKafka inicializa la conexión.
+// NewKafka envuelve un writer ya configurado (brokers, topic, SASL: ver config.KafkaConfig.NewWriter).
+// Ya no creamos la conexión aquí: el adaptador no sabe DÓNDE está Kafka, solo cómo publicar.
+// Las métricas de publicación se registran en reg.
 // Retorna: *Kafka (Dirección de memoria del struct creado).
-func NewKafka(brokerAddress string, topic string) *Kafka {
-	// 1. Configurar el Writer (Productor)
-	// Ya no creamos el topic aquí. Asumimos que la "Plataforma" lo creó.
-	writer := &kafka.Writer{
-		Addr:                   kafka.TCP(brokerAddress),
-		Topic:                  topic,
-		Balancer:               &kafka.LeastBytes{},
-		AllowAutoTopicCreation: false, // Forzamos a que exista
-	}
-
-	fmt.Printf("🔌 INFRA (Kafka): Conectado a %s -> Topic: %s\n", brokerAddress, topic)
+func NewKafka(writer *kafka.Writer, mode domain.ContentMode, reg *metrics.Registry, logger *slog.Logger) *Kafka {
+	logger = logger.With("component", "kafka-producer", "topic", writer.Topic)
+	logger.Info("productor listo", "brokers", writer.Addr.String(), "mode", mode)
 
 	// 💡 POINTERS (Sintaxis):
 	// Usamos '&' (address of) para devolver la dirección del struct literal.
 	return &Kafka{
-		writer: writer,
+		writer:    writer,
+		mode:      mode,
+		published: reg.Counter("kafka_publish_total", "Eventos publicados en Kafka.", "topic", "result"),
+		latency:   reg.Histogram("kafka_publish_duration_seconds", "Latencia de publicación en Kafka.", nil, "topic"),
+		log:       logger,
 	}
 }
 
 // Publish implementa la interfaz ports.EventBus.
 // 💡 SOLID (ISP): Ahora Kafka solo se usa para lo que es bueno: eventos.
-func (repo *Kafka) Publish(hero *domain.Hero, eventType string) error {
-	// 1. Enriquecer el evento (CloudEvents style - simplificado)
-	event := struct {
-		EventType  string       `json:"event_type"`
-		OccurredAt time.Time    `json:"occurred_at"`
-		Data       *domain.Hero `json:"data"`
-	}{
-		EventType:  eventType,
-		OccurredAt: time.Now(),
-		Data:       hero,
-	}
-
-	// 2. Serializar
-	eventJSON, err := json.Marshal(event)
+// El ctx viene del llamador (petición HTTP o Relay): si se cancela, la escritura se aborta.
+func (repo *Kafka) Publish(ctx context.Context, event domain.Event) error {
+	// 1. Serializar el sobre (CloudEvents) según el modo configurado
+	msg, err := EncodeMessage(event, repo.mode)
 	if err != nil {
-		return fmt.Errorf("error serializando evento: %w", err)
+		return err
 	}
 
-	// 3. Enviar a Kafka
-	msg := kafka.Message{
-		Key:   []byte(hero.ID),
-		Value: eventJSON,
-		Time:  time.Now(),
+	// 2. Enviar a Kafka
+	start := time.Now()
+	err = repo.writer.WriteMessages(ctx, msg)
+	repo.latency.With(repo.writer.Topic).Observe(time.Since(start).Seconds())
+	if err != nil {
+		repo.published.With(repo.writer.Topic, "failure").Inc()
+		return fmt.Errorf("%w: error publicando en kafka: %w", domain.ErrUnavailable, err)
 	}
+	repo.published.With(repo.writer.Topic, "success").Inc()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	repo.log.InfoContext(ctx, "evento publicado", "event", event.Type, "event_id", event.ID, "key", event.Subject)
+	return nil
+}
 
-	err = repo.writer.WriteMessages(ctx, msg)
+// EncodeMessage arma el mensaje de Kafka de un evento (sin Topic: lo pone el writer).
+// Key = ID del héroe -> mismo héroe, misma partición, orden garantizado.
+// 💡 Exportada para que los tests del consumer usen exactamente los mensajes que publica Publish.
+func EncodeMessage(event domain.Event, mode domain.ContentMode) (kafka.Message, error) {
+	headers, value, err := domain.EncodeEvent(event, mode)
 	if err != nil {
-		return fmt.Errorf("error publicando en kafka: %w", err)
+		return kafka.Message{}, fmt.Errorf("error serializando evento: %w", err)
 	}
 
-	fmt.Printf("🚀 INFRA (Kafka): Evento '%s' publicado! Key=%s\n", eventType, hero.ID)
-	return nil
+	// El request ID también va como header plano: el consumer lo lee aunque no pueda decodificar el evento
+	if event.RequestID != "" {
+		headers[domain.RequestIDHeader] = event.RequestID
+	}
+
+	return kafka.Message{
+		Key:     []byte(event.Subject),
+		Value:   value,
+		Headers: toKafkaHeaders(headers),
+		Time:    event.Time,
+	}, nil
 }
 
 // Close cierra la conexión (se debe llamar al apagar el servicio).
 func (repo *Kafka) Close() error {
 	return repo.writer.Close()
 }
+
+// toKafkaHeaders convierte los headers del dominio al formato de kafka-go.
+func toKafkaHeaders(headers map[string]string) []kafka.Header {
+	out := make([]kafka.Header, 0, len(headers))
+	for k, v := range headers {
+		out = append(out, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	return out
+}
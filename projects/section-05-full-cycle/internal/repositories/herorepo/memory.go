@@ -1,84 +1,240 @@
 package herorepo
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
 )
 
 // Memory es un adaptador "fake" para base de datos.
+// También implementa ports.Outbox: héroe y eventos se escriben bajo el MISMO lock,
+// que es el equivalente en memoria a una transacción.
+//
+// 💡 COPY-ON-READ/WRITE: nunca compartimos el puntero guardado. Si Get devolviera el mismo
+// *Hero, el llamador modificaría el estado "en DB" antes de llamar a Update
+// (y dos peticiones concurrentes tocarían el MISMO objeto).
 type Memory struct {
-	mu   sync.RWMutex
-	data map[string]*domain.Hero
+	mu     sync.RWMutex
+	data   map[string]*domain.Hero
+	outbox []ports.OutboxMessage // Solo mensajes pendientes (los enviados se descartan)
+	log    *slog.Logger
 }
 
 // NewMemory crea el repositorio en memoria.
-func NewMemory() *Memory {
+func NewMemory(logger *slog.Logger) *Memory {
 	return &Memory{
 		data: make(map[string]*domain.Hero),
+		log:  logger.With("component", "herorepo", "driver", "memory"),
 	}
 }
 
+// Ping siempre está sano: no hay conexión que perder (lo tiene para ser intercambiable con SQLite).
+func (repo *Memory) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
 // Save simula el INSERT en DB.
-func (repo *Memory) Save(hero *domain.Hero) error {
+func (repo *Memory) Save(ctx context.Context, hero *domain.Hero, events ...domain.Event) error {
+	// En memoria no hay I/O que cancelar, pero respetamos el contrato: ctx cancelado = no se hace nada.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	repo.data[hero.ID] = hero
-	fmt.Printf("💾 INFRA (DB): Guardando Hero %s en base de datos (Memoria)... Total records: %d\n", hero.Name, len(repo.data))
+	repo.data[hero.ID] = hero.Clone()
+	repo.appendOutbox(events)
+	repo.log.DebugContext(ctx, "héroe guardado", "hero_id", hero.ID, "total", len(repo.data), "events", len(events))
 	return nil
 }
 
-func (repo *Memory) Get(id string) (*domain.Hero, error) {
+func (repo *Memory) Get(ctx context.Context, id string) (*domain.Hero, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
 	hero, exists := repo.data[id]
 	if !exists {
-		return nil, fmt.Errorf("hero not found with id %s", id)
+		return nil, fmt.Errorf("%w with id %s", domain.ErrHeroNotFound, id)
 	}
-	return hero, nil
+	return hero.Clone(), nil
 }
 
-// Update actualiza un héroe existente.
-func (repo *Memory) Update(hero *domain.Hero) error {
+// Update actualiza un héroe existente (compare-and-swap sobre Version).
+func (repo *Memory) Update(ctx context.Context, hero *domain.Hero, expectedVersion int, events ...domain.Event) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	if _, exists := repo.data[hero.ID]; !exists {
-		return fmt.Errorf("hero not found with id %s", hero.ID)
+	stored, exists := repo.data[hero.ID]
+	if !exists {
+		return fmt.Errorf("%w with id %s", domain.ErrHeroNotFound, hero.ID)
+	}
+	if stored.Version != expectedVersion {
+		return fmt.Errorf("%w: hero %s expected version %d, current %d", domain.ErrVersionConflict, hero.ID, expectedVersion, stored.Version)
 	}
 
-	repo.data[hero.ID] = hero
-	fmt.Printf("🔄 INFRA (DB): Actualizando Hero %s\n", hero.Name)
+	repo.data[hero.ID] = hero.Clone()
+	repo.appendOutbox(events)
+	repo.log.DebugContext(ctx, "héroe actualizado", "hero_id", hero.ID, "version", hero.Version, "events", len(events))
 	return nil
 }
 
 // Delete elimina un héroe por ID.
-func (repo *Memory) Delete(id string) error {
+func (repo *Memory) Delete(ctx context.Context, id string, expectedVersion int, events ...domain.Event) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+
 	repo.mu.Lock()
 	defer repo.mu.Unlock()
 
-	if _, exists := repo.data[id]; !exists {
-		return fmt.Errorf("hero not found with id %s", id)
+	stored, exists := repo.data[id]
+	if !exists {
+		return fmt.Errorf("%w with id %s", domain.ErrHeroNotFound, id)
+	}
+	if stored.Version != expectedVersion {
+		return fmt.Errorf("%w: hero %s expected version %d, current %d", domain.ErrVersionConflict, id, expectedVersion, stored.Version)
 	}
 
 	delete(repo.data, id)
-	fmt.Printf("🗑️ INFRA (DB): Eliminando Hero %s. Total records: %d\n", id, len(repo.data))
+	repo.appendOutbox(events)
+	repo.log.DebugContext(ctx, "héroe eliminado", "hero_id", id, "total", len(repo.data), "events", len(events))
 	return nil
 }
 
-// List retorna todos los héroes.
-func (repo *Memory) List() ([]*domain.Hero, error) {
+// List devuelve una página de héroes.
+// 💡 Un map de Go no tiene orden (y lo aleatoriza a propósito): para paginar hay que
+// ordenar SIEMPRE por una clave total (campo + ID), si no las páginas se mezclan.
+func (repo *Memory) List(ctx context.Context, query ports.HeroQuery) (ports.HeroPage, error) {
+	if err := ctx.Err(); err != nil {
+		return ports.HeroPage{}, fmt.Errorf("%w: %w", domain.ErrUnavailable, err)
+	}
+	after, err := decodeCursor(query)
+	if err != nil {
+		return ports.HeroPage{}, err
+	}
+
 	repo.mu.RLock()
 	defer repo.mu.RUnlock()
 
-	heroes := make([]*domain.Hero, 0, len(repo.data))
+	// 1. Filtrar
+	field := sortField(query)
+	matched := make([]*domain.Hero, 0, len(repo.data))
 	for _, hero := range repo.data {
-		heroes = append(heroes, hero)
+		if matchesQuery(hero, query) {
+			matched = append(matched, hero)
+		}
 	}
 
-	fmt.Printf("📋 INFRA (DB): Listando %d héroes\n", len(heroes))
-	return heroes, nil
+	// 2. Ordenar (estable: el ID desempata)
+	direction := 1
+	if query.Desc {
+		direction = -1
+	}
+	slices.SortFunc(matched, func(a, b *domain.Hero) int {
+		return direction * keyOf(a, field).compare(keyOf(b, field))
+	})
+
+	// 3. Saltar hasta después del cursor (la lista está ordenada: búsqueda binaria)
+	start := 0
+	if after != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return direction*keyOf(matched[i], field).compare(*after) > 0
+		})
+	}
+	end := len(matched)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, len(matched))
+	}
+
+	page := ports.HeroPage{Heroes: make([]*domain.Hero, 0, end-start), Total: len(matched)}
+	for _, hero := range matched[start:end] {
+		page.Heroes = append(page.Heroes, hero.Clone())
+	}
+	if end < len(matched) {
+		page.NextCursor = encodeCursor(query, matched[end-1])
+	}
+
+	repo.log.DebugContext(ctx, "héroes listados", "count", len(page.Heroes), "total", page.Total)
+	return page, nil
+}
+
+// appendOutbox encola eventos. Debe llamarse con el lock de escritura tomado.
+func (repo *Memory) appendOutbox(events []domain.Event) {
+	for _, e := range events {
+		repo.outbox = append(repo.outbox, ports.OutboxMessage{Event: e, NextAttempt: e.Time})
+	}
+}
+
+// Pending implementa ports.Outbox.
+func (repo *Memory) Pending(ctx context.Context, limit int) ([]ports.OutboxMessage, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	now := time.Now()
+	pending := make([]ports.OutboxMessage, 0, limit)
+	waiting := make(map[string]bool) // Héroes con un mensaje anterior sin enviar
+	for _, msg := range repo.outbox {
+		if len(pending) >= limit {
+			break
+		}
+		// Solo el mensaje más viejo de cada héroe puede salir: si está en backoff,
+		// los siguientes esperan (el slice ya está en orden de creación).
+		subject := msg.Event.Subject
+		if waiting[subject] {
+			continue
+		}
+		waiting[subject] = true
+		if msg.NextAttempt.After(now) {
+			continue
+		}
+		pending = append(pending, msg)
+	}
+	return pending, nil
+}
+
+// MarkSent implementa ports.Outbox.
+// Los mensajes enviados se descartan para que el slice no crezca sin límite.
+func (repo *Memory) MarkSent(ctx context.Context, id string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i, msg := range repo.outbox {
+		if msg.Event.ID == id {
+			repo.outbox = append(repo.outbox[:i], repo.outbox[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox message not found with id %s", id)
+}
+
+// MarkFailed implementa ports.Outbox.
+func (repo *Memory) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for i := range repo.outbox {
+		if repo.outbox[i].Event.ID == id {
+			repo.outbox[i].Attempts++
+			repo.outbox[i].LastError = reason
+			repo.outbox[i].NextAttempt = nextAttempt
+			return nil
+		}
+	}
+	return fmt.Errorf("outbox message not found with id %s", id)
 }
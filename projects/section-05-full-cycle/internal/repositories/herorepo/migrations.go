@@ -0,0 +1,73 @@
+package herorepo
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// 🎓 MIGRACIONES:
+// El esquema viaja DENTRO del binario gracias a `embed`.
+// Cada archivo `NNNN_descripcion.sql` se aplica una sola vez y queda
+// registrado en la tabla `schema_migrations`.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrate aplica en orden las migraciones pendientes.
+func migrate(db *sql.DB, logger *slog.Logger) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("error creando schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return fmt.Errorf("error listando migraciones: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		version := strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql")
+
+		var applied int
+		if err := db.QueryRow(`SELECT COUNT(1) FROM schema_migrations WHERE version = ?`, version).Scan(&applied); err != nil {
+			return fmt.Errorf("error consultando migración %s: %w", version, err)
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrationsFS.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("error leyendo migración %s: %w", version, err)
+		}
+
+		// Cada migración corre en su propia transacción: o se aplica entera o nada.
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error aplicando migración %s: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error registrando migración %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		logger.Info("migración aplicada", "version", version)
+	}
+
+	return nil
+}
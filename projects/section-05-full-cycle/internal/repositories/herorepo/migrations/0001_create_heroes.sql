@@ -0,0 +1,8 @@
+-- 0001: Tabla base de héroes.
+CREATE TABLE IF NOT EXISTS heroes (
+    id         TEXT PRIMARY KEY,
+    name       TEXT    NOT NULL,
+    level      INTEGER NOT NULL DEFAULT 1,
+    power      INTEGER NOT NULL DEFAULT 10,
+    created_at TEXT    NOT NULL
+);
@@ -0,0 +1,19 @@
+-- 0002: Transactional Outbox.
+-- Los eventos se insertan en la MISMA transacción que el cambio del héroe.
+CREATE TABLE IF NOT EXISTS outbox (
+    seq          INTEGER PRIMARY KEY AUTOINCREMENT, -- Orden de inserción (el Relay publica en este orden)
+    id           TEXT    NOT NULL UNIQUE,
+    aggregate_id TEXT    NOT NULL,
+    event_type   TEXT    NOT NULL,
+    payload      TEXT    NOT NULL,                  -- Snapshot JSON del héroe
+    created_at   TEXT    NOT NULL,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    next_attempt TEXT    NOT NULL,
+    last_error   TEXT    NOT NULL DEFAULT '',
+    sent_at      TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (sent_at, seq);
+
+-- Para que Pending encuentre rápido si un héroe tiene un mensaje anterior sin enviar.
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox (aggregate_id, sent_at, seq);
@@ -0,0 +1,18 @@
+-- 0003: El payload del outbox pasa a ser el sobre CloudEvents completo (domain.Event).
+-- Los mensajes pendientes guardados con el formato anterior (solo el héroe)
+-- se envuelven para que el Relay pueda publicarlos.
+UPDATE outbox
+   SET payload = json_object(
+         'id',              id,
+         'type',            event_type,
+         'source',          '/section-05/hero-service',
+         'specversion',     '1.0',
+         'subject',         aggregate_id,
+         'time',            created_at,
+         'datacontenttype', 'application/json',
+         'schemaversion',   1,
+         'correlationid',   id,
+         'data',            json(payload)
+       )
+ WHERE sent_at IS NULL
+   AND json_extract(payload, '$.specversion') IS NULL;
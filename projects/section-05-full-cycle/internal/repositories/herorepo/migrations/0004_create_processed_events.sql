@@ -0,0 +1,5 @@
+-- 0004: Eventos ya procesados por el consumer (Idempotent Consumer).
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id     TEXT PRIMARY KEY,
+    processed_at TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+-- 0005: Experiencia acumulada del héroe (progresión).
+ALTER TABLE heroes ADD COLUMN xp INTEGER NOT NULL DEFAULT 0;
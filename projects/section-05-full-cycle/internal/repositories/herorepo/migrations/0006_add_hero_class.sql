@@ -0,0 +1,11 @@
+-- 0006: Clases, atributos y puntos de atributo sin asignar.
+-- Los héroes existentes pasan a ser "warrior" con sus atributos base,
+-- y reciben los puntos que habrían ganado por los niveles ya subidos (5 por nivel).
+ALTER TABLE heroes ADD COLUMN class       TEXT    NOT NULL DEFAULT 'warrior';
+ALTER TABLE heroes ADD COLUMN str         INTEGER NOT NULL DEFAULT 12;
+ALTER TABLE heroes ADD COLUMN agi         INTEGER NOT NULL DEFAULT 8;
+ALTER TABLE heroes ADD COLUMN int         INTEGER NOT NULL DEFAULT 4;
+ALTER TABLE heroes ADD COLUMN vit         INTEGER NOT NULL DEFAULT 12;
+ALTER TABLE heroes ADD COLUMN stat_points INTEGER NOT NULL DEFAULT 0;
+
+UPDATE heroes SET stat_points = (level - 1) * 5;
@@ -0,0 +1,5 @@
+-- 0007: Inventario y equipo.
+-- Se guardan como JSON dentro de la fila del héroe: siempre se leen y escriben
+-- junto con él (son parte del agregado) y nunca se consultan por separado.
+ALTER TABLE heroes ADD COLUMN inventory TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE heroes ADD COLUMN equipment TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+-- 0008: Versión del héroe para concurrencia optimista (compare-and-swap en UPDATE).
+ALTER TABLE heroes ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- 0009: Índices para listar ordenado y paginar por cursor (campo + id como desempate).
+CREATE INDEX IF NOT EXISTS idx_heroes_created_at ON heroes (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_heroes_name       ON heroes (name, id);
+CREATE INDEX IF NOT EXISTS idx_heroes_level      ON heroes (level, id);
+CREATE INDEX IF NOT EXISTS idx_heroes_power      ON heroes (power, id);
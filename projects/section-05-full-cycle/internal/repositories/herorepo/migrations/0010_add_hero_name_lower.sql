@@ -0,0 +1,5 @@
+-- 0010: Nombre normalizado para el filtro name_prefix (sin distinguir mayúsculas, también fuera de ASCII).
+-- Lo escribe el código con foldName; acá se completa para los héroes existentes.
+ALTER TABLE heroes ADD COLUMN name_lower TEXT NOT NULL DEFAULT '';
+UPDATE heroes SET name_lower = go_fold_name(name);
+CREATE INDEX IF NOT EXISTS idx_heroes_name_lower ON heroes (name_lower, id);
@@ -0,0 +1,6 @@
+-- 0011: Fechas viejas (RFC3339Nano, ancho variable) al formato de ancho fijo (timeLayout, UTC).
+-- Con ancho variable "…:00Z" queda DESPUÉS de "…:00.5Z" al comparar texto: el orden y los cursores fallan.
+UPDATE heroes SET created_at = go_fixed_time(created_at) WHERE length(created_at) <> 30;
+UPDATE outbox SET created_at   = go_fixed_time(created_at)   WHERE length(created_at)   <> 30;
+UPDATE outbox SET next_attempt = go_fixed_time(next_attempt) WHERE length(next_attempt) <> 30;
+UPDATE outbox SET sent_at      = go_fixed_time(sent_at)      WHERE length(sent_at)      <> 30;
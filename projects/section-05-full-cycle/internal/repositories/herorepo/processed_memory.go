@@ -0,0 +1,62 @@
+package herorepo
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProcessedMemory es un ports.ProcessedEventStore en memoria con TTL.
+// Los IDs caducan tras `ttl`: basta con cubrir la ventana en la que Kafka
+// puede re-entregar un mensaje (rebalance, reinicio). No sobrevive a un reinicio.
+type ProcessedMemory struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	seen      map[string]time.Time // eventID -> instante de expiración
+	lastPurge time.Time
+}
+
+// NewProcessedMemory crea el store con el TTL indicado.
+func NewProcessedMemory(ttl time.Duration) *ProcessedMemory {
+	return &ProcessedMemory{
+		ttl:       ttl,
+		seen:      make(map[string]time.Time),
+		lastPurge: time.Now(),
+	}
+}
+
+// IsProcessed implementa ports.ProcessedEventStore.
+func (s *ProcessedMemory) IsProcessed(_ context.Context, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.seen[eventID]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.seen, eventID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// MarkProcessed implementa ports.ProcessedEventStore.
+func (s *ProcessedMemory) MarkProcessed(_ context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.seen[eventID] = now.Add(s.ttl)
+
+	// Limpieza perezosa: como mucho una vez por TTL, para que el mapa no crezca sin límite.
+	if now.Sub(s.lastPurge) >= s.ttl {
+		for id, expiresAt := range s.seen {
+			if now.After(expiresAt) {
+				delete(s.seen, id)
+			}
+		}
+		s.lastPurge = now
+	}
+	return nil
+}
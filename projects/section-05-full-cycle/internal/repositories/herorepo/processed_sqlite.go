@@ -0,0 +1,37 @@
+package herorepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+)
+
+// IsProcessed implementa ports.ProcessedEventStore (persistente: sobrevive a reinicios).
+func (repo *SQLite) IsProcessed(ctx context.Context, eventID string) (bool, error) {
+	var id string
+	err := repo.db.QueryRowContext(ctx, `SELECT event_id FROM processed_events WHERE event_id = ?`, eventID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: error consultando evento procesado %s: %w", domain.ErrUnavailable, eventID, err)
+	}
+	return true, nil
+}
+
+// MarkProcessed implementa ports.ProcessedEventStore.
+// INSERT OR IGNORE: marcar dos veces el mismo evento no es un error.
+func (repo *SQLite) MarkProcessed(ctx context.Context, eventID string) error {
+	_, err := repo.db.ExecContext(ctx,
+		`INSERT OR IGNORE INTO processed_events (event_id, processed_at) VALUES (?, ?)`,
+		eventID, formatTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("%w: error marcando evento procesado %s: %w", domain.ErrUnavailable, eventID, err)
+	}
+	return nil
+}
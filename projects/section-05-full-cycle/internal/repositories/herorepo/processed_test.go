@@ -0,0 +1,81 @@
+package herorepo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProcessedMemoryExpiresAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	const ttl = 30 * time.Millisecond
+	store := NewProcessedMemory(ttl)
+
+	if err := store.MarkProcessed(ctx, "evt-1"); err != nil {
+		t.Fatalf("MarkProcessed: %v", err)
+	}
+	if done, _ := store.IsProcessed(ctx, "evt-1"); !done {
+		t.Fatal("IsProcessed right after MarkProcessed = false, want true")
+	}
+	if done, _ := store.IsProcessed(ctx, "evt-2"); done {
+		t.Error("IsProcessed(unknown) = true, want false")
+	}
+
+	time.Sleep(ttl + 10*time.Millisecond)
+	if done, _ := store.IsProcessed(ctx, "evt-1"); done {
+		t.Error("IsProcessed after TTL = true, want false")
+	}
+}
+
+// La limpieza perezosa borra los IDs caducados aunque nadie vuelva a consultarlos.
+func TestProcessedMemoryPurgesExpiredIDs(t *testing.T) {
+	ctx := context.Background()
+	const ttl = 30 * time.Millisecond
+	store := NewProcessedMemory(ttl)
+
+	for _, id := range []string{"evt-1", "evt-2", "evt-3"} {
+		if err := store.MarkProcessed(ctx, id); err != nil {
+			t.Fatalf("MarkProcessed(%s): %v", id, err)
+		}
+	}
+
+	time.Sleep(ttl + 10*time.Millisecond)
+	if err := store.MarkProcessed(ctx, "evt-4"); err != nil {
+		t.Fatalf("MarkProcessed: %v", err)
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.seen) != 1 {
+		t.Errorf("seen has %d IDs after purge, want 1 (evt-4)", len(store.seen))
+	}
+}
+
+func TestProcessedSQLiteSurvivesReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "heroes.db")
+
+	first, err := NewSQLite(path, discardLogger())
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	if err := first.MarkProcessed(ctx, "evt-1"); err != nil {
+		t.Fatalf("MarkProcessed: %v", err)
+	}
+	// Marcar dos veces el mismo evento no es un error (INSERT OR IGNORE).
+	if err := first.MarkProcessed(ctx, "evt-1"); err != nil {
+		t.Fatalf("MarkProcessed twice: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	second := openSQLite(t, path)
+	if done, err := second.IsProcessed(ctx, "evt-1"); err != nil || !done {
+		t.Errorf("IsProcessed(evt-1) after reopen = %v, %v; want true, nil", done, err)
+	}
+	if done, err := second.IsProcessed(ctx, "evt-2"); err != nil || done {
+		t.Errorf("IsProcessed(evt-2) = %v, %v; want false, nil", done, err)
+	}
+}
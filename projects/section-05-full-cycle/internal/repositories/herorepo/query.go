@@ -0,0 +1,107 @@
+package herorepo
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+)
+
+// sortKey es la posición de un héroe en un orden: el valor del campo + el ID (desempate).
+// Solo se usa Num o Str, según el campo (level/power son números; name/created_at, texto).
+type sortKey struct {
+	Num int    `json:"n,omitempty"`
+	Str string `json:"s,omitempty"`
+	ID  string `json:"id"`
+}
+
+// keyOf calcula la clave de orden de un héroe.
+// 💡 created_at usa el mismo formato que la columna de SQLite (ancho fijo): así la clave
+// ordena igual en memoria (comparando strings) que en SQL.
+func keyOf(hero *domain.Hero, field ports.HeroSortField) sortKey {
+	switch field {
+	case ports.SortByName:
+		return sortKey{Str: hero.Name, ID: hero.ID}
+	case ports.SortByLevel:
+		return sortKey{Num: hero.Level, ID: hero.ID}
+	case ports.SortByPower:
+		return sortKey{Num: hero.Power, ID: hero.ID}
+	default:
+		return sortKey{Str: formatTime(hero.CreatedAt), ID: hero.ID}
+	}
+}
+
+// compare ordena dos claves de forma ascendente.
+func (k sortKey) compare(other sortKey) int {
+	return cmp.Or(
+		cmp.Compare(k.Num, other.Num),
+		strings.Compare(k.Str, other.Str),
+		strings.Compare(k.ID, other.ID),
+	)
+}
+
+// cursor es lo que viaja (en base64) dentro de HeroQuery.Cursor.
+// Guarda el orden con el que se generó: no sirve para un listado ordenado distinto.
+type cursor struct {
+	Sort ports.HeroSortField `json:"sort"`
+	Desc bool                `json:"desc,omitempty"`
+	Last sortKey             `json:"last"`
+}
+
+// encodeCursor genera el cursor que apunta DESPUÉS del último héroe de la página.
+func encodeCursor(query ports.HeroQuery, last *domain.Hero) string {
+	data, _ := json.Marshal(cursor{Sort: sortField(query), Desc: query.Desc, Last: keyOf(last, sortField(query))})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor devuelve la clave desde la que continuar (nil = primera página).
+func decodeCursor(query ports.HeroQuery) (*sortKey, error) {
+	if query.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidCursor, err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidCursor, err)
+	}
+	if c.Sort != sortField(query) || c.Desc != query.Desc {
+		return nil, fmt.Errorf("%w: cursor was created for another sort order", domain.ErrInvalidCursor)
+	}
+	return &c.Last, nil
+}
+
+// sortField aplica el orden por defecto (created_at).
+func sortField(query ports.HeroQuery) ports.HeroSortField {
+	if query.SortBy == "" {
+		return ports.SortByCreatedAt
+	}
+	return query.SortBy
+}
+
+// matchesQuery aplica los filtros de la consulta a un héroe.
+func matchesQuery(hero *domain.Hero, query ports.HeroQuery) bool {
+	if query.MinLevel > 0 && hero.Level < query.MinLevel {
+		return false
+	}
+	if query.Class != "" && hero.Class != query.Class {
+		return false
+	}
+	if query.NamePrefix != "" && !strings.HasPrefix(foldName(hero.Name), foldName(query.NamePrefix)) {
+		return false
+	}
+	return true
+}
+
+// foldName normaliza un nombre para el filtro name_prefix (sin distinguir mayúsculas, Unicode).
+// 💡 Es la ÚNICA definición: Memory la usa al filtrar y SQLite al guardar la columna name_lower
+// (el LIKE de SQLite solo ignora mayúsculas en ASCII), así los dos adapters devuelven lo mismo.
+func foldName(name string) string {
+	return strings.ToLower(name)
+}
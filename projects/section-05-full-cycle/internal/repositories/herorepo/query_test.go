@@ -0,0 +1,116 @@
+package herorepo
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+)
+
+// Los dos adapters tienen que filtrar name_prefix igual, también con letras fuera de ASCII.
+func TestNamePrefixMatchesAcrossAdapters(t *testing.T) {
+	names := []string{"Ángel", "ángela", "Arthas", "arthur", "Éowyn", "Jaina", "100%_hero"}
+	prefixes := []string{"á", "ÁNG", "ar", "AR", "é", "ÉO", "e", "j", "100%", "100%_", "1_0", "zz"}
+
+	ctx := context.Background()
+	repos := map[string]ports.HeroRepository{
+		"memory": NewMemory(discardLogger()),
+		"sqlite": openSQLite(t, filepath.Join(t.TempDir(), "heroes.db")),
+	}
+	for _, repo := range repos {
+		for i, name := range names {
+			hero, err := domain.NewHero(string(rune('a'+i)), name, domain.ClassWarrior)
+			if err != nil {
+				t.Fatalf("NewHero(%q): %v", name, err)
+			}
+			if err := repo.Save(ctx, hero); err != nil {
+				t.Fatalf("Save(%q): %v", name, err)
+			}
+		}
+	}
+
+	for _, prefix := range prefixes {
+		got := make(map[string][]string)
+		for adapter, repo := range repos {
+			page, err := repo.List(ctx, ports.HeroQuery{NamePrefix: prefix, SortBy: ports.SortByName})
+			if err != nil {
+				t.Fatalf("%s List(%q): %v", adapter, prefix, err)
+			}
+			for _, hero := range page.Heroes {
+				got[adapter] = append(got[adapter], hero.Name)
+			}
+		}
+		if !slices.Equal(got["memory"], got["sqlite"]) {
+			t.Errorf("name_prefix %q: memory %v, sqlite %v", prefix, got["memory"], got["sqlite"])
+		}
+	}
+
+	page, err := repos["sqlite"].List(ctx, ports.HeroQuery{NamePrefix: "ÁNG", SortBy: ports.SortByName})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Heroes) != 2 {
+		t.Errorf("name_prefix ÁNG matched %d heroes, want 2 (Ángel, ángela)", len(page.Heroes))
+	}
+}
+
+// Una base creada antes de 0010/0011 (nombres sin name_lower, fechas RFC3339Nano de ancho
+// variable) queda normalizada al volver a abrirla.
+func TestMigrationsNormalizeExistingRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heroes.db")
+	repo := openSQLite(t, path)
+	ctx := context.Background()
+
+	created := time.Date(2025, 12, 18, 16, 0, 0, 500_000_000, time.FixedZone("ART", -3*3600))
+	hero, err := domain.NewHero("h-1", "Ángel", domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero: %v", err)
+	}
+	hero.CreatedAt = created
+	if err := repo.Save(ctx, hero, domain.NewHeroEvent("evt-1", domain.EventHeroCreated, hero)); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Simular los datos viejos y "desaplicar" las migraciones que los normalizan
+	legacy := created.Format(time.RFC3339Nano) // "2025-12-18T16:00:00.5-03:00"
+	mustExec(t, repo.db, `UPDATE heroes SET created_at = ?, name_lower = ''`, legacy)
+	mustExec(t, repo.db, `UPDATE outbox SET created_at = ?, next_attempt = ?`, legacy, legacy)
+	mustExec(t, repo.db, `DELETE FROM schema_migrations WHERE version = '0011_fixed_width_times'`)
+	mustExec(t, repo.db, `UPDATE heroes SET name_lower = go_fold_name(name)`) // Lo que hace 0010
+	repo.Close()
+
+	repo = openSQLite(t, path)
+	var createdAt, nameLower, nextAttempt string
+	if err := repo.db.QueryRow(`SELECT created_at, name_lower FROM heroes WHERE id = 'h-1'`).Scan(&createdAt, &nameLower); err != nil {
+		t.Fatalf("select hero: %v", err)
+	}
+	if err := repo.db.QueryRow(`SELECT next_attempt FROM outbox WHERE id = 'evt-1'`).Scan(&nextAttempt); err != nil {
+		t.Fatalf("select outbox: %v", err)
+	}
+	if want := formatTime(created); createdAt != want || nextAttempt != want {
+		t.Errorf("created_at = %q, next_attempt = %q, want %q", createdAt, nextAttempt, want)
+	}
+	if nameLower != "ángel" {
+		t.Errorf("name_lower = %q, want %q", nameLower, "ángel")
+	}
+
+	got, err := repo.Get(ctx, "h-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, created)
+	}
+}
+
+func mustExec(t *testing.T, db *sql.DB, query string, args ...any) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
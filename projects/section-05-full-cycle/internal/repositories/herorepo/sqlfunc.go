@@ -0,0 +1,41 @@
+package herorepo
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// 🎓 FUNCIONES SQL ESCRITAS EN GO
+// Algunas migraciones necesitan EXACTAMENTE la misma lógica que el código Go:
+// lower() de SQLite solo entiende ASCII ("Ángel" quedaría "Ángel") y las fechas viejas
+// hay que reescribirlas con timeLayout. El driver deja registrar funciones propias
+// que después se usan en SQL como cualquier otra: UPDATE heroes SET x = go_fold_name(name).
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("go_fold_name", 1, sqlFoldName)
+	sqlite.MustRegisterDeterministicScalarFunction("go_fixed_time", 1, sqlFixedTime)
+}
+
+// sqlFoldName es foldName para SQL (NULL queda NULL).
+func sqlFoldName(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	name, ok := args[0].(string)
+	if !ok {
+		return args[0], nil
+	}
+	return foldName(name), nil
+}
+
+// sqlFixedTime reescribe una fecha RFC3339 (de cualquier ancho y zona) con timeLayout en UTC.
+func sqlFixedTime(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	value, ok := args[0].(string)
+	if !ok {
+		return args[0], nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("fecha inválida %q: %w", value, err)
+	}
+	return formatTime(t), nil
+}
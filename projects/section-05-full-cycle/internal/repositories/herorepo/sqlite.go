@@ -0,0 +1,451 @@
+package herorepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+
+	// Driver SQLite escrito en Go puro (sin CGO): se registra como "sqlite".
+	_ "modernc.org/sqlite"
+)
+
+// timeLayout es RFC3339 con ancho FIJO (nanosegundos con ceros a la derecha).
+// Así las fechas guardadas como TEXT se pueden comparar/ordenar lexicográficamente.
+const timeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// heroColumns es el orden de columnas que espera scanHero.
+const heroColumns = `id, name, class, level, power, xp, str, agi, int, vit, stat_points, inventory, equipment, version, created_at`
+
+// SQLite es un adaptador REAL de base de datos.
+// A diferencia de Memory, los héroes sobreviven a un reinicio del proceso.
+// También implementa ports.Outbox (tabla `outbox`).
+type SQLite struct {
+	db  *sql.DB
+	log *slog.Logger
+}
+
+// NewSQLite abre (o crea) el archivo de base de datos y aplica las migraciones.
+func NewSQLite(path string, logger *slog.Logger) (*SQLite, error) {
+	logger = logger.With("component", "herorepo", "driver", "sqlite")
+
+	// 🎓 PRAGMAS:
+	// - busy_timeout: espera en vez de fallar si otro proceso tiene el lock.
+	// - journal_mode(WAL): lectores y escritor no se bloquean entre sí.
+	// - foreign_keys: SQLite las trae apagadas por defecto.
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)", path)
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo sqlite: %w", err)
+	}
+
+	// SQLite admite un único escritor: una sola conexión evita "database is locked".
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error conectando a sqlite: %w", err)
+	}
+
+	if err := migrate(db, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Info("sqlite abierto", "path", path)
+	return &SQLite{db: db, log: logger}, nil
+}
+
+// Save hace el INSERT en DB (héroe + eventos del outbox en una sola transacción).
+func (repo *SQLite) Save(ctx context.Context, hero *domain.Hero, events ...domain.Event) error {
+	inventory, equipment, err := marshalItems(hero)
+	if err != nil {
+		return err
+	}
+
+	err = repo.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO heroes (`+heroColumns+`, name_lower) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			hero.ID, hero.Name, string(hero.Class), hero.Level, hero.Power, hero.XP,
+			hero.Attributes.STR, hero.Attributes.AGI, hero.Attributes.INT, hero.Attributes.VIT, hero.StatPoints,
+			inventory, equipment, hero.Version, formatTime(hero.CreatedAt), foldName(hero.Name),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: error insertando hero %s: %w", domain.ErrUnavailable, hero.ID, err)
+		}
+		return insertOutbox(ctx, tx, events)
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.log.DebugContext(ctx, "héroe guardado", "hero_id", hero.ID, "events", len(events))
+	return nil
+}
+
+// Get busca un héroe por ID.
+func (repo *SQLite) Get(ctx context.Context, id string) (*domain.Hero, error) {
+	row := repo.db.QueryRowContext(ctx, `SELECT `+heroColumns+` FROM heroes WHERE id = ?`, id)
+
+	hero, err := scanHero(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w with id %s", domain.ErrHeroNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: error leyendo hero %s: %w", domain.ErrUnavailable, id, err)
+	}
+	return hero, nil
+}
+
+// Update actualiza un héroe existente.
+// 🎓 COMPARE-AND-SWAP: el "AND version = ?" hace que el UPDATE no toque ninguna fila
+// si otro escribió antes. La comprobación y la escritura son UNA sola sentencia (atómica).
+func (repo *SQLite) Update(ctx context.Context, hero *domain.Hero, expectedVersion int, events ...domain.Event) error {
+	inventory, equipment, err := marshalItems(hero)
+	if err != nil {
+		return err
+	}
+
+	err = repo.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE heroes
+			    SET name = ?, name_lower = ?, class = ?, level = ?, power = ?, xp = ?,
+			        str = ?, agi = ?, int = ?, vit = ?, stat_points = ?,
+			        inventory = ?, equipment = ?, version = ?
+			  WHERE id = ? AND version = ?`,
+			hero.Name, foldName(hero.Name), string(hero.Class), hero.Level, hero.Power, hero.XP,
+			hero.Attributes.STR, hero.Attributes.AGI, hero.Attributes.INT, hero.Attributes.VIT, hero.StatPoints,
+			inventory, equipment, hero.Version,
+			hero.ID, expectedVersion,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: error actualizando hero %s: %w", domain.ErrUnavailable, hero.ID, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return versionMismatch(ctx, tx, hero.ID, expectedVersion)
+		}
+		return insertOutbox(ctx, tx, events)
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.log.DebugContext(ctx, "héroe actualizado", "hero_id", hero.ID, "version", hero.Version, "events", len(events))
+	return nil
+}
+
+// Delete elimina un héroe por ID.
+func (repo *SQLite) Delete(ctx context.Context, id string, expectedVersion int, events ...domain.Event) error {
+	err := repo.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM heroes WHERE id = ? AND version = ?`, id, expectedVersion)
+		if err != nil {
+			return fmt.Errorf("%w: error eliminando hero %s: %w", domain.ErrUnavailable, id, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return versionMismatch(ctx, tx, id, expectedVersion)
+		}
+		return insertOutbox(ctx, tx, events)
+	})
+	if err != nil {
+		return err
+	}
+
+	repo.log.DebugContext(ctx, "héroe eliminado", "hero_id", id, "events", len(events))
+	return nil
+}
+
+// sortColumns traduce cada campo de orden a su columna.
+var sortColumns = map[ports.HeroSortField]string{
+	ports.SortByCreatedAt: "created_at",
+	ports.SortByName:      "name",
+	ports.SortByLevel:     "level",
+	ports.SortByPower:     "power",
+}
+
+// List devuelve una página de héroes.
+// 🎓 KEYSET: en vez de OFFSET (que recorre y descarta filas), el cursor se traduce a
+// "WHERE (col > último) OR (col = último AND id > último_id)", que usa el orden del índice.
+func (repo *SQLite) List(ctx context.Context, query ports.HeroQuery) (ports.HeroPage, error) {
+	after, err := decodeCursor(query)
+	if err != nil {
+		return ports.HeroPage{}, err
+	}
+	field := sortField(query)
+	column, ok := sortColumns[field]
+	if !ok {
+		return ports.HeroPage{}, fmt.Errorf("%w: unknown sort field %q", domain.ErrInvalidQuery, field)
+	}
+
+	// 1. Filtros (los mismos para el total y para la página)
+	var (
+		where []string
+		args  []any
+	)
+	if query.MinLevel > 0 {
+		where, args = append(where, "level >= ?"), append(args, query.MinLevel)
+	}
+	if query.Class != "" {
+		where, args = append(where, "class = ?"), append(args, string(query.Class))
+	}
+	if query.NamePrefix != "" {
+		// name_lower ya está normalizado con foldName (igual que Memory): el LIKE compara minúsculas
+		where, args = append(where, `name_lower LIKE ? ESCAPE '\'`), append(args, likePrefix(foldName(query.NamePrefix)))
+	}
+
+	var page ports.HeroPage
+	if err := repo.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM heroes`+whereClause(where), args...).Scan(&page.Total); err != nil {
+		return ports.HeroPage{}, fmt.Errorf("%w: error contando heroes: %w", domain.ErrUnavailable, err)
+	}
+
+	// 2. Cursor + orden
+	op, dir := ">", "ASC"
+	if query.Desc {
+		op, dir = "<", "DESC"
+	}
+	if after != nil {
+		var last any = after.Str
+		if field == ports.SortByLevel || field == ports.SortByPower {
+			last = after.Num
+		}
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, op))
+		args = append(args, last, last, after.ID)
+	}
+	sqlQuery := `SELECT ` + heroColumns + ` FROM heroes` + whereClause(where) +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", column, dir, dir)
+	if query.Limit > 0 {
+		// Pedimos uno de más para saber si hay página siguiente
+		sqlQuery += " LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+
+	rows, err := repo.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return ports.HeroPage{}, fmt.Errorf("%w: error listando heroes: %w", domain.ErrUnavailable, err)
+	}
+	defer rows.Close()
+
+	page.Heroes = make([]*domain.Hero, 0)
+	for rows.Next() {
+		hero, err := scanHero(rows)
+		if err != nil {
+			return ports.HeroPage{}, fmt.Errorf("error leyendo fila: %w", err)
+		}
+		page.Heroes = append(page.Heroes, hero)
+	}
+	if err := rows.Err(); err != nil {
+		return ports.HeroPage{}, fmt.Errorf("%w: error iterando heroes: %w", domain.ErrUnavailable, err)
+	}
+
+	if query.Limit > 0 && len(page.Heroes) > query.Limit {
+		page.Heroes = page.Heroes[:query.Limit]
+		page.NextCursor = encodeCursor(query, page.Heroes[query.Limit-1])
+	}
+
+	repo.log.DebugContext(ctx, "héroes listados", "count", len(page.Heroes), "total", page.Total)
+	return page, nil
+}
+
+// whereClause une las condiciones con AND ("" si no hay ninguna).
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// likePrefix arma el patrón LIKE "prefijo%" escapando los comodines del usuario.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// Pending implementa ports.Outbox.
+// El NOT EXISTS deja pasar solo el mensaje más viejo sin enviar de cada héroe:
+// si ese está en backoff, los siguientes esperan aunque su next_attempt ya haya pasado.
+func (repo *SQLite) Pending(ctx context.Context, limit int) ([]ports.OutboxMessage, error) {
+	rows, err := repo.db.QueryContext(ctx,
+		`SELECT payload, attempts, next_attempt, last_error
+		   FROM outbox
+		  WHERE sent_at IS NULL AND next_attempt <= ?
+		    AND NOT EXISTS (SELECT 1 FROM outbox o2
+		                     WHERE o2.aggregate_id = outbox.aggregate_id
+		                       AND o2.sent_at IS NULL AND o2.seq < outbox.seq)
+		  ORDER BY seq
+		  LIMIT ?`,
+		formatTime(time.Now()), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: error leyendo outbox: %w", domain.ErrUnavailable, err)
+	}
+	defer rows.Close()
+
+	pending := make([]ports.OutboxMessage, 0, limit)
+	for rows.Next() {
+		var (
+			msg                  ports.OutboxMessage
+			payload, nextAttempt string
+		)
+		if err := rows.Scan(&payload, &msg.Attempts, &nextAttempt, &msg.LastError); err != nil {
+			return nil, fmt.Errorf("error leyendo fila del outbox: %w", err)
+		}
+		if err := json.Unmarshal([]byte(payload), &msg.Event); err != nil {
+			return nil, fmt.Errorf("payload inválido en outbox: %w", err)
+		}
+		if msg.NextAttempt, err = time.Parse(time.RFC3339Nano, nextAttempt); err != nil {
+			return nil, fmt.Errorf("next_attempt inválido en outbox %s: %w", msg.Event.ID, err)
+		}
+		pending = append(pending, msg)
+	}
+	return pending, rows.Err()
+}
+
+// MarkSent implementa ports.Outbox.
+func (repo *SQLite) MarkSent(ctx context.Context, id string) error {
+	_, err := repo.db.ExecContext(ctx, `UPDATE outbox SET sent_at = ? WHERE id = ?`, formatTime(time.Now()), id)
+	if err != nil {
+		return fmt.Errorf("%w: error marcando outbox %s como enviado: %w", domain.ErrUnavailable, id, err)
+	}
+	return nil
+}
+
+// MarkFailed implementa ports.Outbox.
+func (repo *SQLite) MarkFailed(ctx context.Context, id string, reason string, nextAttempt time.Time) error {
+	_, err := repo.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = ?, next_attempt = ? WHERE id = ?`,
+		reason, formatTime(nextAttempt), id,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: error registrando fallo del outbox %s: %w", domain.ErrUnavailable, id, err)
+	}
+	return nil
+}
+
+// Ping verifica que la base responda (health check de /readyz).
+func (repo *SQLite) Ping(ctx context.Context) error {
+	if err := repo.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("%w: sqlite ping: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+// Close cierra la conexión (se debe llamar al apagar el servicio).
+func (repo *SQLite) Close() error {
+	return repo.db.Close()
+}
+
+// inTx ejecuta fn dentro de una transacción: commit si devuelve nil, rollback si no.
+func (repo *SQLite) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%w: error iniciando transacción: %w", domain.ErrUnavailable, err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: error haciendo commit: %w", domain.ErrUnavailable, err)
+	}
+	return nil
+}
+
+// insertOutbox guarda los eventos (sobre completo en JSON) dentro de la transacción en curso.
+func insertOutbox(ctx context.Context, tx *sql.Tx, events []domain.Event) error {
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("error serializando evento %s: %w", e.Type, err)
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO outbox (id, aggregate_id, event_type, payload, created_at, next_attempt) VALUES (?, ?, ?, ?, ?, ?)`,
+			e.ID, e.Subject, string(e.Type), string(payload), formatTime(e.Time), formatTime(e.Time),
+		)
+		if err != nil {
+			return fmt.Errorf("%w: error insertando evento %s en outbox: %w", domain.ErrUnavailable, e.Type, err)
+		}
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// versionMismatch explica por qué un UPDATE/DELETE con "AND version = ?" no tocó filas:
+// o el héroe no existe, o su versión cambió (conflicto).
+func versionMismatch(ctx context.Context, tx *sql.Tx, id string, expectedVersion int) error {
+	var current int
+	err := tx.QueryRowContext(ctx, `SELECT version FROM heroes WHERE id = ?`, id).Scan(&current)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w with id %s", domain.ErrHeroNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: error leyendo versión de hero %s: %w", domain.ErrUnavailable, id, err)
+	}
+	return fmt.Errorf("%w: hero %s expected version %d, current %d", domain.ErrVersionConflict, id, expectedVersion, current)
+}
+
+// marshalItems serializa inventario y equipo a JSON para sus columnas.
+func marshalItems(hero *domain.Hero) (string, string, error) {
+	inventory := hero.Inventory
+	if inventory == nil {
+		inventory = []domain.InventoryStack{} // "[]" y no "null"
+	}
+	equipment := hero.Equipment
+	if equipment == nil {
+		equipment = map[domain.ItemSlot]string{}
+	}
+
+	inv, err := json.Marshal(inventory)
+	if err != nil {
+		return "", "", fmt.Errorf("error serializando inventory de %s: %w", hero.ID, err)
+	}
+	eq, err := json.Marshal(equipment)
+	if err != nil {
+		return "", "", fmt.Errorf("error serializando equipment de %s: %w", hero.ID, err)
+	}
+	return string(inv), string(eq), nil
+}
+
+// scanner abstrae *sql.Row y *sql.Rows para reutilizar el mapeo fila -> Hero.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanHero(s scanner) (*domain.Hero, error) {
+	var (
+		hero                 domain.Hero
+		inventory, equipment string
+		createdAt            string
+	)
+	err := s.Scan(
+		&hero.ID, &hero.Name, &hero.Class, &hero.Level, &hero.Power, &hero.XP,
+		&hero.Attributes.STR, &hero.Attributes.AGI, &hero.Attributes.INT, &hero.Attributes.VIT, &hero.StatPoints,
+		&inventory, &equipment, &hero.Version, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(inventory), &hero.Inventory); err != nil {
+		return nil, fmt.Errorf("inventory inválido para hero %s: %w", hero.ID, err)
+	}
+	if err := json.Unmarshal([]byte(equipment), &hero.Equipment); err != nil {
+		return nil, fmt.Errorf("equipment inválido para hero %s: %w", hero.ID, err)
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("created_at inválido %q: %w", createdAt, err)
+	}
+	hero.CreatedAt = t
+
+	return &hero, nil
+}
@@ -0,0 +1,344 @@
+package herorepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/domain"
+	"github.com/EELorenzoni/rpg-microservices-learning/section-05/internal/core/ports"
+)
+
+// discardLogger descarta los logs (los tests verifican comportamiento, no mensajes).
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func openSQLite(t *testing.T, path string) *SQLite {
+	t.Helper()
+	repo, err := NewSQLite(path, discardLogger())
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func newTestHero(t *testing.T, id, name string) *domain.Hero {
+	t.Helper()
+	hero, err := domain.NewHero(id, name, domain.ClassWarrior)
+	if err != nil {
+		t.Fatalf("NewHero(%q): %v", name, err)
+	}
+	return hero
+}
+
+// testRepositoryContract es el contrato que cumple cualquier HeroRepository:
+// Memory y SQLite corren exactamente los mismos casos.
+func testRepositoryContract(t *testing.T, newRepo func(t *testing.T) ports.HeroRepository) {
+	ctx := context.Background()
+	t.Run("save and get", func(t *testing.T) {
+		repo := newRepo(t)
+		hero := newTestHero(t, "h-1", "Arthas")
+		if err := repo.Save(ctx, hero); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		got, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.ID != hero.ID || got.Name != hero.Name || got.Level != hero.Level || got.Power != hero.Power {
+			t.Errorf("Get = %+v, want %+v", got, hero)
+		}
+		if !got.CreatedAt.Equal(hero.CreatedAt) {
+			t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, hero.CreatedAt)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, newTestHero(t, "h-1", "Arthas")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		hero, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		hero.Level, hero.Power, hero.Version = 2, 20, 2
+		if err := repo.Update(ctx, hero, 1); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+
+		got, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Level != 2 || got.Power != 20 || got.Version != 2 {
+			t.Errorf("after Update: level %d power %d version %d, want 2, 20 and 2", got.Level, got.Power, got.Version)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, newTestHero(t, "h-1", "Arthas")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		if err := repo.Delete(ctx, "h-1", 1); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+		if _, err := repo.Get(ctx, "h-1"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Get after Delete = %v, want %v", err, domain.ErrNotFound)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		repo := newRepo(t)
+		for i, name := range []string{"Jaina", "Arthas", "Thrall"} {
+			hero := newTestHero(t, string(rune('a'+i)), name)
+			hero.CreatedAt = hero.CreatedAt.Add(time.Duration(i) * time.Second)
+			if err := repo.Save(ctx, hero); err != nil {
+				t.Fatalf("Save(%q): %v", name, err)
+			}
+		}
+
+		// Por nombre, de a 2: la segunda página sale del cursor de la primera.
+		var names []string
+		query := ports.HeroQuery{SortBy: ports.SortByName, Limit: 2}
+		for pages := 0; ; pages++ {
+			if pages == 3 {
+				t.Fatal("List did not finish after 3 pages")
+			}
+			page, err := repo.List(ctx, query)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if page.Total != 3 {
+				t.Errorf("Total = %d, want 3", page.Total)
+			}
+			for _, hero := range page.Heroes {
+				names = append(names, hero.Name)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+		if want := []string{"Arthas", "Jaina", "Thrall"}; !slices.Equal(names, want) {
+			t.Errorf("List names = %v, want %v", names, want)
+		}
+	})
+
+	t.Run("stale version", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, newTestHero(t, "h-1", "Arthas")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		stale := newTestHero(t, "h-1", "Arthas")
+		stale.Name, stale.Version = "Lich King", 3
+		if err := repo.Update(ctx, stale, 2); !errors.Is(err, domain.ErrVersionConflict) {
+			t.Errorf("Update with stale version = %v, want %v", err, domain.ErrVersionConflict)
+		}
+		if err := repo.Delete(ctx, "h-1", 2); !errors.Is(err, domain.ErrVersionConflict) {
+			t.Errorf("Delete with stale version = %v, want %v", err, domain.ErrVersionConflict)
+		}
+
+		got, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Name != "Arthas" || got.Version != 1 {
+			t.Errorf("after rejected writes: %q version %d, want Arthas version 1", got.Name, got.Version)
+		}
+	})
+
+	// Muchos escritores leen la misma versión: el compare-and-swap deja pasar a UNO solo.
+	t.Run("concurrent updates", func(t *testing.T) {
+		repo := newRepo(t)
+		if err := repo.Save(ctx, newTestHero(t, "h-1", "Arthas")); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+
+		const writers = 8
+		var (
+			wg        sync.WaitGroup
+			won       atomic.Int32
+			conflicts atomic.Int32
+		)
+		for i := range writers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				hero := newTestHero(t, "h-1", fmt.Sprintf("Writer %d", i))
+				hero.Version = 2
+				err := repo.Update(ctx, hero, 1)
+				switch {
+				case err == nil:
+					won.Add(1)
+				case errors.Is(err, domain.ErrVersionConflict):
+					conflicts.Add(1)
+				default:
+					t.Errorf("Update: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if won.Load() != 1 || conflicts.Load() != writers-1 {
+			t.Errorf("%d writes won and %d conflicted, want 1 and %d", won.Load(), conflicts.Load(), writers-1)
+		}
+		got, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Version != 2 {
+			t.Errorf("Version = %d, want 2", got.Version)
+		}
+	})
+
+	// Lo que devuelve el repo es una copia: tocarla no cambia lo guardado.
+	t.Run("clone on read", func(t *testing.T) {
+		repo := newRepo(t)
+		hero := newTestHero(t, "h-1", "Arthas")
+		if err := hero.AddItem("health-potion", 5); err != nil {
+			t.Fatalf("AddItem: %v", err)
+		}
+		hero.Equipment = map[domain.ItemSlot]string{domain.SlotWeapon: "rusty-sword"}
+		if err := repo.Save(ctx, hero); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		hero.Inventory[0].Quantity = 99 // El llamador sigue usando su puntero después de Save
+		hero.Equipment[domain.SlotHead] = "leather-cap"
+
+		first, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		first.Name = "Changed"
+		first.Inventory[0].Quantity = 1
+		first.Equipment[domain.SlotWeapon] = "oak-staff"
+
+		listed, err := repo.List(ctx, ports.HeroQuery{})
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		listed.Heroes[0].Inventory[0].Quantity = 2
+
+		got, err := repo.Get(ctx, "h-1")
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		if got.Name != "Arthas" || got.Inventory[0].Quantity != 5 || len(got.Equipment) != 1 || got.Equipment[domain.SlotWeapon] != "rusty-sword" {
+			t.Errorf("stored hero changed through a returned pointer: %+v", got)
+		}
+	})
+
+	t.Run("missing hero", func(t *testing.T) {
+		repo := newRepo(t)
+		if _, err := repo.Get(ctx, "nope"); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Get = %v, want %v", err, domain.ErrNotFound)
+		}
+		if err := repo.Update(ctx, newTestHero(t, "nope", "Ghost"), 1); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Update = %v, want %v", err, domain.ErrNotFound)
+		}
+		if err := repo.Delete(ctx, "nope", 1); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Delete = %v, want %v", err, domain.ErrNotFound)
+		}
+	})
+}
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) ports.HeroRepository {
+		return NewMemory(discardLogger())
+	})
+}
+
+func TestSQLiteRepositoryContract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) ports.HeroRepository {
+		return openSQLite(t, filepath.Join(t.TempDir(), "heroes.db"))
+	})
+}
+
+// Todas las migraciones embebidas quedan registradas, una vez y en orden de nombre.
+func TestMigrationsAppliedInOrder(t *testing.T) {
+	repo := openSQLite(t, filepath.Join(t.TempDir(), "heroes.db"))
+
+	files, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	want := make([]string, 0, len(files))
+	for _, file := range files {
+		want = append(want, strings.TrimSuffix(strings.TrimPrefix(file, "migrations/"), ".sql"))
+	}
+	sort.Strings(want)
+
+	rows, err := repo.db.Query(`SELECT version FROM schema_migrations ORDER BY rowid`)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		got = append(got, version)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("schema_migrations = %v, want %v", got, want)
+	}
+}
+
+// Reabrir un archivo existente conserva los datos y no vuelve a aplicar migraciones.
+func TestSQLiteReopenExistingFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "heroes.db")
+
+	first, err := NewSQLite(path, discardLogger())
+	if err != nil {
+		t.Fatalf("NewSQLite: %v", err)
+	}
+	if err := first.Save(ctx, newTestHero(t, "h-1", "Arthas")); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	second := openSQLite(t, path)
+	got, err := second.Get(ctx, "h-1")
+	if err != nil {
+		t.Fatalf("Get after reopen: %v", err)
+	}
+	if got.Name != "Arthas" {
+		t.Errorf("Name = %q, want Arthas", got.Name)
+	}
+
+	var applied int
+	if err := second.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	files, _ := fs.Glob(migrationsFS, "migrations/*.sql")
+	if applied != len(files) {
+		t.Errorf("schema_migrations has %d rows after reopen, want %d", applied, len(files))
+	}
+}